@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"load-balancer/internal/lbctl"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := lbctl.Run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if lbctl.IsUsage(err) {
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "lbctl: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -1,25 +1,21 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"load-balancer/internal/api"
+	"load-balancer/internal/app"
 	"load-balancer/internal/config"
-	"load-balancer/internal/dashboard"
 	"load-balancer/internal/events"
-	"load-balancer/internal/health"
-	"load-balancer/internal/lb"
-	"load-balancer/internal/metrics"
-	"load-balancer/internal/server"
+	"load-balancer/internal/handoff"
+	"load-balancer/internal/lifecycle"
 	"load-balancer/internal/testserver"
 )
 
@@ -30,66 +26,20 @@ func main() {
 		log.Fatalf("Unable to load config: %v", err)
 	}
 
-	// 2. Initializing the server manager
-	srvMgr := initServerManager(cfg)
-
-	// 3. Create event system for real-time notifications
-	eventSystem := events.NewEventSystem(100) // Keep last 100 events
-
-	// 4. Create metrics manager for tracking load balancer performance
-	metricsManager := metrics.NewMetricsManager(srvMgr)
-
-	// 5. Created Weighted Round Robin, IP hash, sticky sessions
-	wrr := lb.NewWeightedRoundRobin(srvMgr)
-	ipHash := lb.NewIPHash(srvMgr)
-	stickyMgr := lb.NewStickySessions(srvMgr)
-
-	// 6. Created Balancer
-	balancer := lb.NewBalancer(srvMgr, wrr, ipHash, stickyMgr)
-	balancer.UseIPHash = cfg.UseIPHash
-	balancer.UseStickySessions = cfg.UseStickySessions
-
-	// 7. Setting up the circuit breaker
-	cbSettings := lb.CircuitBreakerSettings{
-		FailureThreshold: cfg.CircuitBreaker.FailureThreshold,
-		CooldownPeriod:   cfg.CircuitBreaker.CooldownPeriod,
-		TrialRequests:    cfg.CircuitBreaker.TrialRequests,
+	// Collect listeners and state if a previous process is handing over
+	inherited, err := handoff.Receive()
+	if err != nil {
+		log.Fatalf("Unable to take over from previous process: %v", err)
 	}
-	cbCoordinator := lb.NewCircuitBreakerCoordinator(srvMgr, cbSettings)
-
-	// Starting a background goroutine to monitor open circuits
-	go cbCoordinator.MonitorServers()
 
-	// 8. Starting the health checker
-	checker := health.NewChecker(cfg.HealthCheckInterval, srvMgr)
-	healthCtx, healthCancel := context.WithCancel(context.Background())
-	checker.Start(healthCtx)
-
-	// Log startup information
-	eventSystem.Publish(events.InfoEvent, "Load balancer starting up")
-	eventSystem.Publish(events.InfoEvent, fmt.Sprintf("Using IP Hash: %v, Sticky Sessions: %v",
-		cfg.UseIPHash, cfg.UseStickySessions))
-
-	// 9. Setup HTTP server to handle incoming requests
-	mux := http.NewServeMux()
-
-	// 9a. Load balancer endpoint
-	mux.HandleFunc("/lb/", func(w http.ResponseWriter, r *http.Request) {
-		// Strip the /lb prefix from the URL
-		r.URL.Path = r.URL.Path[3:]
-		if r.URL.Path == "" {
-			r.URL.Path = "/"
-		}
-
-		handleLoadBalancedRequest(balancer, w, r, cbCoordinator, metricsManager, eventSystem)
-	})
-
-	// 9b. Setup the dashboard API endpoints
-	apiHandler := api.NewAPI(srvMgr, balancer, cbCoordinator, metricsManager, eventSystem)
-	apiHandler.RegisterHandlers(mux)
-
-	// 9c. Setup the dashboard UI
-	mux.HandleFunc("/", dashboard.Handler(srvMgr))
+	// 2-9. Wire the balancer: events, upstream pools, routing table, metrics,
+	// snapshots, rate limiting, HA, clustering, the API and the dashboards
+	balancer, err := app.New(cfg, inherited)
+	if err != nil {
+		log.Fatalf("Unable to start load balancer: %v", err)
+	}
+	eventSystem := balancer.Events
+	lc := balancer.Lifecycle
 
 	// 10. Start test servers if enabled
 	var testServers []*testserver.TestServer
@@ -109,7 +59,9 @@ func main() {
 			},
 		}
 
-		testServers = testserver.StartTestServers(testServerConfigs)
+		testServers = testserver.StartTestServersWithListeners(testServerConfigs, func(id string) net.Listener {
+			return inherited.Listener(testServerListener(id))
+		})
 		log.Println("Started test servers")
 		eventSystem.Publish(events.SuccessEvent, "Test servers started successfully")
 	}
@@ -117,7 +69,15 @@ func main() {
 	// 11. Create and start the HTTP server
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.LBPort),
-		Handler: mux,
+		Handler: balancer.Handler,
+	}
+
+	listener := inherited.Listener(httpListener)
+	if listener == nil {
+		listener, err = net.Listen("tcp", srv.Addr)
+		if err != nil {
+			log.Fatalf("Unable to listen on port %d: %v", cfg.LBPort, err)
+		}
 	}
 
 	// Start server in a goroutine
@@ -126,262 +86,135 @@ func main() {
 		log.Printf("Dashboard available at http://localhost:%d/", cfg.LBPort)
 		eventSystem.Publish(events.SuccessEvent, fmt.Sprintf("Load balancer listening on port %d", cfg.LBPort))
 
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("HTTP server error: %v", err)
 		}
 	}()
-
-	// 12. Wait for interrupt signal to gracefully shutdown
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
-	<-stop
-
-	log.Println("Shutting down load balancer...")
-	eventSystem.Publish(events.InfoEvent, "Load balancer shutting down...")
-
-	healthCancel() // stop the health checker
-
-	ctxTimeout, cancelTimeout := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancelTimeout()
-
-	if err := srv.Shutdown(ctxTimeout); err != nil {
-		log.Fatalf("HTTP server Shutdown error: %v", err)
-	}
-
-	// Stop test servers if they were started
-	for _, ts := range testServers {
-		if err := ts.Stop(); err != nil {
-			log.Printf("Error stopping test server %s: %v", ts.Config.ID, err)
+	lc.MarkReady()
+	if inherited != nil {
+		if err := inherited.Ready(); err != nil {
+			log.Printf("Unable to signal previous process: %v", err)
 		}
+		eventSystem.Publish(events.SuccessEvent, "Took over listeners and state from previous process")
 	}
 
-	log.Println("Load balancer stopped.")
-	eventSystem.Publish(events.InfoEvent, "Load balancer stopped")
-}
-
-// initServerManager initializes the server manager with servers read from config.
-func initServerManager(cfg *config.Config) *server.Manager {
-	var servers []*server.Server
-	for _, s := range cfg.Servers {
-		servers = append(servers, &server.Server{
-			ID:                  s.ID,
-			Address:             s.Address,
-			Port:                s.Port,
-			CircuitBreakerState: server.CBStateClosed,
-			// Initial placeholders for metrics:
-			CPUUsage:     0.1,
-			MemUsage:     0.1,
-			ResponseTime: 0.1,
-			ErrorRate:    0.0,
-			PingStatus:   true,
-		})
-	}
-	return server.NewManager(servers)
-}
-
-func handleLoadBalancedRequest(balancer *lb.Balancer, w http.ResponseWriter, r *http.Request,
-	cbc *lb.CircuitBreakerCoordinator, mm *metrics.MetricsManager, es *events.EventSystem) {
-
-	totalServers := len(balancer.ServerManager.GetAllServers())
-	if totalServers == 0 {
-		es.Publish(events.ErrorEvent, "Request failed: No backend servers registered")
-		http.Error(w, "Service Unavailable (no backend servers)", http.StatusServiceUnavailable)
-		return
-	}
-
-	priority := lb.ExtractPriority(r)
-	requestID := mm.GeneratePacketID()
-	attempted := make(map[string]bool, totalServers)
-
-	var bodyBytes []byte
-	if r.Body != nil {
-		var err error
-		bodyBytes, err = io.ReadAll(r.Body)
-		if err != nil {
-			es.Publish(events.ErrorEvent, fmt.Sprintf("Failed to read request body: %v", err))
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
-			return
-		}
-		r.Body = io.NopCloser(bytes.NewReader(bodyBytes))
-	}
-
-	var lastErr error
-
-	for attempt := 0; attempt < totalServers; attempt++ {
-		srv := balancer.PickServerWithExclude(r, attempted)
-		if srv == nil {
-			break
-		}
-		attempted[srv.ID] = true
-
-		active := server.BeginRequest(srv)
-		dispatchEvent := metrics.PacketEvent{
-			RequestID:      requestID,
-			Attempt:        attempt + 1,
-			Priority:       priority,
-			ServerID:       srv.ID,
-			ServerAddress:  fmt.Sprintf("%s:%d", srv.Address, srv.Port),
-			Status:         "dispatch",
-			Timestamp:      time.Now(),
-			ActiveRequests: active,
-		}
-		mm.RecordAndBroadcastPacketEvent(es, dispatchEvent)
-
-		if active > lb.BusyThreshold {
-			activeAfter := server.EndRequest(srv)
-			rerouteEvent := dispatchEvent
-			rerouteEvent.Status = "rerouted"
-			rerouteEvent.Reason = "busy"
-			rerouteEvent.Timestamp = time.Now()
-			rerouteEvent.ActiveRequests = activeAfter
-			mm.RecordAndBroadcastPacketEvent(es, rerouteEvent)
-
-			es.Publish(events.WarningEvent, fmt.Sprintf("Server %s busy; rerouting request %s", srv.ID, requestID))
-			continue
-		}
-
-		start := time.Now()
-		result, err := forwardToBackend(srv, r, bodyBytes)
-		duration := time.Since(start)
-		responseMs := float64(duration.Milliseconds())
-
-		if err != nil {
-			activeAfter := server.EndRequest(srv)
-			cbc.RecordFailure(srv)
-			mm.RecordRequest(srv.ID, responseMs, true)
-
-			failureEvent := metrics.PacketEvent{
-				RequestID:      requestID,
-				Attempt:        attempt + 1,
-				Priority:       priority,
-				ServerID:       srv.ID,
-				ServerAddress:  fmt.Sprintf("%s:%d", srv.Address, srv.Port),
-				Status:         "failed",
-				Reason:         err.Error(),
-				Timestamp:      time.Now(),
-				ResponseTime:   responseMs,
-				ActiveRequests: activeAfter,
+	// 12. Wait for interrupt signal to gracefully shutdown, or for SIGUSR2 to
+	// hand the listeners to a freshly started binary. A second signal skips
+	// the remaining shutdown waits.
+	stop := make(chan os.Signal, 2)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	upgrade := make(chan os.Signal, 1)
+	handoff.Notify(upgrade)
+
+	preStopDelay := cfg.Shutdown.PreStopDelay
+	upgraded := false
+wait:
+	for {
+		select {
+		case <-stop:
+			break wait
+		case <-upgrade:
+			if balancer.HA != nil || balancer.Cluster != nil {
+				// Both processes would need the election or gossip sockets;
+				// upgrade by restarting one instance at a time instead.
+				log.Println("Upgrade requested but HA or clustering is enabled; ignoring")
+				eventSystem.Publish(events.WarningEvent, "In-place upgrade is disabled with HA or clustering; restart instances one at a time")
+				continue
 			}
-			mm.RecordAndBroadcastPacketEvent(es, failureEvent)
-			es.Publish(events.ErrorEvent, fmt.Sprintf("Request to %s failed: %v", srv.ID, err))
-
-			lastErr = err
-			continue
-		}
-
-		if result.StatusCode >= http.StatusInternalServerError {
-			activeAfter := server.EndRequest(srv)
-			cbc.RecordFailure(srv)
-			mm.RecordRequest(srv.ID, responseMs, true)
-
-			failureEvent := metrics.PacketEvent{
-				RequestID:      requestID,
-				Attempt:        attempt + 1,
-				Priority:       priority,
-				ServerID:       srv.ID,
-				ServerAddress:  fmt.Sprintf("%s:%d", srv.Address, srv.Port),
-				Status:         "failed",
-				Reason:         fmt.Sprintf("status %d", result.StatusCode),
-				Timestamp:      time.Now(),
-				ResponseTime:   responseMs,
-				ActiveRequests: activeAfter,
+			log.Println("Upgrade requested: starting new process")
+			eventSystem.Publish(events.InfoEvent, "Upgrade requested: handing listeners to a new process")
+
+			listeners := map[string]net.Listener{httpListener: listener}
+			for _, ts := range testServers {
+				if l := ts.Listener(); l != nil {
+					listeners[testServerListener(ts.Config.ID)] = l
+				}
 			}
-			mm.RecordAndBroadcastPacketEvent(es, failureEvent)
-			es.Publish(events.WarningEvent, fmt.Sprintf("Request to %s returned status %d", srv.ID, result.StatusCode))
-
-			lastErr = fmt.Errorf("backend status %d", result.StatusCode)
-			continue
-		}
-
-		cbc.RecordSuccess(srv)
-		mm.RecordRequest(srv.ID, responseMs, false)
-		activeAfter := server.EndRequest(srv)
-
-		successEvent := metrics.PacketEvent{
-			RequestID:      requestID,
-			Attempt:        attempt + 1,
-			Priority:       priority,
-			ServerID:       srv.ID,
-			ServerAddress:  fmt.Sprintf("%s:%d", srv.Address, srv.Port),
-			Status:         "completed",
-			Timestamp:      time.Now(),
-			ResponseTime:   responseMs,
-			ActiveRequests: activeAfter,
+			// Hand counters and event history over through the snapshot file,
+			// and the on-disk history with everything written up to now
+			if balancer.History != nil {
+				balancer.History.Suspend()
+			}
+			if err := balancer.Snapshots.Save(); err != nil {
+				log.Printf("Unable to save snapshot before upgrade: %v", err)
+			}
+			proc, err := handoff.Upgrade(cfg.Handoff.SocketPath, listeners, handoff.Capture(balancer.Routes), cfg.Handoff.ReadyTimeout)
+			if err != nil {
+				log.Printf("Upgrade failed, continuing to serve: %v", err)
+				if balancer.History != nil {
+					balancer.History.Resume()
+				}
+				eventSystem.Publish(events.ErrorEvent, fmt.Sprintf("Upgrade failed, continuing to serve: %v", err))
+				continue
+			}
+			log.Printf("New process %d is ready; draining this one", proc.Pid)
+			// The new process already accepts on the shared sockets, so there
+			// is no readiness window to wait out.
+			preStopDelay = 0
+			upgraded = true
+			balancer.StopSnapshots()
+			break wait
 		}
-		mm.RecordAndBroadcastPacketEvent(es, successEvent)
-
-		es.Publish(events.InfoEvent, fmt.Sprintf("Request %s served by %s in %.0fms", requestID, srv.ID, responseMs))
-
-		writeBackendResponse(w, result)
-		return
 	}
 
-	if lastErr == nil {
-		lastErr = fmt.Errorf("no healthy downstream servers")
-	}
-	es.Publish(events.ErrorEvent, fmt.Sprintf("Request %s failed: %v", requestID, lastErr))
-	http.Error(w, "Service Unavailable (no healthy servers)", http.StatusServiceUnavailable)
-}
-
-type backendResult struct {
-	StatusCode int
-	Header     http.Header
-	Body       []byte
-}
-
-func forwardToBackend(srv *server.Server, original *http.Request, body []byte) (*backendResult, error) {
-	url := fmt.Sprintf("http://%s:%d%s", srv.Address, srv.Port, original.URL.Path)
-	if raw := original.URL.RawQuery; raw != "" {
-		url = url + "?" + raw
-	}
+	log.Printf("Shutting down load balancer: readiness failing, pre-stop delay %v", preStopDelay)
+	eventSystem.Publish(events.InfoEvent, "Load balancer shutting down...")
 
-	var reqBody io.Reader
-	if body != nil {
-		reqBody = bytes.NewReader(body)
-	}
+	shutdownCtx, forceStop := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-stop:
+			log.Println("Second signal received; forcing shutdown")
+			forceStop()
+		case <-shutdownCtx.Done():
+		}
+	}()
 
-	req, err := http.NewRequestWithContext(original.Context(), original.Method, url, reqBody)
-	if err != nil {
-		return nil, err
+	// Hand the leader role to the standby before readiness starts failing
+	if balancer.HA != nil {
+		balancer.HA.Resign()
 	}
 
-	for k, vv := range original.Header {
-		for _, v := range vv {
-			req.Header.Set(k, v)
+	summary := lc.Shutdown(shutdownCtx, srv, lifecycle.ShutdownOptions{
+		PreStopDelay:  preStopDelay,
+		DrainDeadline: cfg.Shutdown.DrainDeadline,
+	}, func() {
+		// Stop health checkers, breaker monitors and canary analyses
+		balancer.StopLoops()
+	})
+	forceStop()
+	balancer.StopSnapshots()
+
+	// After an upgrade the new process owns the snapshot file
+	if !upgraded {
+		if err := balancer.Snapshots.Save(); err != nil {
+			log.Printf("Unable to save snapshot: %v", err)
+		} else if cfg.Snapshot.Path != "" {
+			log.Printf("Saved snapshot to %s", cfg.Snapshot.Path)
 		}
 	}
 
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	balancer.Upstreams.CloseAll()
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	// Stop test servers gracefully now that nothing is proxying to them
+	testCtx, cancelTest := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelTest()
+	for _, ts := range testServers {
+		if err := ts.Shutdown(testCtx); err != nil {
+			log.Printf("Error stopping test server %s: %v", ts.Config.ID, err)
+		}
 	}
 
-	return &backendResult{
-		StatusCode: resp.StatusCode,
-		Header:     resp.Header.Clone(),
-		Body:       respBody,
-	}, nil
+	log.Printf("Shutdown summary: %s", summary)
+	eventSystem.Publish(events.InfoEvent, fmt.Sprintf("Shutdown summary: %s", summary))
+	log.Println("Load balancer stopped.")
+	eventSystem.Publish(events.InfoEvent, "Load balancer stopped")
+	balancer.CloseHistory()
 }
 
-func writeBackendResponse(w http.ResponseWriter, result *backendResult) {
-	for k, vv := range result.Header {
-		for _, v := range vv {
-			w.Header().Add(k, v)
-		}
-	}
+// Names of the listeners passed to a new process on upgrade
+const httpListener = "http"
 
-	w.WriteHeader(result.StatusCode)
-	if len(result.Body) == 0 {
-		return
-	}
-	if _, err := w.Write(result.Body); err != nil {
-		log.Printf("Error writing response body: %v", err)
-	}
+func testServerListener(id string) string {
+	return "testserver:" + id
 }
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"load-balancer/internal/loadgen"
+)
+
+func main() {
+	var (
+		opts     loadgen.Options
+		mix      string
+		body     string
+		bodyFile string
+		jsonOut  string
+	)
+	flag.StringVar(&opts.Target, "target", "http://localhost:8080", "balancer base URL")
+	flag.StringVar(&opts.Path, "path", "/lb/", "request path")
+	flag.StringVar(&opts.Method, "method", "GET", "HTTP method")
+	flag.Float64Var(&opts.RPS, "rps", 50, "request rate (at the start of a ramp)")
+	flag.Float64Var(&opts.RampTo, "ramp-to", 0, "rate to ramp linearly to by the end of the run; 0 keeps -rps")
+	flag.DurationVar(&opts.Duration, "duration", 30*time.Second, "how long to send traffic")
+	flag.StringVar(&mix, "priority", "", `X-Task-Priority mix, e.g. "high=1,normal=3"`)
+	flag.IntVar(&opts.Sessions, "sessions", 0, "distinct session_id cookies to spread requests over")
+	flag.IntVar(&opts.ClientIPs, "client-ips", 0, "distinct X-Forwarded-For client IPs to spread requests over")
+	flag.StringVar(&body, "body", "", "request body")
+	flag.StringVar(&bodyFile, "body-file", "", "read the request body from a file")
+	flag.StringVar(&opts.ContentType, "content-type", "application/json", "Content-Type sent with a body")
+	flag.DurationVar(&opts.Timeout, "timeout", 10*time.Second, "per-request timeout")
+	flag.IntVar(&opts.MaxInFlight, "max-in-flight", 1000, "requests in flight before scheduled sends are dropped")
+	flag.Int64Var(&opts.Seed, "seed", 0, "seed for the session, client IP and priority draw")
+	flag.StringVar(&jsonOut, "json", "", `write the report as JSON to this file ("-" for stdout)`)
+	flag.Parse()
+
+	var err error
+	if mix != "" {
+		if opts.Priorities, err = loadgen.ParseMix(mix); err != nil {
+			log.Fatalf("Invalid -priority: %v", err)
+		}
+	}
+	switch {
+	case bodyFile != "":
+		if opts.Body, err = os.ReadFile(bodyFile); err != nil {
+			log.Fatalf("Unable to read body: %v", err)
+		}
+	case body != "":
+		opts.Body = []byte(body)
+	}
+
+	// Ctrl-C stops sending early and still prints the report
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := loadgen.Run(ctx, opts)
+	if report == nil {
+		log.Fatalf("Load run failed: %v", err)
+	}
+	if err != nil {
+		log.Printf("Load run stopped early: %v", err)
+	}
+
+	if jsonOut != "-" {
+		if err := report.WriteText(os.Stdout); err != nil {
+			log.Fatalf("Unable to write report: %v", err)
+		}
+	}
+	if jsonOut != "" {
+		out := os.Stdout
+		if jsonOut != "-" {
+			if out, err = os.Create(jsonOut); err != nil {
+				log.Fatalf("Unable to write JSON report: %v", err)
+			}
+			defer out.Close()
+		}
+		if err := report.WriteJSON(out); err != nil {
+			log.Fatalf("Unable to write JSON report: %v", err)
+		}
+		if jsonOut != "-" {
+			fmt.Fprintf(os.Stderr, "JSON report written to %s\n", jsonOut)
+		}
+	}
+}
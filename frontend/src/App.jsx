@@ -52,6 +52,19 @@ function App() {
         }
     };
 
+    const drainServer = async (server) => {
+        if (!server) return;
+        const draining = (server.AdminState || 'active') === 'active';
+        const action = draining ? 'drain' : 'activate';
+        try {
+            await fetch(`/api/servers/${server.ID}/${action}`, { method: 'POST' });
+            setStatusMessage(`${server.ID} ${draining ? 'draining' : 'back in rotation'}`);
+            fetchServers();
+        } catch (error) {
+            console.error(`Failed to ${action} server`, error);
+        }
+    };
+
     const handleScenarioComplete = (scenario) => {
         const labels = {
             failure: 'Failure scenario executed',
@@ -96,6 +109,7 @@ function App() {
                         servers={servers}
                         onToggleServer={toggleServer}
                         onResetServer={resetServer}
+                        onDrainServer={drainServer}
                     />
                     <MetricsChart servers={servers} />
                 </aside>
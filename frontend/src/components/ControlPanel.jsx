@@ -16,6 +16,38 @@ const PRIORITY_OPTIONS = [
     { value: 'normal', label: 'Normal' }
 ];
 
+// Starts a server-side scenario and follows its progress stream, resolving
+// with the final run status once the report is ready.
+const runServerScenario = async (name, onProgress) => {
+    const response = await fetch('/api/scenarios', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ name })
+    });
+    if (!response.ok) {
+        throw new Error((await response.text()).trim() || `HTTP ${response.status}`);
+    }
+    const run = await response.json();
+
+    return new Promise((resolve, reject) => {
+        const stream = new EventSource(`/api/scenarios/runs/${run.id}/events`);
+        stream.addEventListener('progress', (event) => onProgress(JSON.parse(event.data)));
+        stream.addEventListener('done', (event) => {
+            stream.close();
+            const status = JSON.parse(event.data);
+            if (status.state === 'failed') {
+                reject(new Error(status.error || 'scenario failed'));
+            } else {
+                resolve(status);
+            }
+        });
+        stream.onerror = () => {
+            stream.close();
+            reject(new Error('lost the scenario progress stream'));
+        };
+    });
+};
+
 const ControlPanel = ({ servers, onScenarioComplete, onRefreshConfig, onServerAdded, onStatusChange }) => {
     const [useIPHash, setUseIPHash] = useState(false);
     const [useSticky, setUseSticky] = useState(true);
@@ -163,37 +195,12 @@ const ControlPanel = ({ servers, onScenarioComplete, onRefreshConfig, onServerAd
         notifyStatus(`${label} running...`);
 
         try {
-            switch (scenario) {
-                case 'failure': {
-                    const target = onlineServers[0];
-                    await fetch(`/api/servers/${target.ID}/toggle`, { method: 'POST' });
-                    break;
-                }
-                case 'heavy': {
-                    const bursts = Array.from({ length: 15 }).map((_, idx) =>
-                        fetch(`/api/test?priority=${priority}&burst=${idx}`, { method: 'GET' })
-                    );
-                    await Promise.allSettled(bursts);
-                    break;
-                }
-                case 'priority': {
-                    const priorityBursts = Array.from({ length: 8 }).map(() =>
-                        fetch(`/api/test?priority=critical&burst=${Math.random()}`, { method: 'GET' })
-                    );
-                    await Promise.allSettled(priorityBursts);
-                    break;
-                }
-                case 'recovery': {
-                    await Promise.all(
-                        servers.map((srv) =>
-                            fetch(`/api/servers/${srv.ID}/reset`, { method: 'POST' })
-                        )
-                    );
-                    break;
-                }
-                default:
-                    break;
-            }
+            const status = await runServerScenario(scenario, (progress) =>
+                notifyStatus(`${label}: ${progress.requests} requests, ${progress.errors} errors`)
+            );
+            const report = status.report || {};
+            const p95 = Math.round((report.latency && report.latency.p95) || 0);
+            notifyStatus(`${label} ${status.state}: ${report.requests || 0} requests, ${report.errors || 0} errors, p95 ${p95}ms`);
 
             if (onScenarioComplete) {
                 onScenarioComplete(scenario);
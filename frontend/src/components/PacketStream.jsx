@@ -36,6 +36,7 @@ function groupEvents(events) {
             map.set(id, {
                 id,
                 priority: evt.priority || 'normal',
+                synthetic: Boolean(evt.synthetic),
                 attempts: [],
                 lastUpdated: evt.timestamp
             });
@@ -107,6 +108,9 @@ function PacketStream() {
                                 <span className={`priority-tag priority-${request.priority || 'normal'}`}>
                                     {PRIORITY_LABELS[request.priority] || 'Normal'} Priority
                                 </span>
+                                {request.synthetic && (
+                                    <span className="packet-synthetic">Synthetic</span>
+                                )}
                                 <span className="packet-updated">{formatTime(request.lastUpdated)}</span>
                             </div>
 
@@ -1,6 +1,6 @@
 import React from 'react';
 
-const ServerList = ({ servers, onToggleServer, onResetServer }) => {
+const ServerList = ({ servers, onToggleServer, onResetServer, onDrainServer }) => {
     return (
         <div className="server-list panel">
             <h2>Server Fabric</h2>
@@ -12,13 +12,14 @@ const ServerList = ({ servers, onToggleServer, onResetServer }) => {
                         <th>Health</th>
                         <th>Active</th>
                         <th>Breaker</th>
+                        <th>State</th>
                         <th></th>
                     </tr>
                 </thead>
                 <tbody>
                     {servers.length === 0 && (
                         <tr className="empty-row">
-                            <td colSpan="6">No servers registered</td>
+                            <td colSpan="7">No servers registered</td>
                         </tr>
                     )}
                     {servers.map(server => (
@@ -36,6 +37,11 @@ const ServerList = ({ servers, onToggleServer, onResetServer }) => {
                                     {['Closed', 'Open', 'Half-Open'][server.CircuitBreakerState]}
                                 </span>
                             </td>
+                            <td>
+                                <span className={`status-chip ${server.AdminState || 'active'}`}>
+                                    {server.AdminState || 'active'}
+                                </span>
+                            </td>
                             <td>
                                 <div className="server-actions-inline">
                                     <button
@@ -44,6 +50,12 @@ const ServerList = ({ servers, onToggleServer, onResetServer }) => {
                                     >
                                         {server.PingStatus ? 'Disable' : 'Enable'}
                                     </button>
+                                    <button
+                                        className="cyber-button ghost"
+                                        onClick={() => onDrainServer(server)}
+                                    >
+                                        {(server.AdminState || 'active') === 'active' ? 'Drain' : 'Activate'}
+                                    </button>
                                     <button
                                         className="cyber-button ghost"
                                         onClick={() => onResetServer(server)}
@@ -1,6 +1,7 @@
 import { useEffect, useRef, useState } from 'react';
 
 let eventSource = null;
+let lastEventId = null;
 const subscribers = new Set();
 
 function startEventSource() {
@@ -8,20 +9,26 @@ function startEventSource() {
         return;
     }
 
-    eventSource = new EventSource('/api/events');
-    eventSource.onmessage = (event) => {
+    // Resume after the last packet seen, so a reconnect replays the gap
+    const resume = lastEventId ? `&lastEventId=${lastEventId}` : '';
+    eventSource = new EventSource(`/api/events?types=packet${resume}`);
+    eventSource.addEventListener('packet', (event) => {
         try {
+            lastEventId = event.lastEventId || lastEventId;
             const payload = JSON.parse(event.data);
-            if (payload.type !== 'packet') {
+            if (!payload.data) {
                 return;
             }
-            const packet = JSON.parse(payload.message);
+            const packet = { ...payload.data };
             packet.timestamp = packet.timestamp || payload.timestamp;
             subscribers.forEach((cb) => cb(packet));
         } catch (error) {
             console.error('Failed to parse packet event', error);
         }
-    };
+    });
+    eventSource.addEventListener('overflow', () => {
+        console.warn('Packet feed fell behind; reconnecting to catch up');
+    });
 
     eventSource.onerror = () => {
         if (eventSource) {
@@ -4,15 +4,23 @@ package api
 import (
 	"encoding/json"
 	"fmt"
-	"math/rand"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"load-balancer/internal/canary"
+	"load-balancer/internal/cluster"
 	"load-balancer/internal/events"
+	"load-balancer/internal/ha"
+	"load-balancer/internal/history"
 	"load-balancer/internal/lb"
+	"load-balancer/internal/lifecycle"
 	"load-balancer/internal/metrics"
+	"load-balancer/internal/router"
+	"load-balancer/internal/scenario"
 	"load-balancer/internal/server"
+	"load-balancer/internal/snapshot"
 )
 
 // API handles all the dashboard API endpoints
@@ -22,6 +30,35 @@ type API struct {
 	CircuitBreaker *lb.CircuitBreakerCoordinator
 	MetricsManager *metrics.MetricsManager
 	EventSystem    *events.EventSystem
+	Router         *router.Table
+	Canary         *canary.Analyzer
+
+	// DrainTimeout bounds how long a drain waits for in-flight requests
+	DrainTimeout time.Duration
+
+	// Lifecycle, when set, ends event streams once shutdown starts draining
+	Lifecycle *lifecycle.Lifecycle
+
+	// Snapshots, when set, exposes export and import of runtime state
+	Snapshots *snapshot.Manager
+
+	// HA, when set, reports this instance's leader election role
+	HA *ha.Node
+
+	// Cluster, when set, reports gossip membership and shared state
+	Cluster *cluster.Node
+
+	// Scenarios, when set, runs chaos and load drills server-side
+	Scenarios *scenario.Engine
+
+	// History, when set, serves stored events and packets from /api/history
+	// and reaches back past the in-memory packet history for timelines
+	History *history.Store
+
+	// Probe is the /lb/ dispatcher /api/test sends synthetic probes through,
+	// to ProbePath; without it /api/test is unavailable
+	Probe     http.Handler
+	ProbePath string
 }
 
 // Config represents the load balancer configuration that can be updated via API
@@ -30,6 +67,13 @@ type Config struct {
 	UseStickySessions bool `json:"useStickySessions"`
 }
 
+// ServerInfo is a server as reported by /api/servers, with its current
+// slow-start ramp factor (1 once the server takes its full share)
+type ServerInfo struct {
+	*server.Server
+	RampFactor float64 `json:"rampFactor"`
+}
+
 // ServerToggleResponse is returned when toggling a server's status
 type ServerToggleResponse struct {
 	ID      string `json:"id"`
@@ -39,8 +83,11 @@ type ServerToggleResponse struct {
 // TestResponse is returned from the test endpoint
 type TestResponse struct {
 	Server       string    `json:"server"`
+	Status       int       `json:"status"`
+	Path         string    `json:"path"`
 	ResponseTime int       `json:"responseTime"`
 	Timestamp    time.Time `json:"timestamp"`
+	Synthetic    bool      `json:"synthetic"`
 }
 
 // NewAPI creates a new API handler
@@ -64,6 +111,49 @@ func (api *API) RegisterHandlers(mux *http.ServeMux) {
 	// Configuration endpoint
 	mux.HandleFunc("/api/config", api.updateConfig)
 
+	// Routing table and backend pools
+	mux.HandleFunc("/api/routes", api.handleRoutes)
+	mux.HandleFunc("/api/routes/", api.handleRoute)
+	mux.HandleFunc("/api/pools", api.handlePools)
+	mux.HandleFunc("/api/pools/", api.handlePool)
+
+	// Automated canary analysis
+	if api.Canary != nil {
+		mux.HandleFunc("/api/canaries", api.handleCanaries)
+		mux.HandleFunc("/api/canaries/", api.handleCanary)
+	}
+
+	// Runtime state export and import
+	if api.Snapshots != nil {
+		mux.HandleFunc("/api/snapshot", api.handleSnapshot)
+	}
+
+	// Leader election status
+	if api.HA != nil {
+		mux.HandleFunc("/api/ha", api.getHAStatus)
+	}
+
+	// Gossip cluster status
+	if api.Cluster != nil {
+		mux.HandleFunc("/api/cluster", api.getClusterStatus)
+	}
+
+	// Stored events and packets
+	if api.History != nil {
+		mux.HandleFunc("/api/history", api.getHistory)
+	}
+
+	// Chaos and load drills
+	if api.Scenarios != nil {
+		mux.HandleFunc("/api/scenarios", api.handleScenarios)
+		mux.HandleFunc("/api/scenarios/runs/", api.handleScenarioRun)
+	}
+
+	// Versioned API with stable types, problem+json errors and ETags
+	if api.Router != nil {
+		mux.Handle("/api/v1/", api.v1Handler())
+	}
+
 	// Test endpoint
 	mux.HandleFunc("/api/test", api.handleTest)
 
@@ -75,6 +165,7 @@ func (api *API) RegisterHandlers(mux *http.ServeMux) {
 
 	// Server-sent events for realtime updates
 	mux.HandleFunc("/api/events", api.handleEvents)
+	mux.HandleFunc("/api/events/subscribers", api.getEventSubscribers)
 }
 
 // getServers returns information about all servers
@@ -84,10 +175,30 @@ func (api *API) getServers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	servers := api.ServerManager.GetAllServers()
+	mgr, balancer := api.ServerManager, api.Balancer
+	if name := r.URL.Query().Get("pool"); name != "" && api.Router != nil {
+		pool := api.Router.Pool(name)
+		if pool == nil {
+			http.Error(w, "Pool not found", http.StatusNotFound)
+			return
+		}
+		mgr, balancer = pool.Manager, pool.Balancer
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(servers)
+	json.NewEncoder(w).Encode(serverInfos(mgr.GetAllServers(), balancer))
+}
+
+// serverInfos annotates servers with the ramp factor the balancer applies
+func serverInfos(servers []*server.Server, balancer *lb.Balancer) []ServerInfo {
+	infos := make([]ServerInfo, len(servers))
+	for i, srv := range servers {
+		infos[i] = ServerInfo{Server: server.Snapshot(srv), RampFactor: 1}
+		if balancer != nil {
+			infos[i].RampFactor = balancer.RampFactor(srv)
+		}
+	}
+	return infos
 }
 
 // handleServerRequests manages all endpoints under /api/servers/...
@@ -108,21 +219,15 @@ func (api *API) handleServerRequests(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Validate the server ID
-	serverFound := false
-	var targetServer *server.Server
-
-	servers := api.ServerManager.GetAllServers()
-	for _, srv := range servers {
-		if srv.ID == serverID {
-			serverFound = true
-			targetServer = srv
-			break
-		}
+	targetServer, balancer := api.findServer(serverID)
+	if targetServer == nil {
+		http.Error(w, "Server not found", http.StatusNotFound)
+		return
 	}
 
-	if !serverFound {
-		http.Error(w, "Server not found", http.StatusNotFound)
+	// Fault injection is relayed to the backend itself
+	if action == "faults" || strings.HasPrefix(action, "faults/") {
+		api.handleServerFaults(w, r, targetServer, strings.TrimPrefix(strings.TrimPrefix(action, "faults"), "/"))
 		return
 	}
 
@@ -132,6 +237,12 @@ func (api *API) handleServerRequests(w http.ResponseWriter, r *http.Request) {
 		api.toggleServer(w, r, targetServer)
 	case "reset":
 		api.resetServer(w, r, targetServer)
+	case "drain":
+		api.drainServer(w, r, targetServer, balancer)
+	case "maintenance":
+		api.maintainServer(w, r, targetServer, balancer)
+	case "activate":
+		api.activateServer(w, r, targetServer, balancer)
 	default:
 		http.Error(w, "Unknown action", http.StatusBadRequest)
 	}
@@ -144,42 +255,12 @@ func (api *API) toggleServer(w http.ResponseWriter, r *http.Request, srv *server
 		return
 	}
 
-	wasEnabled := srv.PingStatus
-	srv.PingStatus = !srv.PingStatus
-
-	if srv.PingStatus {
-		// Restoring connectivity: close breaker and reset counters
-		srv.CircuitBreakerState = server.CBStateClosed
-		srv.FailureCount = 0
-		srv.TrialSuccessCount = 0
-	} else {
-		// Disabling the server: trip breaker so it is skipped by balancer
-		srv.CircuitBreakerState = server.CBStateOpen
-		srv.OpenSince = time.Now()
-		srv.FailureCount = 0
-		srv.TrialSuccessCount = 0
-		for server.GetActiveRequests(srv) > 0 {
-			server.EndRequest(srv)
-		}
-	}
-
-	statusText := "enabled"
-	eventType := events.SuccessEvent
-	if !srv.PingStatus {
-		statusText = "disabled"
-		eventType = events.WarningEvent
-	}
-
-	if wasEnabled == srv.PingStatus {
-		statusText = "unchanged"
-		eventType = events.InfoEvent
-	}
-
-	api.EventSystem.Publish(eventType, fmt.Sprintf("Server %s %s", srv.ID, statusText))
+	enabled := !server.IsUp(srv)
+	api.setServerEnabled(srv, enabled)
 
 	response := ServerToggleResponse{
 		ID:      srv.ID,
-		Enabled: srv.PingStatus,
+		Enabled: enabled,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -193,17 +274,7 @@ func (api *API) resetServer(w http.ResponseWriter, r *http.Request, srv *server.
 		return
 	}
 
-	// Reset the circuit breaker state to closed
-	srv.CircuitBreakerState = server.CBStateClosed
-	srv.FailureCount = 0
-	srv.TrialSuccessCount = 0
-	srv.PingStatus = true
-	for server.GetActiveRequests(srv) > 0 {
-		server.EndRequest(srv)
-	}
-
-	// Send event notification
-	api.EventSystem.Publish(events.InfoEvent, fmt.Sprintf("Server %s circuit breaker reset", srv.ID))
+	api.resetBreaker(srv)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
@@ -212,6 +283,100 @@ func (api *API) resetServer(w http.ResponseWriter, r *http.Request, srv *server.
 	})
 }
 
+// findServer looks a server up in any pool, returning it with the balancer
+// that routes to it; nil if there is no such server.
+func (api *API) findServer(serverID string) (*server.Server, *lb.Balancer) {
+	servers := api.ServerManager.GetAllServers()
+	balancer := api.Balancer
+	if api.Router != nil {
+		if pool := api.Router.FindServer(serverID); pool != nil {
+			servers = pool.Manager.GetAllServers()
+			balancer = pool.Balancer
+		}
+	}
+	for _, srv := range servers {
+		if srv.ID == serverID {
+			return srv, balancer
+		}
+	}
+	return nil, nil
+}
+
+// setServerEnabled restores or cuts a server's connectivity
+func (api *API) setServerEnabled(srv *server.Server, enabled bool) {
+	var wasEnabled bool
+	server.Update(srv, func(s *server.Server) {
+		wasEnabled = s.PingStatus
+		s.PingStatus = enabled
+		s.FailureCount = 0
+		s.TrialSuccessCount = 0
+		if enabled {
+			// Restoring connectivity: close breaker and reset counters
+			s.CircuitBreakerState = server.CBStateClosed
+		} else {
+			// Disabling the server: trip breaker so it is skipped by balancer
+			// In-flight requests finish and release their own ActiveRequests slot;
+			// use the drain action to wait for them gracefully.
+			s.CircuitBreakerState = server.CBStateOpen
+			s.OpenSince = time.Now()
+		}
+	})
+	if enabled {
+		server.BeginSlowStart(srv)
+	}
+
+	statusText := "enabled"
+	eventType := events.SuccessEvent
+	if !enabled {
+		statusText = "disabled"
+		eventType = events.WarningEvent
+	}
+
+	if wasEnabled == enabled {
+		statusText = "unchanged"
+		eventType = events.InfoEvent
+	}
+
+	api.serverEvent(eventType, srv.ID, fmt.Sprintf("Server %s %s", srv.ID, statusText))
+}
+
+// resetBreaker closes a server's circuit breaker and re-enables it
+func (api *API) resetBreaker(srv *server.Server) {
+	server.Update(srv, func(s *server.Server) {
+		s.CircuitBreakerState = server.CBStateClosed
+		s.FailureCount = 0
+		s.TrialSuccessCount = 0
+		s.PingStatus = true
+	})
+	server.BeginSlowStart(srv)
+
+	api.EventSystem.Emit(events.Event{
+		Type:     events.BreakerEvent,
+		Severity: events.SeverityInfo,
+		Source:   "api",
+		ServerID: srv.ID,
+		Message:  fmt.Sprintf("Server %s circuit breaker reset", srv.ID),
+	})
+}
+
+// getHAStatus reports this instance's role, term and peers.
+func (api *API) getHAStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, api.HA.Status())
+}
+
+// getClusterStatus reports gossip membership and shared entry counts.
+func (api *API) getClusterStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, api.Cluster.Status())
+}
+
 // getPackets returns the recent packet flow events for visualization.
 func (api *API) getPackets(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -219,6 +384,11 @@ func (api *API) getPackets(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if requestID := r.URL.Query().Get("requestId"); requestID != "" {
+		api.getPacketTimeline(w, r, requestID)
+		return
+	}
+
 	limit := 100
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
@@ -226,7 +396,25 @@ func (api *API) getPackets(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	events := api.MetricsManager.GetPacketHistory(limit)
+	// synthetic=false drops /api/test probes, synthetic=true keeps only them
+	events := api.MetricsManager.GetPacketHistory(0)
+	if raw := r.URL.Query().Get("synthetic"); raw != "" {
+		want, err := strconv.ParseBool(raw)
+		if err != nil {
+			http.Error(w, "Invalid synthetic filter", http.StatusBadRequest)
+			return
+		}
+		kept := events[:0]
+		for _, evt := range events {
+			if evt.Synthetic == want {
+				kept = append(kept, evt)
+			}
+		}
+		events = kept
+	}
+	if len(events) > limit {
+		events = events[len(events)-limit:]
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(struct {
@@ -250,8 +438,8 @@ func (api *API) updateConfig(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update the balancer configuration
-	api.Balancer.UseIPHash = config.UseIPHash
-	api.Balancer.UseStickySessions = config.UseStickySessions
+	api.Balancer.SetIPHash(config.UseIPHash)
+	api.Balancer.SetStickySessions(config.UseStickySessions)
 
 	// Send event notification
 	api.EventSystem.Publish(events.InfoEvent, fmt.Sprintf(
@@ -263,170 +451,9 @@ func (api *API) updateConfig(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(config)
 }
 
-// handleTest simulates a request to test the load balancer
-func (api *API) handleTest(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	totalServers := len(api.ServerManager.GetAllServers())
-	if totalServers == 0 {
-		http.Error(w, "No server available", http.StatusServiceUnavailable)
-		api.EventSystem.Publish(events.ErrorEvent, "Test request failed: no servers registered")
-		return
-	}
-
-	priority := lb.ExtractPriority(r)
-	requestID := api.MetricsManager.GeneratePacketID()
-	attempted := make(map[string]bool, totalServers)
-	var lastErr error
-
-	for attempt := 0; attempt < totalServers; attempt++ {
-		srv := api.Balancer.PickServerWithExclude(r, attempted)
-		if srv == nil {
-			break
-		}
-		attempted[srv.ID] = true
-
-		active := server.BeginRequest(srv)
-		dispatchEvent := metrics.PacketEvent{
-			RequestID:      requestID,
-			Attempt:        attempt + 1,
-			Priority:       priority,
-			ServerID:       srv.ID,
-			ServerAddress:  fmt.Sprintf("%s:%d", srv.Address, srv.Port),
-			Status:         "dispatch",
-			Timestamp:      time.Now(),
-			ActiveRequests: active,
-		}
-		api.MetricsManager.RecordAndBroadcastPacketEvent(api.EventSystem, dispatchEvent)
-
-		if active > lb.BusyThreshold {
-			activeAfter := server.EndRequest(srv)
-			rerouteEvent := dispatchEvent
-			rerouteEvent.Status = "rerouted"
-			rerouteEvent.Reason = "busy"
-			rerouteEvent.Timestamp = time.Now()
-			rerouteEvent.ActiveRequests = activeAfter
-			api.MetricsManager.RecordAndBroadcastPacketEvent(api.EventSystem, rerouteEvent)
-			continue
-		}
-
-		processing := time.Duration(50+rand.Intn(200)) * time.Millisecond
-		time.Sleep(processing)
-		responseMs := float64(processing.Milliseconds())
-
-		failureChance := 0.05 + srv.ErrorRate
-		if failureChance > 0.4 {
-			failureChance = 0.4
-		}
-
-		if rand.Float64() < failureChance {
-			activeAfter := server.EndRequest(srv)
-			api.CircuitBreaker.RecordFailure(srv)
-			api.MetricsManager.RecordRequest(srv.ID, responseMs, true)
-
-			failureEvent := metrics.PacketEvent{
-				RequestID:      requestID,
-				Attempt:        attempt + 1,
-				Priority:       priority,
-				ServerID:       srv.ID,
-				ServerAddress:  fmt.Sprintf("%s:%d", srv.Address, srv.Port),
-				Status:         "failed",
-				Reason:         "simulated failure",
-				Timestamp:      time.Now(),
-				ResponseTime:   responseMs,
-				ActiveRequests: activeAfter,
-			}
-			api.MetricsManager.RecordAndBroadcastPacketEvent(api.EventSystem, failureEvent)
-			api.EventSystem.Publish(events.WarningEvent, fmt.Sprintf("Simulated failure on %s for request %s", srv.ID, requestID))
-			lastErr = fmt.Errorf("simulated failure")
-			continue
-		}
-
-		api.CircuitBreaker.RecordSuccess(srv)
-		api.MetricsManager.RecordRequest(srv.ID, responseMs, false)
-		activeAfter := server.EndRequest(srv)
-
-		successEvent := metrics.PacketEvent{
-			RequestID:      requestID,
-			Attempt:        attempt + 1,
-			Priority:       priority,
-			ServerID:       srv.ID,
-			ServerAddress:  fmt.Sprintf("%s:%d", srv.Address, srv.Port),
-			Status:         "completed",
-			Timestamp:      time.Now(),
-			ResponseTime:   responseMs,
-			ActiveRequests: activeAfter,
-		}
-		api.MetricsManager.RecordAndBroadcastPacketEvent(api.EventSystem, successEvent)
-
-		response := TestResponse{
-			Server:       srv.ID,
-			ResponseTime: int(responseMs),
-			Timestamp:    time.Now(),
-		}
-
-		api.EventSystem.Publish(events.InfoEvent, fmt.Sprintf("Test request %s served by %s (%s priority)", requestID, srv.ID, priority))
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
-		return
-	}
-
-	if lastErr == nil {
-		lastErr = fmt.Errorf("all servers busy")
-	}
-	api.EventSystem.Publish(events.ErrorEvent, fmt.Sprintf("Test request %s failed: %v", requestID, lastErr))
-	http.Error(w, "No server available", http.StatusServiceUnavailable)
-}
-
-// handleEvents sets up a Server-Sent Events connection
-func (api *API) handleEvents(w http.ResponseWriter, r *http.Request) {
-	// Set headers for SSE
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-
-	// Create a channel for this client by subscribing to the event system
-	subscriber := api.EventSystem.Subscribe()
-
-	// Make sure to unsubscribe when the client disconnects
-	defer api.EventSystem.Unsubscribe(subscriber)
-
-	// Send welcome event
-	api.EventSystem.Publish(events.InfoEvent, "Connected to event stream")
-
-	// Create notification channel for client disconnection
-	notify := r.Context().Done()
-
-	// Keep connection open
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
-		return
-	}
-
-	for {
-		select {
-		case <-notify:
-			return // Client disconnected
-		case msg, ok := <-subscriber:
-			if !ok {
-				return // Channel closed
-			}
-
-			// Write the event to the response
-			fmt.Fprintf(w, "data: %s\n\n", msg)
-			flusher.Flush()
-		case <-time.After(30 * time.Second):
-			// Send a keepalive comment
-			fmt.Fprintf(w, ": keepalive\n\n")
-			flusher.Flush()
-		}
-	}
+// serverEvent publishes an admin API event about one server
+func (api *API) serverEvent(eventType events.EventType, serverID, message string) {
+	api.EventSystem.Emit(events.Event{Type: eventType, Source: "api", ServerID: serverID, Message: message})
 }
 
 // boolToString converts a boolean to "enabled" or "disabled"
@@ -0,0 +1,63 @@
+// internal/api/canary.go
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"load-balancer/internal/canary"
+)
+
+// handleCanaries lists analyses (GET) or starts one on a split route (POST)
+func (api *API) handleCanaries(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		statuses := api.Canary.List()
+		sort.Slice(statuses, func(i, j int) bool { return statuses[i].Config.Route < statuses[j].Config.Route })
+		writeJSON(w, http.StatusOK, statuses)
+	case http.MethodPost:
+		var cfg canary.Config
+		if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil || cfg.Route == "" {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		status, err := api.Canary.Start(cfg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusCreated, status)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleCanary reports (GET) or aborts (DELETE) the analysis of one route
+func (api *API) handleCanary(w http.ResponseWriter, r *http.Request) {
+	route := strings.TrimPrefix(r.URL.Path, "/api/canaries/")
+
+	switch r.Method {
+	case http.MethodGet:
+		status, ok := api.Canary.Status(route)
+		if !ok {
+			http.Error(w, "Canary analysis not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, status)
+	case http.MethodDelete:
+		status, err := api.Canary.Abort(route)
+		if err != nil {
+			if _, ok := api.Canary.Status(route); !ok {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, status)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
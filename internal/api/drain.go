@@ -0,0 +1,113 @@
+// internal/api/drain.go
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"load-balancer/internal/events"
+	"load-balancer/internal/lb"
+	"load-balancer/internal/server"
+)
+
+// ServerStateResponse reports a server's admin state after a drain,
+// maintenance or activate request
+type ServerStateResponse struct {
+	ID             string            `json:"id"`
+	AdminState     server.AdminState `json:"adminState"`
+	ActiveRequests int64             `json:"activeRequests"`
+}
+
+// drainServer stops new traffic to a server and waits for in-flight requests.
+// An optional ?timeout=45s overrides the configured drain timeout.
+func (api *API) drainServer(w http.ResponseWriter, r *http.Request, srv *server.Server, balancer *lb.Balancer) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	timeout := api.DrainTimeout
+	if raw := r.URL.Query().Get("timeout"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			http.Error(w, "Invalid timeout", http.StatusBadRequest)
+			return
+		}
+		timeout = parsed
+	}
+
+	if !api.startDrain(srv, balancer, timeout) {
+		http.Error(w, fmt.Sprintf("Server %s is already %s", srv.ID, server.GetAdminState(srv)), http.StatusConflict)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, serverState(srv))
+}
+
+// startDrain begins draining a server, publishing an event when the drain
+// starts and when it ends. It reports false if the server was not active.
+func (api *API) startDrain(srv *server.Server, balancer *lb.Balancer, timeout time.Duration) bool {
+	started := balancer.Drain(srv, timeout, func(result lb.DrainResult) {
+		switch {
+		case result.Cancelled:
+			api.serverEvent(events.InfoEvent, result.ServerID, fmt.Sprintf("Drain of server %s cancelled after %v",
+				result.ServerID, result.Elapsed.Round(time.Millisecond)))
+		case result.Completed:
+			api.serverEvent(events.SuccessEvent, result.ServerID, fmt.Sprintf("Server %s drained in %v; now in maintenance",
+				result.ServerID, result.Elapsed.Round(time.Millisecond)))
+		default:
+			api.serverEvent(events.WarningEvent, result.ServerID, fmt.Sprintf("Drain of server %s timed out with %d requests in flight; now in maintenance",
+				result.ServerID, result.InFlight))
+		}
+	})
+	if started {
+		api.serverEvent(events.WarningEvent, srv.ID, fmt.Sprintf("Draining server %s (%d in flight, timeout %v)",
+			srv.ID, server.GetActiveRequests(srv), timeout))
+	}
+	return started
+}
+
+// maintainServer takes a server out of rotation immediately
+func (api *API) maintainServer(w http.ResponseWriter, r *http.Request, srv *server.Server, balancer *lb.Balancer) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	api.enterMaintenance(srv, balancer)
+	writeJSON(w, http.StatusOK, serverState(srv))
+}
+
+// enterMaintenance takes a server out of rotation and says so
+func (api *API) enterMaintenance(srv *server.Server, balancer *lb.Balancer) {
+	balancer.EnterMaintenance(srv)
+	api.serverEvent(events.WarningEvent, srv.ID, fmt.Sprintf("Server %s in maintenance (%d requests still in flight)",
+		srv.ID, server.GetActiveRequests(srv)))
+}
+
+// activateServer returns a drained or maintenance server to rotation
+func (api *API) activateServer(w http.ResponseWriter, r *http.Request, srv *server.Server, balancer *lb.Balancer) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	api.activate(srv, balancer)
+	writeJSON(w, http.StatusOK, serverState(srv))
+}
+
+// activate returns a server to rotation, announcing it if it was out
+func (api *API) activate(srv *server.Server, balancer *lb.Balancer) {
+	if balancer.Activate(srv) {
+		api.serverEvent(events.SuccessEvent, srv.ID, fmt.Sprintf("Server %s back in rotation", srv.ID))
+	}
+}
+
+func serverState(srv *server.Server) ServerStateResponse {
+	return ServerStateResponse{
+		ID:             srv.ID,
+		AdminState:     server.GetAdminState(srv),
+		ActiveRequests: server.GetActiveRequests(srv),
+	}
+}
@@ -0,0 +1,166 @@
+// internal/api/events.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"load-balancer/internal/events"
+)
+
+// sseRetry is the reconnect delay suggested to EventSource clients
+const sseRetry = 2 * time.Second
+
+// StreamNotice is the data of the stream's own "gap" and "overflow" events
+type StreamNotice struct {
+	Message     string `json:"message"`
+	LastEventID uint64 `json:"lastEventId,omitempty"`
+	Dropped     uint64 `json:"dropped,omitempty"`
+}
+
+// handleEvents sets up a Server-Sent Events connection. The query can narrow
+// the stream, e.g. ?types=packet,breaker&server=server-1&minSeverity=warning.
+// Each event is sent with its ID and its type as the SSE event name; a client
+// reconnecting with Last-Event-ID (or ?lastEventId=) first gets what it
+// missed from the retained log. A client that falls behind is sent an
+// "overflow" event and disconnected, so it can reconnect and catch up.
+func (api *API) handleEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := events.ParseFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	lastID, resuming, err := lastEventID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Set headers for SSE
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	// Create a channel for this client by subscribing to the event system,
+	// replaying what a returning client missed
+	var subscriber events.Subscriber
+	var replay []string
+	complete := true
+	if resuming {
+		subscriber, replay, complete = api.EventSystem.Resume(filter, lastID)
+	} else {
+		subscriber = api.EventSystem.SubscribeStream(filter)
+	}
+
+	// Make sure to unsubscribe when the client disconnects
+	defer api.EventSystem.Unsubscribe(subscriber)
+
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+	if !complete {
+		writeNotice(w, "gap", StreamNotice{
+			Message:     "Some events since the last one received are no longer retained",
+			LastEventID: lastID,
+		})
+	}
+	for _, msg := range replay {
+		lastID = writeEvent(w, msg)
+	}
+	flusher.Flush()
+
+	// Send welcome event
+	if !resuming {
+		api.EventSystem.Publish(events.InfoEvent, "Connected to event stream")
+	}
+
+	// Create notification channel for client disconnection
+	notify := r.Context().Done()
+
+	// Streams never finish on their own, so end them when shutdown drains
+	var draining <-chan struct{}
+	if api.Lifecycle != nil {
+		draining = api.Lifecycle.Draining()
+	}
+
+	for {
+		select {
+		case <-notify:
+			return // Client disconnected
+		case <-draining:
+			return // Balancer shutting down
+		case msg, ok := <-subscriber:
+			if !ok {
+				if overflowed, dropped := api.EventSystem.Overflowed(subscriber); overflowed {
+					writeNotice(w, "overflow", StreamNotice{
+						Message:     "Stream fell behind and was closed; reconnect with Last-Event-ID to catch up",
+						LastEventID: lastID,
+						Dropped:     dropped,
+					})
+					flusher.Flush()
+				}
+				return // Channel closed
+			}
+
+			// Write the event to the response
+			lastID = writeEvent(w, msg)
+			flusher.Flush()
+		case <-time.After(30 * time.Second):
+			// Send a keepalive comment
+			fmt.Fprintf(w, ": keepalive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// lastEventID reads the ID a reconnecting client last received
+func lastEventID(r *http.Request) (uint64, bool, error) {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("lastEventId")
+	}
+	if raw == "" {
+		return 0, false, nil
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid Last-Event-ID %q", raw)
+	}
+	return id, true, nil
+}
+
+// writeEvent sends one encoded event under its ID and type, returning the ID
+func writeEvent(w http.ResponseWriter, msg string) uint64 {
+	var head struct {
+		ID   uint64           `json:"id"`
+		Type events.EventType `json:"type"`
+	}
+	json.Unmarshal([]byte(msg), &head)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", head.ID, head.Type, msg)
+	return head.ID
+}
+
+// writeNotice sends a stream notice, which carries no ID so a reconnect
+// resumes after the last real event
+func writeNotice(w http.ResponseWriter, event string, notice StreamNotice) {
+	data, _ := json.Marshal(notice)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
+
+// getEventSubscribers lists event subscribers with their delivery and drop
+// counts
+func (api *API) getEventSubscribers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, api.EventSystem.Subscribers())
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/events"
+	"load-balancer/internal/metrics"
+	"load-balancer/internal/router"
+)
+
+func TestEvents_ResumeWithLastEventID(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	table := router.NewTable(ctx, &config.Config{HealthCheckInterval: time.Hour})
+	pool, err := table.AddPoolConfig(config.PoolConfig{Name: "web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	es := events.NewEventSystem(10)
+	api := NewAPI(pool.Manager, pool.Balancer, pool.Breaker, metrics.NewMetricsManager(pool.Manager), es)
+	mux := http.NewServeMux()
+	api.RegisterHandlers(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	es.Publish(events.InfoEvent, "one")
+	es.Emit(events.Event{Type: events.BreakerEvent, ServerID: "web-1", Message: "two"})
+	es.Publish(events.InfoEvent, "three")
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/events?minSeverity=warning", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// The retry hint, then the one missed event that passes the filter
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() && len(lines) < 5 {
+		lines = append(lines, scanner.Text())
+	}
+	want := []string{"retry: 2000", "", "id: 2", "event: breaker"}
+	if strings.Join(lines[:4], "\n") != strings.Join(want, "\n") || !strings.Contains(lines[4], `"message":"two"`) {
+		t.Errorf("stream began\n%s\nwant\n%s\ndata: {...two...}", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,141 @@
+// internal/api/faults.go
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"load-balancer/internal/events"
+	"load-balancer/internal/scenario"
+	"load-balancer/internal/server"
+)
+
+// faultClient talks to the backends' /admin/faults endpoints
+var faultClient = &http.Client{Timeout: 5 * time.Second}
+
+// maxFaultBody bounds fault definitions relayed to a backend
+const maxFaultBody = 64 << 10
+
+// handleServerFaults relays /api/servers/{id}/faults[/{faultID}] to the
+// backend's own /admin/faults endpoint
+func (api *API) handleServerFaults(w http.ResponseWriter, r *http.Request, srv *server.Server, faultID string) {
+	var body []byte
+	switch r.Method {
+	case http.MethodGet, http.MethodDelete:
+	case http.MethodPost:
+		if faultID != "" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var err error
+		if body, err = io.ReadAll(io.LimitReader(r.Body, maxFaultBody)); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := "/admin/faults"
+	if faultID != "" {
+		path += "/" + faultID
+	}
+	status, payload, err := api.callFaults(r.Context(), srv, r.Method, path, body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Fault injection unavailable on server %s: %v", srv.ID, err), http.StatusBadGateway)
+		return
+	}
+
+	if status < 300 {
+		switch r.Method {
+		case http.MethodPost:
+			var f scenario.Fault
+			json.Unmarshal(payload, &f)
+			api.serverEvent(events.WarningEvent, srv.ID, fmt.Sprintf("Fault %s injected into server %s", f.Type, srv.ID))
+		case http.MethodDelete:
+			if faultID != "" {
+				api.serverEvent(events.InfoEvent, srv.ID, fmt.Sprintf("Fault %s removed from server %s", faultID, srv.ID))
+			} else {
+				api.serverEvent(events.InfoEvent, srv.ID, fmt.Sprintf("Faults cleared on server %s", srv.ID))
+			}
+		}
+	}
+
+	if status == http.StatusNoContent {
+		w.WriteHeader(status)
+		return
+	}
+	if json.Valid(payload) {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.WriteHeader(status)
+	w.Write(payload)
+}
+
+// callFaults sends a request to a backend's fault endpoint and returns its
+// status and body
+func (api *API) callFaults(ctx context.Context, srv *server.Server, method, path string, body []byte) (int, []byte, error) {
+	url := fmt.Sprintf("http://%s:%d%s", srv.Address, srv.Port, path)
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := faultClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	payload, err := io.ReadAll(io.LimitReader(resp.Body, maxFaultBody))
+	return resp.StatusCode, payload, err
+}
+
+// InjectFault injects a scenario fault into a backend.
+func (f ScenarioFleet) InjectFault(ctx context.Context, serverID string, fault scenario.Fault) error {
+	srv, _ := f.API.findServer(serverID)
+	if srv == nil {
+		return fmt.Errorf("server %s not found", serverID)
+	}
+	body, err := json.Marshal(fault)
+	if err != nil {
+		return err
+	}
+	status, payload, err := f.API.callFaults(ctx, srv, http.MethodPost, "/admin/faults", body)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusCreated {
+		return fmt.Errorf("backend refused fault: %s", strings.TrimSpace(string(payload)))
+	}
+	f.API.serverEvent(events.WarningEvent, srv.ID, fmt.Sprintf("Fault %s injected into server %s", fault.Type, srv.ID))
+	return nil
+}
+
+// ClearFaults removes every fault from a backend.
+func (f ScenarioFleet) ClearFaults(ctx context.Context, serverID string) error {
+	srv, _ := f.API.findServer(serverID)
+	if srv == nil {
+		return fmt.Errorf("server %s not found", serverID)
+	}
+	status, payload, err := f.API.callFaults(ctx, srv, http.MethodDelete, "/admin/faults", nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("backend refused to clear faults: %s", strings.TrimSpace(string(payload)))
+	}
+	f.API.serverEvent(events.InfoEvent, srv.ID, fmt.Sprintf("Faults cleared on server %s", srv.ID))
+	return nil
+}
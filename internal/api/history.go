@@ -0,0 +1,92 @@
+// internal/api/history.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"load-balancer/internal/events"
+	"load-balancer/internal/history"
+	"load-balancer/internal/metrics"
+)
+
+// getHistory queries the event store, e.g.
+// ?from=2024-05-01T10:00:00Z&server=server-1&status=failed&limit=50. Pass a
+// page's next as ?after= to get the following page.
+func (api *API) getHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q, err := history.ParseQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := api.History.Query(q)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read event history: %v", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// timelineWindow is how far back a timeline is searched for in the event
+// store unless the request gives ?from=.
+const timelineWindow = time.Hour
+
+// getPacketTimeline reconstructs every attempt of one request. Recent
+// requests come from the in-memory history; older ones are searched for in
+// the event store from ?from= (RFC 3339), by default over timelineWindow,
+// so a lookup never reads the whole store.
+func (api *API) getPacketTimeline(w http.ResponseWriter, r *http.Request, requestID string) {
+	var (
+		packets []metrics.PacketEvent
+		notes   []events.Event
+	)
+	for _, packet := range api.MetricsManager.GetPacketHistory(0) {
+		if packet.RequestID == requestID {
+			packets = append(packets, packet)
+		}
+	}
+	if len(packets) > 0 {
+		for _, event := range api.EventSystem.GetRecentEvents(0) {
+			if event.RequestID == requestID {
+				notes = append(notes, event)
+			}
+		}
+	} else if api.History != nil {
+		from := time.Now().Add(-timelineWindow)
+		if raw := r.URL.Query().Get("from"); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid from time %q: use RFC 3339", raw), http.StatusBadRequest)
+				return
+			}
+			from = parsed
+		}
+		stored, err := api.History.All(history.Query{RequestID: requestID, From: from})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read event history: %v", err), http.StatusInternalServerError)
+			return
+		}
+		for _, event := range stored {
+			if event.Type != events.PacketEvent {
+				notes = append(notes, event)
+				continue
+			}
+			var packet metrics.PacketEvent
+			if err := json.Unmarshal(event.Data, &packet); err == nil {
+				packets = append(packets, packet)
+			}
+		}
+	}
+
+	if len(packets) == 0 {
+		http.Error(w, fmt.Sprintf("No packets recorded for request %s", requestID), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, metrics.BuildTimeline(requestID, packets, notes))
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/events"
+	"load-balancer/internal/history"
+	"load-balancer/internal/metrics"
+	"load-balancer/internal/router"
+)
+
+func TestHistory_PacketTimelineFromStore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	table := router.NewTable(ctx, &config.Config{HealthCheckInterval: time.Hour})
+	pool, err := table.AddPoolConfig(config.PoolConfig{Name: "web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := history.Open(history.Options{Dir: t.TempDir(), SegmentBytes: 1 << 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	es := events.NewEventSystem(10)
+	es.OnEvent(store.Append)
+	store.Start()
+
+	mm := metrics.NewMetricsManager(pool.Manager)
+	api := NewAPI(pool.Manager, pool.Balancer, pool.Breaker, mm, es)
+	api.History = store
+	mux := http.NewServeMux()
+	api.RegisterHandlers(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	start := time.Now()
+	for i, step := range []struct {
+		attempt        int
+		server, status string
+	}{{1, "web-1", "dispatch"}, {1, "web-1", "failed"}, {2, "web-2", "dispatch"}, {2, "web-2", "completed"}} {
+		mm.RecordAndBroadcastPacketEvent(es, metrics.PacketEvent{
+			RequestID: "pkt-42",
+			Attempt:   step.attempt,
+			Priority:  "high",
+			ServerID:  step.server,
+			Status:    step.status,
+			Timestamp: start.Add(time.Duration(i) * time.Millisecond),
+		})
+	}
+	// Only the store still has the packets once the in-memory history moves on
+	for i := 0; i < 250; i++ {
+		mm.RecordPacketEvent(metrics.PacketEvent{RequestID: "pkt-other"})
+	}
+	store.Close()
+
+	var timeline metrics.Timeline
+	getJSON(t, srv.URL+"/api/packets?requestId=pkt-42", &timeline)
+	if timeline.Outcome != "completed" || len(timeline.Attempts) != 2 ||
+		timeline.Attempts[0].Status != "failed" || timeline.Attempts[1].ServerID != "web-2" || len(timeline.Attempts[1].Steps) != 2 {
+		t.Fatalf("unexpected timeline %+v", timeline)
+	}
+
+	var page history.Page
+	getJSON(t, srv.URL+"/api/history?status=failed&priority=high", &page)
+	if len(page.Events) != 1 || page.Events[0].ServerID != "web-1" {
+		t.Fatalf("want the one failed attempt, got %+v", page.Events)
+	}
+
+	later := url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339))
+	for _, query := range []string{"requestId=pkt-7", "requestId=pkt-42&from=" + later} {
+		resp, err := http.Get(srv.URL + "/api/packets?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: want 404 outside the searched range, got %d", query, resp.StatusCode)
+		}
+	}
+}
+
+func getJSON(t *testing.T, url string, v interface{}) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,107 @@
+// internal/api/probe.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"load-balancer/internal/events"
+	"load-balancer/internal/lb"
+	"load-balancer/internal/metrics"
+)
+
+// handleTest sends a synthetic probe through the /lb/ dispatch pipeline to
+// ProbePath and reports which server answered. The probe's attempts are
+// tagged synthetic, so they show up in packet history and synthetic metrics
+// but never in the real request metrics.
+func (api *API) handleTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if api.Probe == nil {
+		http.Error(w, "Synthetic probes unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	path := api.ProbePath
+	if path == "" {
+		path = "/lb/"
+	}
+	probe, err := http.NewRequestWithContext(metrics.WithSynthetic(r.Context()), http.MethodGet, path, nil)
+	if err != nil {
+		http.Error(w, "Invalid probe path", http.StatusInternalServerError)
+		return
+	}
+	// Carry over what steers balancing: the client, its priority and its
+	// sticky session
+	probe.RemoteAddr = r.RemoteAddr
+	probe.Host = r.Host
+	probe.Header.Set("X-Task-Priority", lb.ExtractPriority(r))
+	if cookie := r.Header.Get("Cookie"); cookie != "" {
+		probe.Header.Set("Cookie", cookie)
+	}
+
+	rec := &probeRecorder{header: make(http.Header)}
+	start := time.Now()
+	api.Probe.ServeHTTP(rec, probe)
+	elapsed := time.Since(start)
+
+	status := rec.statusCode()
+	if status >= http.StatusBadRequest {
+		api.EventSystem.Publish(events.WarningEvent, fmt.Sprintf("Synthetic probe to %s failed with status %d", path, status))
+		http.Error(w, fmt.Sprintf("Synthetic probe failed: %s", strings.TrimSpace(rec.body.String())), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Served-By", rec.header.Get("X-Served-By"))
+	json.NewEncoder(w).Encode(TestResponse{
+		Server:       rec.header.Get("X-Served-By"),
+		Status:       status,
+		Path:         path,
+		ResponseTime: int(elapsed.Milliseconds()),
+		Timestamp:    time.Now(),
+		Synthetic:    true,
+	})
+}
+
+// probeRecorder captures a probe's response. Only the start of the body is
+// kept, for error messages.
+type probeRecorder struct {
+	header http.Header
+	status int
+	body   strings.Builder
+}
+
+const maxProbeBody = 512
+
+func (pr *probeRecorder) Header() http.Header { return pr.header }
+
+func (pr *probeRecorder) WriteHeader(status int) {
+	if pr.status == 0 {
+		pr.status = status
+	}
+}
+
+func (pr *probeRecorder) Write(b []byte) (int, error) {
+	pr.WriteHeader(http.StatusOK)
+	if room := maxProbeBody - pr.body.Len(); room > 0 {
+		if len(b) > room {
+			pr.body.Write(b[:room])
+		} else {
+			pr.body.Write(b)
+		}
+	}
+	return len(b), nil
+}
+
+func (pr *probeRecorder) statusCode() int {
+	if pr.status == 0 {
+		return http.StatusOK
+	}
+	return pr.status
+}
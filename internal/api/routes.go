@@ -0,0 +1,267 @@
+// internal/api/routes.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/events"
+	"load-balancer/internal/metrics"
+	"load-balancer/internal/router"
+	"load-balancer/internal/server"
+)
+
+// PoolInfo describes a backend pool and its live servers
+type PoolInfo struct {
+	Name    string            `json:"name"`
+	Config  config.PoolConfig `json:"config"`
+	Servers []ServerInfo      `json:"servers"`
+}
+
+// handleRoutes lists routes (GET) or creates/replaces a route (POST)
+func (api *API) handleRoutes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, api.Router.Routes())
+	case http.MethodPost:
+		var route config.RouteConfig
+		if err := json.NewDecoder(r.Body).Decode(&route); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := api.Router.SetRoute(route); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		api.EventSystem.Publish(events.InfoEvent, fmt.Sprintf("Route %s now targets pool %s", route.Name, route.Pool))
+		writeJSON(w, http.StatusOK, api.Router.Route(route.Name).Config)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// SplitUpdate shifts traffic between the variants of a split route
+type SplitUpdate struct {
+	Weights map[string]float64 `json:"weights"`
+}
+
+// SplitResponse reports a route's split and the traffic each variant received
+type SplitResponse struct {
+	Route    string                          `json:"route"`
+	Split    *config.SplitConfig             `json:"split"`
+	Variants map[string]metrics.VariantStats `json:"variants"`
+}
+
+// handleRoute serves /api/routes/{name} and /api/routes/{name}/split
+func (api *API) handleRoute(w http.ResponseWriter, r *http.Request) {
+	name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/routes/"), "/")
+	route := api.Router.Route(name)
+	if route == nil {
+		http.Error(w, "Route not found", http.StatusNotFound)
+		return
+	}
+
+	if action == "split" {
+		api.handleSplit(w, r, route)
+		return
+	}
+	if action == "mirror" {
+		api.handleMirror(w, r, route)
+		return
+	}
+	if action != "" {
+		http.Error(w, "Unknown action", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, route.Config)
+	case http.MethodDelete:
+		if err := api.Router.RemoveRoute(name); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		api.EventSystem.Publish(events.WarningEvent, fmt.Sprintf("Route %s removed", name))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleSplit reports (GET) or atomically re-weights (PUT/POST) a route's traffic split
+func (api *API) handleSplit(w http.ResponseWriter, r *http.Request, route *router.Route) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, api.splitResponse(route.Config.Name, route.Config.Split))
+	case http.MethodPut, http.MethodPost:
+		var update SplitUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil || len(update.Weights) == 0 {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		split, err := api.Router.SetSplitWeights(route.Config.Name, update.Weights)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		parts := make([]string, 0, len(split.Variants))
+		for _, v := range split.Variants {
+			parts = append(parts, fmt.Sprintf("%s=%.1f%%", v.Name, v.Weight))
+		}
+		api.EventSystem.Publish(events.InfoEvent, fmt.Sprintf("Route %s traffic split updated: %s",
+			route.Config.Name, strings.Join(parts, ", ")))
+		writeJSON(w, http.StatusOK, api.splitResponse(route.Config.Name, split))
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (api *API) splitResponse(routeName string, split *config.SplitConfig) SplitResponse {
+	variants := make(map[string]metrics.VariantStats)
+	for _, stats := range api.MetricsManager.GetVariantStats() {
+		if stats.Route == routeName {
+			variants[stats.Variant] = stats
+		}
+	}
+	return SplitResponse{Route: routeName, Split: split, Variants: variants}
+}
+
+// MirrorResponse reports a route's mirror policy and its shadow traffic
+type MirrorResponse struct {
+	Route  string               `json:"route"`
+	Mirror *config.MirrorConfig `json:"mirror"`
+	Stats  *metrics.MirrorStats `json:"stats,omitempty"`
+}
+
+// handleMirror reports a route's mirror policy with shadow metrics and diff summary
+func (api *API) handleMirror(w http.ResponseWriter, r *http.Request, route *router.Route) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := MirrorResponse{Route: route.Config.Name, Mirror: route.Config.Mirror}
+	if route.Config.Mirror != nil {
+		for _, stats := range api.MetricsManager.GetMirrorStats() {
+			if stats.Route == route.Config.Name && stats.Pool == route.Config.Mirror.Pool {
+				stats := stats
+				response.Stats = &stats
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handlePools lists pools (GET) or creates a pool (POST)
+func (api *API) handlePools(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		pools := api.Router.Pools()
+		infos := make([]PoolInfo, 0, len(pools))
+		for _, pool := range pools {
+			infos = append(infos, poolInfo(pool))
+		}
+		writeJSON(w, http.StatusOK, infos)
+	case http.MethodPost:
+		var cfg config.PoolConfig
+		if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		pool, err := api.Router.AddPoolConfig(cfg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		api.EventSystem.Publish(events.SuccessEvent, fmt.Sprintf("Pool %s created with %d servers", pool.Name, len(cfg.Servers)))
+		writeJSON(w, http.StatusCreated, poolInfo(pool))
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePool serves /api/pools/{name} and /api/pools/{name}/servers[/{id}]
+func (api *API) handlePool(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/pools/"), "/")
+	pool := api.Router.Pool(parts[0])
+	if pool == nil {
+		http.Error(w, "Pool not found", http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, poolInfo(pool))
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		if err := api.Router.RemovePool(pool.Name); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		api.EventSystem.Publish(events.WarningEvent, fmt.Sprintf("Pool %s removed", pool.Name))
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) == 2 && parts[1] == "servers" && r.Method == http.MethodPost:
+		var s config.ServerConfig
+		if err := json.NewDecoder(r.Body).Decode(&s); err != nil || s.ID == "" {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if _, err := api.addServer(pool, s); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		writeJSON(w, http.StatusCreated, poolInfo(pool))
+	case len(parts) == 3 && parts[1] == "servers" && r.Method == http.MethodDelete:
+		if !api.removeServer(pool, parts[2]) {
+			http.Error(w, "Server not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Unknown action", http.StatusBadRequest)
+	}
+}
+
+// addServer adds a server to pool and warms it up with slow start. Server
+// IDs are unique across pools.
+func (api *API) addServer(pool *router.Pool, s config.ServerConfig) (*server.Server, error) {
+	srv := router.NewServer(s)
+	server.BeginSlowStart(srv)
+	if err := api.Router.AddServer(pool, srv); err != nil {
+		return nil, err
+	}
+	api.serverEvent(events.SuccessEvent, s.ID, fmt.Sprintf("Server %s added to pool %s", s.ID, pool.Name))
+	return srv, nil
+}
+
+// removeServer removes a server from pool, reporting false if it is not there
+func (api *API) removeServer(pool *router.Pool, id string) bool {
+	if api.Router.FindServer(id) != pool {
+		return false
+	}
+	pool.Manager.RemoveServer(id)
+	api.serverEvent(events.WarningEvent, id, fmt.Sprintf("Server %s removed from pool %s", id, pool.Name))
+	return true
+}
+
+func poolInfo(pool *router.Pool) PoolInfo {
+	cfg := pool.Settings()
+	cfg.Servers = nil // the live list below is authoritative
+
+	return PoolInfo{
+		Name:    pool.Name,
+		Config:  cfg,
+		Servers: serverInfos(pool.Manager.GetAllServers(), pool.Balancer),
+	}
+}
+
+// writeJSON encodes v with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,206 @@
+// internal/api/scenarios.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"load-balancer/internal/scenario"
+	"load-balancer/internal/server"
+)
+
+// ScenarioCatalog is returned by GET /api/scenarios
+type ScenarioCatalog struct {
+	Scenarios []scenario.Scenario `json:"scenarios"`
+	Runs      []scenario.Status   `json:"runs"`
+}
+
+// handleScenarios lists the catalog and runs (GET) or starts a run (POST).
+// A POST body is either {"name": "failure"} for a built-in scenario or a
+// full scenario definition with steps.
+func (api *API) handleScenarios(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, ScenarioCatalog{
+			Scenarios: api.Scenarios.Catalog(),
+			Runs:      api.Scenarios.List(),
+		})
+	case http.MethodPost:
+		var s scenario.Scenario
+		if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if len(s.Steps) == 0 {
+			builtin, ok := api.Scenarios.Lookup(s.Name)
+			if !ok {
+				http.Error(w, "Scenario not found", http.StatusNotFound)
+				return
+			}
+			s = builtin
+		}
+		status, err := api.Scenarios.Start(s)
+		if errors.Is(err, scenario.ErrBusy) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, status)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleScenarioRun reports (GET) or cancels (DELETE) a run, and streams its
+// progress from /api/scenarios/runs/{id}/events
+func (api *API) handleScenarioRun(w http.ResponseWriter, r *http.Request) {
+	id, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/scenarios/runs/"), "/")
+	if sub == "events" {
+		api.streamScenarioRun(w, r, id)
+		return
+	}
+	if sub != "" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		status, ok := api.Scenarios.Status(id)
+		if !ok {
+			http.Error(w, "Scenario run not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, status)
+	case http.MethodDelete:
+		status, err := api.Scenarios.Cancel(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, status)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// streamScenarioRun sends a run's progress as Server-Sent Events: its status
+// on connect, "step" and "progress" events while it runs, and a final "done"
+// event carrying the report.
+func (api *API) streamScenarioRun(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	updates, unsubscribe, ok := api.Scenarios.Subscribe(id)
+	if !ok {
+		http.Error(w, "Scenario run not found", http.StatusNotFound)
+		return
+	}
+	defer unsubscribe()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	send := func(event string, v interface{}) {
+		data, _ := json.Marshal(v)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		flusher.Flush()
+	}
+
+	status, _ := api.Scenarios.Status(id)
+	send("status", status)
+
+	var draining <-chan struct{}
+	if api.Lifecycle != nil {
+		draining = api.Lifecycle.Draining()
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-draining:
+			return
+		case p, ok := <-updates:
+			if !ok {
+				status, _ := api.Scenarios.Status(id)
+				send("done", status)
+				return
+			}
+			send(p.Type, p)
+		case <-time.After(30 * time.Second):
+			fmt.Fprintf(w, ": keepalive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// ScenarioFleet lets the scenario engine toggle servers through the same
+// paths as the dashboard buttons
+type ScenarioFleet struct {
+	API *API
+}
+
+// Servers lists the primary pool's servers first, then those of other pools.
+func (f ScenarioFleet) Servers() []scenario.ServerState {
+	servers := f.API.ServerManager.GetAllServers()
+	if f.API.Router != nil {
+		for _, pool := range f.API.Router.Pools() {
+			if pool.Manager != f.API.ServerManager {
+				servers = append(servers, pool.Manager.GetAllServers()...)
+			}
+		}
+	}
+
+	states := make([]scenario.ServerState, 0, len(servers))
+	for _, srv := range servers {
+		states = append(states, scenario.ServerState{
+			ID: srv.ID,
+			Online: server.IsUp(srv) && server.GetBreakerState(srv) == server.CBStateClosed &&
+				server.GetAdminState(srv) == server.AdminActive,
+		})
+	}
+	return states
+}
+
+// Apply performs a scenario server action.
+func (f ScenarioFleet) Apply(serverID string, action scenario.Action) error {
+	srv, balancer := f.API.findServer(serverID)
+	if srv == nil {
+		return fmt.Errorf("server %s not found", serverID)
+	}
+
+	switch action {
+	case scenario.ActionEnable:
+		f.API.setServerEnabled(srv, true)
+	case scenario.ActionDisable:
+		f.API.setServerEnabled(srv, false)
+	case scenario.ActionReset:
+		f.API.resetBreaker(srv)
+	case scenario.ActionDrain:
+		if !f.API.startDrain(srv, balancer, f.API.DrainTimeout) {
+			return fmt.Errorf("server %s is already %s", srv.ID, server.GetAdminState(srv))
+		}
+	case scenario.ActionMaintenance:
+		f.API.enterMaintenance(srv, balancer)
+	case scenario.ActionActivate:
+		f.API.activate(srv, balancer)
+	default:
+		return fmt.Errorf("unknown action %q", action)
+	}
+	return nil
+}
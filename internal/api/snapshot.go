@@ -0,0 +1,37 @@
+// internal/api/snapshot.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"load-balancer/internal/events"
+	"load-balancer/internal/snapshot"
+)
+
+// handleSnapshot exports the current runtime state (GET) or restores a
+// previously exported snapshot (POST/PUT)
+func (api *API) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Disposition", `attachment; filename="loadbalancer-snapshot.json"`)
+		writeJSON(w, http.StatusOK, api.Snapshots.Capture())
+	case http.MethodPost, http.MethodPut:
+		var snap snapshot.Snapshot
+		if err := json.NewDecoder(r.Body).Decode(&snap); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		result, err := api.Snapshots.Restore(&snap)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		api.EventSystem.Publish(events.InfoEvent, fmt.Sprintf("Snapshot from %s imported: %d sessions, %d servers, %d skipped",
+			snap.Taken.Format("2006-01-02 15:04:05"), result.Sessions, result.Servers, len(result.SkippedServers)))
+		writeJSON(w, http.StatusOK, result)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
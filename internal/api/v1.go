@@ -0,0 +1,378 @@
+// internal/api/v1.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	apiv1 "load-balancer/internal/api/v1"
+	"load-balancer/internal/events"
+	"load-balancer/internal/lb"
+	"load-balancer/internal/router"
+	"load-balancer/internal/server"
+)
+
+// v1Route is one operation of /api/v1. openapi.json documents every one of
+// them; TestOpenAPIMatchesRoutes keeps the two in step.
+type v1Route struct {
+	Method  string
+	Path    string
+	Handler func(api *API, w http.ResponseWriter, r *http.Request)
+}
+
+var v1Routes = []v1Route{
+	{http.MethodGet, "/api/v1/openapi.json", (*API).v1OpenAPI},
+	{http.MethodGet, "/api/v1/servers", (*API).v1ListServers},
+	{http.MethodGet, "/api/v1/servers/{id}", (*API).v1GetServer},
+	{http.MethodPost, "/api/v1/servers/{id}/{action}", (*API).v1ServerAction},
+	{http.MethodGet, "/api/v1/pools", (*API).v1ListPools},
+	{http.MethodPost, "/api/v1/pools", (*API).v1CreatePool},
+	{http.MethodGet, "/api/v1/pools/{name}", (*API).v1GetPool},
+	{http.MethodPatch, "/api/v1/pools/{name}", (*API).v1PatchPool},
+	{http.MethodDelete, "/api/v1/pools/{name}", (*API).v1DeletePool},
+	{http.MethodPost, "/api/v1/pools/{name}/servers", (*API).v1AddServer},
+	{http.MethodDelete, "/api/v1/pools/{name}/servers/{id}", (*API).v1RemoveServer},
+}
+
+// v1ServerActions are the {action}s a server accepts.
+var v1ServerActions = []string{"enable", "disable", "drain", "maintenance", "activate", "reset-breaker"}
+
+// v1Handler serves /api/v1/, answering unknown paths and methods with
+// problem+json rather than the mux's plain text.
+func (api *API) v1Handler() http.Handler {
+	mux := http.NewServeMux()
+	for _, route := range v1Routes {
+		handler := route.Handler
+		mux.HandleFunc(route.Method+" "+route.Path, func(w http.ResponseWriter, r *http.Request) {
+			handler(api, w, r)
+		})
+	}
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		var allowed []string
+		for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodDelete} {
+			probe := r.Clone(r.Context())
+			probe.Method = method
+			if _, pattern := mux.Handler(probe); pattern != "/api/v1/" {
+				allowed = append(allowed, method)
+			}
+		}
+		if len(allowed) == 0 {
+			apiv1.WriteProblem(w, r, apiv1.ProblemNotFound, http.StatusNotFound, "No such endpoint")
+			return
+		}
+		for _, method := range allowed {
+			w.Header().Add("Allow", method)
+		}
+		apiv1.WriteProblem(w, r, apiv1.ProblemMethodNotAllowed, http.StatusMethodNotAllowed,
+			fmt.Sprintf("%s is not supported here", r.Method))
+	})
+	return mux
+}
+
+func (api *API) v1OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(apiv1.OpenAPI)
+}
+
+// v1Server converts a server, naming the pool it belongs to.
+func (api *API) v1Server(srv *server.Server, balancer *lb.Balancer) apiv1.Server {
+	pool := ""
+	if p := api.Router.FindServer(srv.ID); p != nil {
+		pool = p.Name
+	}
+	return apiv1.NewServer(srv, pool, balancer)
+}
+
+// serverETag versions a server's state, not its live metrics, so If-Match
+// only fails when the server was reconfigured or changed state. It is weak
+// as two responses with the same tag may differ in their metrics.
+func serverETag(s apiv1.Server) string {
+	return apiv1.WeakETag(struct {
+		ID, Pool, Address     string
+		Port                  int
+		State, Admin, Breaker string
+	}{s.ID, s.Pool, s.Address, s.Port, s.State, s.AdminState, s.Breaker.State})
+}
+
+func (api *API) v1ListServers(w http.ResponseWriter, r *http.Request) {
+	pools := api.Router.Pools()
+	if name := r.URL.Query().Get("pool"); name != "" {
+		pool := api.Router.Pool(name)
+		if pool == nil {
+			apiv1.WriteProblem(w, r, apiv1.ProblemNotFound, http.StatusNotFound, fmt.Sprintf("Pool %s not found", name))
+			return
+		}
+		pools = []*router.Pool{pool}
+	}
+
+	list := apiv1.ServerList{Items: []apiv1.Server{}}
+	for _, pool := range pools {
+		for _, srv := range pool.Manager.GetAllServers() {
+			list.Items = append(list.Items, apiv1.NewServer(srv, pool.Name, pool.Balancer))
+		}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// v1FindServer looks up the {id} server, answering 404 if there is none.
+func (api *API) v1FindServer(w http.ResponseWriter, r *http.Request) (*server.Server, *lb.Balancer, bool) {
+	id := r.PathValue("id")
+	srv, balancer := api.findServer(id)
+	if srv == nil {
+		apiv1.WriteProblem(w, r, apiv1.ProblemNotFound, http.StatusNotFound, fmt.Sprintf("Server %s not found", id))
+		return nil, nil, false
+	}
+	return srv, balancer, true
+}
+
+func (api *API) v1GetServer(w http.ResponseWriter, r *http.Request) {
+	srv, balancer, ok := api.v1FindServer(w, r)
+	if !ok {
+		return
+	}
+	out := api.v1Server(srv, balancer)
+	w.Header().Set("ETag", serverETag(out))
+	writeJSON(w, http.StatusOK, out)
+}
+
+// v1ServerAction changes a server's state. drain takes an optional
+// ?timeout= and answers 202 while in-flight requests finish.
+func (api *API) v1ServerAction(w http.ResponseWriter, r *http.Request) {
+	action := r.PathValue("action")
+	if !slices.Contains(v1ServerActions, action) {
+		apiv1.WriteProblem(w, r, apiv1.ProblemNotFound, http.StatusNotFound, fmt.Sprintf("Unknown action %s", action))
+		return
+	}
+	srv, balancer, ok := api.v1FindServer(w, r)
+	if !ok {
+		return
+	}
+	timeout := api.DrainTimeout
+	if raw := r.URL.Query().Get("timeout"); raw != "" && action == "drain" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			apiv1.WriteProblem(w, r, apiv1.ProblemInvalidRequest, http.StatusBadRequest, "timeout must be a duration such as 30s")
+			return
+		}
+		timeout = parsed
+	}
+
+	status := http.StatusOK
+	etag := func() string { return serverETag(api.v1Server(srv, balancer)) }
+	applied := v1Update(w, r, api.Router.FindServer(srv.ID), "Server "+srv.ID, etag, func() {
+		switch action {
+		case "enable":
+			api.setServerEnabled(srv, true)
+		case "disable":
+			api.setServerEnabled(srv, false)
+		case "reset-breaker":
+			api.resetBreaker(srv)
+		case "maintenance":
+			api.enterMaintenance(srv, balancer)
+		case "activate":
+			api.activate(srv, balancer)
+		case "drain":
+			status = http.StatusAccepted
+			if !api.startDrain(srv, balancer, timeout) {
+				status = http.StatusConflict
+			}
+		}
+	})
+	if !applied {
+		return
+	}
+	if status == http.StatusConflict {
+		apiv1.WriteProblem(w, r, apiv1.ProblemConflict, http.StatusConflict,
+			fmt.Sprintf("Server %s is already %s", srv.ID, server.GetAdminState(srv)))
+		return
+	}
+
+	out := api.v1Server(srv, balancer)
+	w.Header().Set("ETag", serverETag(out))
+	writeJSON(w, status, out)
+}
+
+func (api *API) v1ListPools(w http.ResponseWriter, r *http.Request) {
+	list := apiv1.PoolList{Items: []apiv1.Pool{}}
+	for _, pool := range api.Router.Pools() {
+		list.Items = append(list.Items, v1Pool(pool))
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func v1Pool(pool *router.Pool) apiv1.Pool {
+	return apiv1.NewPool(pool.Settings(), pool.Balancer, pool.Manager.GetAllServers())
+}
+
+// v1FindPool looks up the {name} pool, answering 404 if there is none.
+func (api *API) v1FindPool(w http.ResponseWriter, r *http.Request) (*router.Pool, bool) {
+	name := r.PathValue("name")
+	pool := api.Router.Pool(name)
+	if pool == nil {
+		apiv1.WriteProblem(w, r, apiv1.ProblemNotFound, http.StatusNotFound, fmt.Sprintf("Pool %s not found", name))
+		return nil, false
+	}
+	return pool, true
+}
+
+// v1Update runs fn if the request's If-Match still admits etag, checking
+// and changing under pool's update lock so concurrent conditional requests
+// cannot both apply. It answers 412 and reports false otherwise. what names
+// the resource in the problem.
+func v1Update(w http.ResponseWriter, r *http.Request, pool *router.Pool, what string, etag func() string, fn func()) bool {
+	matches := func() bool { return apiv1.IfMatch(r.Header.Get("If-Match"), etag()) }
+	applied := false
+	if pool != nil {
+		applied = pool.Update(matches, fn)
+	} else if matches() {
+		fn()
+		applied = true
+	}
+	if !applied {
+		apiv1.WriteProblem(w, r, apiv1.ProblemPreconditionFailed, http.StatusPreconditionFailed,
+			fmt.Sprintf("%s has changed", what))
+	}
+	return applied
+}
+
+// v1UpdatePool is v1Update for the pool's own ETag.
+func v1UpdatePool(w http.ResponseWriter, r *http.Request, pool *router.Pool, fn func()) bool {
+	return v1Update(w, r, pool, "Pool "+pool.Name, func() string { return apiv1.ETag(v1Pool(pool)) }, fn)
+}
+
+func (api *API) v1CreatePool(w http.ResponseWriter, r *http.Request) {
+	var spec apiv1.PoolSpec
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil || spec.Name == "" {
+		apiv1.WriteProblem(w, r, apiv1.ProblemInvalidRequest, http.StatusBadRequest, "Body must be a pool with a name")
+		return
+	}
+	if api.Router.Pool(spec.Name) != nil {
+		apiv1.WriteProblem(w, r, apiv1.ProblemConflict, http.StatusConflict, fmt.Sprintf("Pool %s already exists", spec.Name))
+		return
+	}
+	cfg, err := spec.PoolConfig()
+	if err != nil {
+		apiv1.WriteProblem(w, r, apiv1.ProblemInvalidRequest, http.StatusBadRequest, "healthCheckInterval must be a duration such as 5s")
+		return
+	}
+	pool, err := api.Router.AddPoolConfig(cfg)
+	if err != nil {
+		apiv1.WriteProblem(w, r, apiv1.ProblemInvalidRequest, http.StatusBadRequest, err.Error())
+		return
+	}
+	api.EventSystem.Publish(events.SuccessEvent, fmt.Sprintf("Pool %s created with %d servers", pool.Name, len(cfg.Servers)))
+
+	out := v1Pool(pool)
+	w.Header().Set("Location", "/api/v1/pools/"+pool.Name)
+	w.Header().Set("ETag", apiv1.ETag(out))
+	writeJSON(w, http.StatusCreated, out)
+}
+
+func (api *API) v1GetPool(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	pool := api.Router.Pool(name)
+	if pool == nil {
+		apiv1.WriteProblem(w, r, apiv1.ProblemNotFound, http.StatusNotFound, fmt.Sprintf("Pool %s not found", name))
+		return
+	}
+	out := v1Pool(pool)
+	etag := apiv1.ETag(out)
+	w.Header().Set("ETag", etag)
+	if apiv1.IfNoneMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (api *API) v1PatchPool(w http.ResponseWriter, r *http.Request) {
+	pool, ok := api.v1FindPool(w, r)
+	if !ok {
+		return
+	}
+	var patch apiv1.PoolPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		apiv1.WriteProblem(w, r, apiv1.ProblemInvalidRequest, http.StatusBadRequest, "Body must be a pool patch")
+		return
+	}
+	var err error
+	if !v1UpdatePool(w, r, pool, func() { err = pool.Reconfigure(patch.Strategy, patch.StickySessions) }) {
+		return
+	}
+	if err != nil {
+		apiv1.WriteProblem(w, r, apiv1.ProblemInvalidRequest, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	out := v1Pool(pool)
+	api.EventSystem.Publish(events.InfoEvent, fmt.Sprintf("Pool %s now uses %s, sticky sessions %s",
+		pool.Name, out.Strategy, boolToString(out.StickySessions)))
+	w.Header().Set("ETag", apiv1.ETag(out))
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (api *API) v1DeletePool(w http.ResponseWriter, r *http.Request) {
+	pool, ok := api.v1FindPool(w, r)
+	if !ok {
+		return
+	}
+	var err error
+	if !v1UpdatePool(w, r, pool, func() { err = api.Router.RemovePool(pool.Name) }) {
+		return
+	}
+	if err != nil {
+		apiv1.WriteProblem(w, r, apiv1.ProblemConflict, http.StatusConflict, err.Error())
+		return
+	}
+	api.EventSystem.Publish(events.WarningEvent, fmt.Sprintf("Pool %s removed", pool.Name))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *API) v1AddServer(w http.ResponseWriter, r *http.Request) {
+	pool, ok := api.v1FindPool(w, r)
+	if !ok {
+		return
+	}
+	var spec apiv1.ServerSpec
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil || spec.ID == "" || spec.Address == "" || spec.Port <= 0 {
+		apiv1.WriteProblem(w, r, apiv1.ProblemInvalidRequest, http.StatusBadRequest, "Body must be a server with id, address and port")
+		return
+	}
+	var (
+		srv *server.Server
+		err error
+	)
+	if !v1UpdatePool(w, r, pool, func() { srv, err = api.addServer(pool, spec.ServerConfig()) }) {
+		return
+	}
+	if err != nil {
+		apiv1.WriteProblem(w, r, apiv1.ProblemConflict, http.StatusConflict, err.Error())
+		return
+	}
+
+	out := apiv1.NewServer(srv, pool.Name, pool.Balancer)
+	w.Header().Set("Location", "/api/v1/servers/"+srv.ID)
+	w.Header().Set("ETag", serverETag(out))
+	writeJSON(w, http.StatusCreated, out)
+}
+
+func (api *API) v1RemoveServer(w http.ResponseWriter, r *http.Request) {
+	pool, ok := api.v1FindPool(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	removed := false
+	if !v1UpdatePool(w, r, pool, func() { removed = api.removeServer(pool, id) }) {
+		return
+	}
+	if !removed {
+		apiv1.WriteProblem(w, r, apiv1.ProblemNotFound, http.StatusNotFound,
+			fmt.Sprintf("Server %s not found in pool %s", id, pool.Name))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,10 @@
+// internal/api/v1/openapi.go
+package v1
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI 3 document for /api/v1, served at
+// /api/v1/openapi.json.
+//
+//go:embed openapi.json
+var OpenAPI []byte
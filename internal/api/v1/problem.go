@@ -0,0 +1,87 @@
+// internal/api/v1/problem.go
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// ProblemContentType is the media type of error responses (RFC 7807).
+const ProblemContentType = "application/problem+json"
+
+// Problem types. Clients switch on Type, not on Title or Detail.
+const (
+	ProblemNotFound           = "urn:load-balancer:problem:not-found"
+	ProblemMethodNotAllowed   = "urn:load-balancer:problem:method-not-allowed"
+	ProblemInvalidRequest     = "urn:load-balancer:problem:invalid-request"
+	ProblemConflict           = "urn:load-balancer:problem:conflict"
+	ProblemPreconditionFailed = "urn:load-balancer:problem:precondition-failed"
+)
+
+// Problem is an RFC 7807 error body.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"` // the request path
+}
+
+// WriteProblem answers r with a problem of the given type and status.
+func WriteProblem(w http.ResponseWriter, r *http.Request, problemType string, status int, detail string) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Problem{
+		Type:     problemType,
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	})
+}
+
+// ETag is a strong entity tag over v's JSON encoding.
+func ETag(v interface{}) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// WeakETag is ETag marked weak, for a tag over only part of a
+// representation.
+func WeakETag(v interface{}) string {
+	return "W/" + ETag(v)
+}
+
+// IfMatch reports whether an If-Match header admits etag. A missing header
+// admits anything; "*" admits any current representation. Tags compare
+// weakly, so the weak ETag of a server can be sent back.
+func IfMatch(header, etag string) bool {
+	if header == "" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// IfNoneMatch reports whether an If-None-Match header already holds etag,
+// so a GET may answer 304. Weak tags compare equal to their strong form.
+func IfNoneMatch(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+// internal/api/v1/types.go
+
+// Package v1 holds the wire types of the /api/v1 admin API. Field names and
+// enum values here are a compatibility promise: add fields, never rename or
+// repurpose them. openapi.json describes the same types.
+package v1
+
+import (
+	"time"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/lb"
+	"load-balancer/internal/server"
+)
+
+// Server states, from the health checker's view.
+const (
+	StateUp   = "up"
+	StateDown = "down"
+)
+
+// Admin states, set by operators.
+const (
+	AdminActive      = "active"
+	AdminDraining    = "draining"
+	AdminMaintenance = "maintenance"
+)
+
+// Circuit breaker states.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// Pool strategies.
+const (
+	StrategyWeightedRoundRobin = string(lb.StrategyWeightedRoundRobin)
+	StrategyLeastConnections   = string(lb.StrategyLeastConnections)
+	StrategyIPHash             = lb.StrategyIPHash
+)
+
+// Enums lists the values of every enum in the API, keyed by the schema
+// property that carries it.
+var Enums = map[string][]string{
+	"Server.state":       {StateUp, StateDown},
+	"Server.adminState":  {AdminActive, AdminDraining, AdminMaintenance},
+	"Breaker.state":      {BreakerClosed, BreakerOpen, BreakerHalfOpen},
+	"Pool.strategy":      {StrategyWeightedRoundRobin, StrategyLeastConnections, StrategyIPHash},
+	"PoolSpec.strategy":  {StrategyWeightedRoundRobin, StrategyLeastConnections, StrategyIPHash},
+	"PoolPatch.strategy": {StrategyWeightedRoundRobin, StrategyLeastConnections, StrategyIPHash},
+	"Problem.type": {
+		ProblemNotFound, ProblemMethodNotAllowed, ProblemInvalidRequest, ProblemConflict, ProblemPreconditionFailed,
+	},
+}
+
+// Server is a backend and its live state.
+type Server struct {
+	ID             string  `json:"id"`
+	Pool           string  `json:"pool"`
+	Address        string  `json:"address"`
+	Port           int     `json:"port"`
+	State          string  `json:"state"`
+	AdminState     string  `json:"adminState"`
+	Breaker        Breaker `json:"breaker"`
+	Weight         float64 `json:"weight"`      // share of the pool's traffic, 0..1
+	HealthScore    float64 `json:"healthScore"` // 0..1
+	RampFactor     float64 `json:"rampFactor"`  // slow-start share of full weight, 0..1
+	ActiveRequests int64   `json:"activeRequests"`
+	ResponseTimeMs float64 `json:"responseTimeMs"`
+	ErrorRate      float64 `json:"errorRate"`
+	CPUUsage       float64 `json:"cpuUsage"`
+	MemUsage       float64 `json:"memUsage"`
+}
+
+// Breaker is a server's circuit breaker.
+type Breaker struct {
+	State     string     `json:"state"`
+	Failures  int        `json:"failures"`
+	OpenSince *time.Time `json:"openSince,omitempty"`
+}
+
+// ServerList is a page of servers.
+type ServerList struct {
+	Items []Server `json:"items"`
+}
+
+// ServerSpec adds a server to a pool.
+type ServerSpec struct {
+	ID      string `json:"id"`
+	Address string `json:"address"`
+	Port    int    `json:"port"`
+}
+
+// Pool is a named group of servers behind one balancer.
+type Pool struct {
+	Name                string   `json:"name"`
+	Strategy            string   `json:"strategy"`
+	StickySessions      bool     `json:"stickySessions"`
+	HealthCheckPath     string   `json:"healthCheckPath,omitempty"`
+	HealthCheckInterval string   `json:"healthCheckInterval"`
+	Servers             []string `json:"servers"`
+}
+
+// PoolList is a page of pools.
+type PoolList struct {
+	Items []Pool `json:"items"`
+}
+
+// PoolSpec creates a pool. Unset settings take the balancer's defaults.
+type PoolSpec struct {
+	Name                string       `json:"name"`
+	Strategy            string       `json:"strategy,omitempty"`
+	StickySessions      *bool        `json:"stickySessions,omitempty"`
+	HealthCheckPath     string       `json:"healthCheckPath,omitempty"`
+	HealthCheckInterval string       `json:"healthCheckInterval,omitempty"`
+	Servers             []ServerSpec `json:"servers"`
+}
+
+// PoolPatch changes a pool's settings; absent fields are left alone.
+type PoolPatch struct {
+	Strategy       *string `json:"strategy,omitempty"`
+	StickySessions *bool   `json:"stickySessions,omitempty"`
+}
+
+// NewServer converts a server in the named pool. balancer may be nil.
+func NewServer(srv *server.Server, pool string, balancer *lb.Balancer) Server {
+	snap := server.Snapshot(srv)
+	out := Server{
+		ID:         snap.ID,
+		Pool:       pool,
+		Address:    snap.Address,
+		Port:       snap.Port,
+		State:      StateDown,
+		AdminState: snap.AdminState.String(),
+		Breaker: Breaker{
+			State:    BreakerState(snap.CircuitBreakerState),
+			Failures: snap.FailureCount,
+		},
+		Weight:         snap.CurrentWeight,
+		HealthScore:    snap.HealthScore,
+		RampFactor:     1,
+		ActiveRequests: snap.ActiveRequests,
+		ResponseTimeMs: snap.ResponseTime,
+		ErrorRate:      snap.ErrorRate,
+		CPUUsage:       snap.CPUUsage,
+		MemUsage:       snap.MemUsage,
+	}
+	if snap.PingStatus {
+		out.State = StateUp
+	}
+	if snap.CircuitBreakerState != server.CBStateClosed && !snap.OpenSince.IsZero() {
+		since := snap.OpenSince
+		out.Breaker.OpenSince = &since
+	}
+	if balancer != nil {
+		out.RampFactor = balancer.RampFactor(srv)
+	}
+	return out
+}
+
+// BreakerState names a circuit breaker state.
+func BreakerState(state server.CBState) string {
+	switch state {
+	case server.CBStateOpen:
+		return BreakerOpen
+	case server.CBStateHalfOpen:
+		return BreakerHalfOpen
+	default:
+		return BreakerClosed
+	}
+}
+
+// NewPool converts a pool's settings and server IDs.
+func NewPool(cfg config.PoolConfig, balancer *lb.Balancer, servers []*server.Server) Pool {
+	out := Pool{
+		Name:                cfg.Name,
+		Strategy:            balancer.StrategyName(),
+		StickySessions:      balancer.StickySessions(),
+		HealthCheckPath:     cfg.HealthCheckPath,
+		HealthCheckInterval: cfg.HealthCheckInterval.Duration.String(),
+		Servers:             make([]string, 0, len(servers)),
+	}
+	for _, srv := range servers {
+		out.Servers = append(out.Servers, srv.ID)
+	}
+	return out
+}
+
+// PoolConfig converts a pool spec for the routing table.
+func (s PoolSpec) PoolConfig() (config.PoolConfig, error) {
+	cfg := config.PoolConfig{
+		Name:              s.Name,
+		Strategy:          s.Strategy,
+		UseStickySessions: s.StickySessions,
+		HealthCheckPath:   s.HealthCheckPath,
+	}
+	if s.HealthCheckInterval != "" {
+		d, err := time.ParseDuration(s.HealthCheckInterval)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.HealthCheckInterval.Duration = d
+	}
+	for _, srv := range s.Servers {
+		cfg.Servers = append(cfg.Servers, srv.ServerConfig())
+	}
+	return cfg, nil
+}
+
+// ServerConfig converts a server spec.
+func (s ServerSpec) ServerConfig() config.ServerConfig {
+	return config.ServerConfig{ID: s.ID, Address: s.Address, Port: s.Port}
+}
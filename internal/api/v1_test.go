@@ -0,0 +1,248 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	apiv1 "load-balancer/internal/api/v1"
+	"load-balancer/internal/config"
+	"load-balancer/internal/events"
+	"load-balancer/internal/metrics"
+	"load-balancer/internal/router"
+)
+
+type openAPISchema struct {
+	Required   []string `json:"required"`
+	Properties map[string]struct {
+		Enum []string `json:"enum"`
+	} `json:"properties"`
+}
+
+type openAPIDoc struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]openAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+func loadOpenAPI(t *testing.T) openAPIDoc {
+	t.Helper()
+	var doc openAPIDoc
+	if err := json.Unmarshal(apiv1.OpenAPI, &doc); err != nil {
+		t.Fatalf("openapi.json: %v", err)
+	}
+	return doc
+}
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	doc := loadOpenAPI(t)
+
+	var documented, served []string
+	for path, ops := range doc.Paths {
+		for method := range ops {
+			if method != "parameters" {
+				documented = append(documented, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+	for _, route := range v1Routes {
+		served = append(served, route.Method+" "+route.Path)
+	}
+	sort.Strings(documented)
+	sort.Strings(served)
+	if !slices.Equal(documented, served) {
+		t.Errorf("openapi.json documents\n  %v\nbut /api/v1 serves\n  %v", documented, served)
+	}
+
+	var params []struct {
+		Name   string `json:"name"`
+		Schema struct {
+			Enum []string `json:"enum"`
+		} `json:"schema"`
+	}
+	json.Unmarshal(doc.Paths["/api/v1/servers/{id}/{action}"]["parameters"], &params)
+	for _, p := range params {
+		if p.Name == "action" && !slices.Equal(p.Schema.Enum, v1ServerActions) {
+			t.Errorf("documented actions %v, served %v", p.Schema.Enum, v1ServerActions)
+		}
+	}
+}
+
+func TestOpenAPIMatchesTypes(t *testing.T) {
+	doc := loadOpenAPI(t)
+	types := map[string]reflect.Type{
+		"Server":     reflect.TypeOf(apiv1.Server{}),
+		"Breaker":    reflect.TypeOf(apiv1.Breaker{}),
+		"ServerList": reflect.TypeOf(apiv1.ServerList{}),
+		"ServerSpec": reflect.TypeOf(apiv1.ServerSpec{}),
+		"Pool":       reflect.TypeOf(apiv1.Pool{}),
+		"PoolList":   reflect.TypeOf(apiv1.PoolList{}),
+		"PoolSpec":   reflect.TypeOf(apiv1.PoolSpec{}),
+		"PoolPatch":  reflect.TypeOf(apiv1.PoolPatch{}),
+		"Problem":    reflect.TypeOf(apiv1.Problem{}),
+	}
+
+	for name, schema := range doc.Components.Schemas {
+		typ, ok := types[name]
+		if !ok {
+			t.Errorf("schema %s has no Go type", name)
+			continue
+		}
+		var fields, required []string
+		for i := 0; i < typ.NumField(); i++ {
+			tag, opts, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			fields = append(fields, tag)
+			if opts != "omitempty" && typ.Field(i).Type.Kind() != reflect.Ptr {
+				required = append(required, tag)
+			}
+		}
+		var props []string
+		for prop, p := range schema.Properties {
+			props = append(props, prop)
+			if want, ok := apiv1.Enums[name+"."+prop]; !slices.Equal(p.Enum, want) {
+				t.Errorf("%s.%s: documented enum %v, Go enum %v (known: %v)", name, prop, p.Enum, want, ok)
+			}
+		}
+		sort.Strings(fields)
+		sort.Strings(props)
+		sort.Strings(required)
+		specRequired := slices.Clone(schema.Required)
+		sort.Strings(specRequired)
+		if !slices.Equal(fields, props) {
+			t.Errorf("%s: documented properties %v, Go fields %v", name, props, fields)
+		}
+		if !slices.Equal(required, specRequired) {
+			t.Errorf("%s: documented required %v, Go always sends %v", name, specRequired, required)
+		}
+	}
+	for name := range types {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("type %s is not documented", name)
+		}
+	}
+}
+
+func newV1TestServer(t *testing.T) http.Handler {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	table := router.NewTable(ctx, &config.Config{HealthCheckInterval: time.Hour})
+	pool, err := table.AddPoolConfig(config.PoolConfig{
+		Name:    "web",
+		Servers: []config.ServerConfig{{ID: "web-1", Address: "localhost", Port: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := NewAPI(pool.Manager, pool.Balancer, pool.Breaker, metrics.NewMetricsManager(pool.Manager), events.NewEventSystem(10))
+	api.Router = table
+	mux := http.NewServeMux()
+	api.RegisterHandlers(mux)
+	return mux
+}
+
+func do(h http.Handler, method, path, ifMatch, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestV1_ProblemsAndOptimisticConcurrency(t *testing.T) {
+	h := newV1TestServer(t)
+
+	rec := do(h, http.MethodGet, "/api/v1/servers/nope", "", "")
+	var problem apiv1.Problem
+	json.Unmarshal(rec.Body.Bytes(), &problem)
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Content-Type") != apiv1.ProblemContentType ||
+		problem.Type != apiv1.ProblemNotFound || problem.Instance != "/api/v1/servers/nope" {
+		t.Errorf("unknown server: %d %s %+v", rec.Code, rec.Header().Get("Content-Type"), problem)
+	}
+
+	rec = do(h, http.MethodPut, "/api/v1/pools/web", "", "")
+	if rec.Code != http.StatusMethodNotAllowed || !slices.Contains(rec.Header().Values("Allow"), http.MethodPatch) {
+		t.Errorf("PUT pool: %d, Allow %v", rec.Code, rec.Header().Values("Allow"))
+	}
+
+	rec = do(h, http.MethodGet, "/api/v1/pools/web", "", "")
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("GET pool: %d, ETag %q", rec.Code, etag)
+	}
+
+	rec = do(h, http.MethodPatch, "/api/v1/pools/web", etag, `{"strategy":"least-connections"}`)
+	var pool apiv1.Pool
+	json.Unmarshal(rec.Body.Bytes(), &pool)
+	if rec.Code != http.StatusOK || pool.Strategy != apiv1.StrategyLeastConnections {
+		t.Fatalf("PATCH pool: %d %s", rec.Code, rec.Body)
+	}
+	if rec.Header().Get("ETag") == etag {
+		t.Error("ETag unchanged after the pool changed")
+	}
+
+	rec = do(h, http.MethodPatch, "/api/v1/pools/web", etag, `{"strategy":"ip-hash"}`)
+	json.Unmarshal(rec.Body.Bytes(), &problem)
+	if rec.Code != http.StatusPreconditionFailed || problem.Type != apiv1.ProblemPreconditionFailed {
+		t.Errorf("PATCH with stale ETag: %d %+v", rec.Code, problem)
+	}
+
+	rec = do(h, http.MethodPost, "/api/v1/servers/web-1/disable", "", "")
+	var srv apiv1.Server
+	json.Unmarshal(rec.Body.Bytes(), &srv)
+	if rec.Code != http.StatusOK || srv.State != apiv1.StateDown || srv.Breaker.State != apiv1.BreakerOpen || srv.Pool != "web" {
+		t.Errorf("disable: %d %+v", rec.Code, srv)
+	}
+
+	etag = do(h, http.MethodGet, "/api/v1/servers/web-1", "", "").Header().Get("ETag")
+	if !strings.HasPrefix(etag, `W/"`) {
+		t.Errorf("server ETag %q is not weak", etag)
+	}
+	if rec = do(h, http.MethodPost, "/api/v1/servers/web-1/enable", etag, ""); rec.Code != http.StatusOK {
+		t.Errorf("enable with the weak ETag: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestV1_ConcurrentConditionalChangesApplyOnce(t *testing.T) {
+	h := newV1TestServer(t)
+	etag := do(h, http.MethodGet, "/api/v1/pools/web", "", "").Header().Get("ETag")
+
+	codes := make(chan int, 8)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(codes); i++ {
+		strategy := []string{"least-connections", "ip-hash"}[i%2]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- do(h, http.MethodPatch, "/api/v1/pools/web", etag, `{"strategy":"`+strategy+`"}`).Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	applied := 0
+	for code := range codes {
+		switch code {
+		case http.StatusOK:
+			applied++
+		case http.StatusPreconditionFailed:
+		default:
+			t.Errorf("unexpected status %d", code)
+		}
+	}
+	if applied != 1 {
+		t.Errorf("%d changes applied with the same ETag, want 1", applied)
+	}
+}
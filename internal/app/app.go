@@ -0,0 +1,331 @@
+// internal/app/app.go
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"load-balancer/internal/api"
+	"load-balancer/internal/canary"
+	"load-balancer/internal/cluster"
+	"load-balancer/internal/config"
+	"load-balancer/internal/dashboard"
+	"load-balancer/internal/dispatch"
+	"load-balancer/internal/events"
+	"load-balancer/internal/ha"
+	"load-balancer/internal/handoff"
+	"load-balancer/internal/history"
+	"load-balancer/internal/lifecycle"
+	"load-balancer/internal/metrics"
+	"load-balancer/internal/mirror"
+	"load-balancer/internal/proxy"
+	"load-balancer/internal/router"
+	"load-balancer/internal/scenario"
+	"load-balancer/internal/server"
+	"load-balancer/internal/snapshot"
+	ratelimiter "load-balancer/rate_limiter"
+)
+
+// App is a fully wired load balancer: routing table and pools, metrics,
+// events, snapshots, the proxy endpoint, the admin API and the dashboard.
+// It does not listen by itself; serve Handler on a listener of your choice.
+type App struct {
+	Config     *config.Config
+	Events     *events.EventSystem
+	Upstreams  *proxy.Registry
+	Routes     *router.Table
+	Primary    *router.Pool // backs the dashboard and the classic /api/servers views
+	Metrics    *metrics.MetricsManager
+	Snapshots  *snapshot.Manager
+	History    *history.Store // nil when cfg.History.Dir is empty or unusable
+	Limiter    *ratelimiter.ClientLimiter
+	Dispatcher *dispatch.Dispatcher // serves /lb/ and /api/test probes; add filters before serving
+	Lifecycle  *lifecycle.Lifecycle
+	HA         *ha.Node
+	Cluster    *cluster.Node
+	API        *api.API
+
+	// Handler serves /lb/, /api/, /healthz, /readyz and the dashboard
+	Handler http.Handler
+
+	// Proxy and Admin split Handler for embedding: Proxy dispatches every
+	// request it gets, Admin serves everything but /lb/
+	Proxy http.Handler
+	Admin http.Handler
+
+	stopLoops     context.CancelFunc
+	stopSnapshots context.CancelFunc
+}
+
+// New wires a balancer from cfg, restoring the saved snapshot and any state
+// handed over by a previous process. Background loops (health checks,
+// breakers, canaries, snapshots, HA and gossip) run until StopLoops; the
+// event history is written until CloseHistory.
+// cfg.LBPort must be the port the balancer will serve on, as scenario drills
+// send their traffic there.
+func New(cfg *config.Config, inherited *handoff.Inherited) (_ *App, err error) {
+	// 2. Create event system for real-time notifications
+	eventSystem := events.NewEventSystem(100) // Keep last 100 events
+
+	// 2a. Record every event, packets included, to the on-disk history.
+	// Writing starts once wiring succeeds; until then events queue.
+	var historyStore *history.Store
+	if cfg.History.Dir != "" {
+		store, err := history.Open(history.Options{
+			Dir:          cfg.History.Dir,
+			SegmentBytes: cfg.History.SegmentBytes,
+			MaxBytes:     cfg.History.MaxBytes,
+			MaxAge:       cfg.History.MaxAge,
+		})
+		if err != nil {
+			log.Printf("Event history disabled: %v", err)
+		} else {
+			historyStore = store
+			eventSystem.ContinueAfter(store.LastID())
+			eventSystem.OnEvent(store.Append)
+		}
+	}
+	defer func() {
+		if err != nil && historyStore != nil {
+			historyStore.Close()
+		}
+	}()
+
+	// 3. Create the shared upstream connection pools, one per backend
+	upstreams := proxy.NewRegistry(proxy.PoolSettings{
+		MaxIdleConns:          cfg.Upstream.MaxIdleConns,
+		MaxConns:              cfg.Upstream.MaxConns,
+		IdleConnTimeout:       cfg.Upstream.IdleConnTimeout,
+		KeepAlive:             cfg.Upstream.KeepAlive,
+		DisableKeepAlives:     cfg.Upstream.DisableKeepAlives,
+		DialTimeout:           cfg.Upstream.DialTimeout,
+		TLSHandshakeTimeout:   cfg.Upstream.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.Upstream.ResponseHeaderTimeout,
+		RequestTimeout:        cfg.Upstream.RequestTimeout,
+	})
+
+	// 4. Build the routing table. Each pool gets its own server manager,
+	// balancer (WRR / least-connections / IP hash + sticky sessions),
+	// circuit breaker and health checker, all stopped via poolCtx.
+	poolCtx, poolCancel := context.WithCancel(context.Background())
+	routes := router.NewTable(poolCtx, cfg)
+	routes.OnPoolAdded(func(p *router.Pool) {
+		upstreams.Watch(p.Manager)
+		p.Breaker.OnTrip(func(srv *server.Server) {
+			eventSystem.Emit(events.Event{
+				Type:     events.BreakerEvent,
+				Source:   "breaker",
+				ServerID: srv.ID,
+				Message:  fmt.Sprintf("Server %s circuit breaker opened after %d failures", srv.ID, server.GetBreaker(srv).Failures),
+			})
+		})
+	})
+	for _, poolCfg := range cfg.Pools {
+		if _, err := routes.AddPoolConfig(poolCfg); err != nil {
+			poolCancel()
+			return nil, fmt.Errorf("creating pool %s: %w", poolCfg.Name, err)
+		}
+	}
+	for _, routeCfg := range cfg.Routes {
+		if err := routes.SetRoute(routeCfg); err != nil {
+			poolCancel()
+			return nil, fmt.Errorf("creating route %s: %w", routeCfg.Name, err)
+		}
+	}
+
+	// The primary pool backs the dashboard and the classic /api/servers views.
+	primary := routes.Pool(config.DefaultPoolName)
+	if primary == nil {
+		pools := routes.Pools()
+		if len(pools) == 0 {
+			poolCancel()
+			return nil, fmt.Errorf("no backend pools configured")
+		}
+		primary = pools[0]
+	}
+
+	// 5. Create metrics manager for tracking load balancer performance
+	metricsManager := metrics.NewMetricsManager(primary.Manager)
+	metricsManager.Upstreams = upstreams
+
+	// Restore runtime state saved by a previous run, then let a live handoff,
+	// which is always fresher, override sessions and breaker state.
+	snapshots := snapshot.NewManager(routes, metricsManager, eventSystem, cfg.Snapshot.Path)
+	if snap, err := snapshots.Load(); err != nil {
+		log.Printf("Ignoring snapshot %s: %v", cfg.Snapshot.Path, err)
+	} else if snap != nil {
+		result, err := snapshots.Restore(snap)
+		if err != nil {
+			log.Printf("Ignoring snapshot %s: %v", cfg.Snapshot.Path, err)
+		} else {
+			log.Printf("Restored snapshot from %s: %d sticky sessions, %d server states, %d events (%d servers skipped)",
+				snap.Taken.Format(time.RFC3339), result.Sessions, result.Servers, result.Events, len(result.SkippedServers))
+		}
+	}
+	if inherited != nil {
+		sessions, servers := handoff.Restore(routes, inherited.State)
+		log.Printf("Restored %d sticky sessions and %d server states from previous process", sessions, servers)
+	}
+
+	// Resolve client IPs, honouring forwarding headers only from trusted proxies
+	clientIPs, err := proxy.NewClientIPResolver(cfg.TrustedProxies)
+	if err != nil {
+		poolCancel()
+		return nil, fmt.Errorf("invalid trusted proxy configuration: %w", err)
+	}
+
+	// Shadow traffic for routes with a mirror policy
+	mirrors := mirror.NewSender(upstreams, metricsManager, cfg.MirrorMaxInFlight)
+
+	var limiter *ratelimiter.ClientLimiter
+	if cfg.RateLimit.RequestsPerSecond > 0 {
+		limiter = ratelimiter.NewClientLimiter(cfg.RateLimit.RequestsPerSecond, cfg.RateLimit.Burst)
+	}
+
+	// Share sticky bindings, breaker ejections and rate-limit counters with
+	// the other replicas
+	var clusterNode *cluster.Node
+	if cfg.Cluster.Bind != "" {
+		transport, err := cluster.NewUDPTransport(cfg.Cluster.Bind)
+		if err != nil {
+			poolCancel()
+			return nil, fmt.Errorf("starting cluster gossip: %w", err)
+		}
+		clusterNode, err = cluster.NewNode(cfg.Cluster, transport, eventSystem)
+		if err != nil {
+			transport.Close()
+			poolCancel()
+			return nil, fmt.Errorf("starting cluster gossip: %w", err)
+		}
+		cluster.Share(poolCtx, clusterNode, routes, limiter)
+		go clusterNode.Run(poolCtx)
+	}
+
+	// Log startup information
+	eventSystem.Publish(events.InfoEvent, "Load balancer starting up")
+	eventSystem.Publish(events.InfoEvent, fmt.Sprintf("Using IP Hash: %v, Sticky Sessions: %v",
+		cfg.UseIPHash, cfg.UseStickySessions))
+
+	// Readiness and in-flight tracking for the balancer itself
+	lc := lifecycle.New()
+
+	// Active/passive pair: only the elected leader reports ready, and the
+	// standby keeps a replica of the leader's sessions and breaker state.
+	var haNode *ha.Node
+	if cfg.HA.Bind != "" {
+		haNode, err = ha.NewNode(cfg.HA, eventSystem,
+			func() *handoff.State { return handoff.Capture(routes) },
+			func(state *handoff.State) { handoff.Restore(routes, state) })
+		if err != nil {
+			poolCancel()
+			return nil, fmt.Errorf("starting leader election: %w", err)
+		}
+		lc.AddReadinessCheck("standby", haNode.IsLeader)
+		go haNode.Run(poolCtx)
+	}
+
+	// 9a. Load balancer endpoint: match a route, rewrite the path for its
+	// pool, run the filters and proxy with retries
+	dispatcher := dispatch.New(routes, upstreams, mirrors, metricsManager, eventSystem)
+	dispatcher.Use(func(next http.Handler) http.Handler {
+		return lc.Track(next.ServeHTTP)
+	})
+	if limiter != nil {
+		dispatcher.AddFilter(dispatch.RateLimit(limiter, eventSystem))
+	}
+
+	// 9b. Setup the dashboard API endpoints
+	admin := http.NewServeMux()
+	admin.HandleFunc("/healthz", lc.HealthzHandler())
+	admin.HandleFunc("/readyz", lc.ReadyzHandler())
+
+	apiHandler := api.NewAPI(primary.Manager, primary.Balancer, primary.Breaker, metricsManager, eventSystem)
+	apiHandler.Router = routes
+	apiHandler.DrainTimeout = cfg.DrainTimeout
+	apiHandler.Lifecycle = lc
+	apiHandler.Snapshots = snapshots
+	apiHandler.History = historyStore
+	apiHandler.HA = haNode
+	apiHandler.Cluster = clusterNode
+	apiHandler.Canary = canary.NewAnalyzer(poolCtx, routes, metricsManager, eventSystem)
+	// Scenario drills send their traffic back through this balancer's own
+	// port, so they need one
+	if cfg.LBPort > 0 {
+		fleet := api.ScenarioFleet{API: apiHandler}
+		apiHandler.Scenarios = scenario.NewEngine(poolCtx,
+			scenario.NewHTTPSender(fmt.Sprintf("http://127.0.0.1:%d", cfg.LBPort)), fleet, eventSystem)
+		apiHandler.Scenarios.Faults = fleet
+	}
+	// /api/test probes take the same path as client traffic on /lb/
+	apiHandler.Probe = dispatcher
+	apiHandler.ProbePath = cfg.SyntheticProbePath
+	apiHandler.RegisterHandlers(admin)
+
+	// 9c. Setup the dashboard UI
+	admin.HandleFunc("/", dashboard.Handler(primary.Manager))
+
+	// 9d. The balancer's own server: /lb/ beside the admin endpoints
+	mux := http.NewServeMux()
+	mux.Handle("/lb/", dispatcher)
+	mux.Handle("/", admin)
+
+	snapshotCtx, stopSnapshots := context.WithCancel(poolCtx)
+	go snapshots.Run(snapshotCtx, cfg.Snapshot.Interval)
+	if historyStore != nil {
+		historyStore.Start()
+	}
+
+	return &App{
+		Config:        cfg,
+		Events:        eventSystem,
+		Upstreams:     upstreams,
+		Routes:        routes,
+		Primary:       primary,
+		Metrics:       metricsManager,
+		Snapshots:     snapshots,
+		History:       historyStore,
+		Limiter:       limiter,
+		Dispatcher:    dispatcher,
+		Lifecycle:     lc,
+		HA:            haNode,
+		Cluster:       clusterNode,
+		API:           apiHandler,
+		Handler:       clientIPs.Middleware(mux),
+		Proxy:         clientIPs.Middleware(dispatcher),
+		Admin:         clientIPs.Middleware(admin),
+		stopLoops:     poolCancel,
+		stopSnapshots: stopSnapshots,
+	}, nil
+}
+
+// StopLoops stops health checkers, breaker monitors, canary analyses,
+// scenario runs, snapshots, HA and gossip.
+func (a *App) StopLoops() {
+	a.stopSnapshots()
+	a.stopLoops()
+}
+
+// StopSnapshots stops the periodic snapshot writer, e.g. once another
+// process owns the snapshot file.
+func (a *App) StopSnapshots() {
+	a.stopSnapshots()
+}
+
+// CloseHistory writes out queued events and closes the event history.
+// Events published afterwards are not stored.
+func (a *App) CloseHistory() {
+	if a.History != nil {
+		a.History.Close()
+	}
+}
+
+// Close stops every background loop, closes idle upstream connections and
+// the event history. It does not save a snapshot.
+func (a *App) Close() {
+	a.StopLoops()
+	a.Upstreams.CloseAll()
+	a.CloseHistory()
+}
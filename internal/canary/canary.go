@@ -0,0 +1,417 @@
+// internal/canary/canary.go
+package canary
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/events"
+	"load-balancer/internal/metrics"
+	"load-balancer/internal/router"
+)
+
+// Config describes an automated canary analysis for one split route.
+type Config struct {
+	Route             string          `json:"route"`
+	Baseline          string          `json:"baseline"`          // variant receiving the remaining traffic
+	Canary            string          `json:"canary"`            // variant being evaluated
+	Interval          config.Duration `json:"interval"`          // analysis window, default 30s
+	Steps             []float64       `json:"steps"`             // canary percentages, default 5,10,25,50,100
+	MinRequests       int64           `json:"minRequests"`       // canary requests needed per window, default 20
+	MaxErrorRateDelta float64         `json:"maxErrorRateDelta"` // canary error rate may exceed baseline by this much, default 0.02
+	MaxLatencyRatio   float64         `json:"maxLatencyRatio"`   // canary/baseline latency percentile ratio, default 1.5
+	LatencyPercentile float64         `json:"latencyPercentile"` // 50, 95 or 99; default 95
+}
+
+// Analysis states.
+const (
+	StateRunning    = "running"
+	StatePromoted   = "promoted"
+	StateRolledBack = "rolled-back"
+	StateAborted    = "aborted"
+)
+
+// Decision actions.
+const (
+	ActionPromote  = "promote"
+	ActionHold     = "hold"
+	ActionRollback = "rollback"
+	ActionComplete = "complete"
+)
+
+// Decision records the outcome of one analysis interval.
+type Decision struct {
+	Time     time.Time            `json:"time"`
+	Action   string               `json:"action"`
+	Reason   string               `json:"reason"`
+	Weight   float64              `json:"weight"` // canary percentage after the decision
+	Canary   metrics.VariantStats `json:"canary"`
+	Baseline metrics.VariantStats `json:"baseline"`
+}
+
+// Status reports the progress of an analysis.
+type Status struct {
+	Config     Config     `json:"config"`
+	State      string     `json:"state"`
+	Step       int        `json:"step"`
+	Weight     float64    `json:"weight"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt time.Time  `json:"finishedAt,omitempty"`
+	History    []Decision `json:"history"`
+}
+
+// maxHistory bounds the decisions kept per analysis.
+const maxHistory = 50
+
+// Analyzer runs canary analyses, shifting split weights on the routing table
+// based on the per-variant metrics and publishing every decision as an event.
+type Analyzer struct {
+	Router  *router.Table
+	Metrics *metrics.MetricsManager
+	Events  *events.EventSystem
+
+	ctx  context.Context
+	mu   sync.Mutex
+	runs map[string]*run
+}
+
+type run struct {
+	// changing is held by whatever changes the run's weight or state
+	// (Start, apply, Abort) from its state check until the status is
+	// updated, so an abort cannot interleave with a decision.
+	changing sync.Mutex
+
+	mu     sync.Mutex
+	status Status
+	total  float64 // combined baseline+canary weight to preserve
+	cancel context.CancelFunc
+}
+
+// NewAnalyzer creates an analyzer whose analyses stop when ctx is cancelled.
+func NewAnalyzer(ctx context.Context, table *router.Table, mm *metrics.MetricsManager, es *events.EventSystem) *Analyzer {
+	return &Analyzer{
+		Router:  table,
+		Metrics: mm,
+		Events:  es,
+		ctx:     ctx,
+		runs:    make(map[string]*run),
+	}
+}
+
+// withDefaults fills unset analysis settings.
+func (cfg Config) withDefaults() Config {
+	if cfg.Interval.Duration <= 0 {
+		cfg.Interval.Duration = 30 * time.Second
+	}
+	if len(cfg.Steps) == 0 {
+		cfg.Steps = []float64{5, 10, 25, 50, 100}
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 20
+	}
+	if cfg.MaxErrorRateDelta <= 0 {
+		cfg.MaxErrorRateDelta = 0.02
+	}
+	if cfg.MaxLatencyRatio <= 0 {
+		cfg.MaxLatencyRatio = 1.5
+	}
+	if cfg.LatencyPercentile != 50 && cfg.LatencyPercentile != 99 {
+		cfg.LatencyPercentile = 95
+	}
+	return cfg
+}
+
+// Start begins analysing a route's canary, setting it to the first step.
+func (a *Analyzer) Start(cfg Config) (Status, error) {
+	cfg = cfg.withDefaults()
+
+	route := a.Router.Route(cfg.Route)
+	if route == nil {
+		return Status{}, fmt.Errorf("route %s not found", cfg.Route)
+	}
+	split := route.Config.Split
+	if split == nil {
+		return Status{}, fmt.Errorf("route %s has no traffic split", cfg.Route)
+	}
+	if cfg.Baseline == cfg.Canary {
+		return Status{}, fmt.Errorf("baseline and canary must be different variants")
+	}
+
+	var baselineWeight, canaryWeight float64
+	found := 0
+	for _, v := range split.Variants {
+		switch v.Name {
+		case cfg.Baseline:
+			baselineWeight = v.Weight
+			found++
+		case cfg.Canary:
+			canaryWeight = v.Weight
+			found++
+		}
+	}
+	if found != 2 {
+		return Status{}, fmt.Errorf("route %s must have variants %s and %s", cfg.Route, cfg.Baseline, cfg.Canary)
+	}
+	total := baselineWeight + canaryWeight
+	for _, step := range cfg.Steps {
+		if step < 0 || step > total {
+			return Status{}, fmt.Errorf("step %.1f outside 0..%.1f", step, total)
+		}
+	}
+
+	a.mu.Lock()
+	if existing, ok := a.runs[cfg.Route]; ok && existing.state() == StateRunning {
+		a.mu.Unlock()
+		return Status{}, fmt.Errorf("an analysis is already running for route %s", cfg.Route)
+	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	r := &run{
+		status: Status{Config: cfg, State: StateRunning, StartedAt: time.Now()},
+		total:  total,
+		cancel: cancel,
+	}
+	r.changing.Lock()
+	a.runs[cfg.Route] = r
+	a.mu.Unlock()
+
+	err := a.setWeight(r, cfg.Steps[0])
+	r.changing.Unlock()
+	if err != nil {
+		cancel()
+		a.mu.Lock()
+		delete(a.runs, cfg.Route)
+		a.mu.Unlock()
+		return Status{}, err
+	}
+	a.publish(events.InfoEvent, fmt.Sprintf("Canary analysis started on route %s: %s at %.1f%% against %s",
+		cfg.Route, cfg.Canary, cfg.Steps[0], cfg.Baseline))
+
+	go a.loop(ctx, r)
+	return r.snapshot(), nil
+}
+
+// Abort stops a running analysis and sends all traffic back to the baseline.
+// A decision being applied finishes first.
+func (a *Analyzer) Abort(routeName string) (Status, error) {
+	a.mu.Lock()
+	r, ok := a.runs[routeName]
+	a.mu.Unlock()
+	if !ok {
+		return Status{}, fmt.Errorf("no analysis for route %s", routeName)
+	}
+
+	r.changing.Lock()
+	if r.state() != StateRunning {
+		r.changing.Unlock()
+		return r.snapshot(), nil
+	}
+	r.cancel()
+	err := a.setWeight(r, 0)
+	r.mu.Lock()
+	r.status.State = StateAborted
+	r.status.FinishedAt = time.Now()
+	r.mu.Unlock()
+	r.changing.Unlock()
+
+	a.publish(events.WarningEvent, fmt.Sprintf("Canary analysis on route %s aborted; traffic returned to %s",
+		routeName, r.status.Config.Baseline))
+	return r.snapshot(), err
+}
+
+// Status returns the analysis for a route.
+func (a *Analyzer) Status(routeName string) (Status, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	r, ok := a.runs[routeName]
+	if !ok {
+		return Status{}, false
+	}
+	return r.snapshot(), true
+}
+
+// List returns every analysis, running or finished.
+func (a *Analyzer) List() []Status {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	result := make([]Status, 0, len(a.runs))
+	for _, r := range a.runs {
+		result = append(result, r.snapshot())
+	}
+	return result
+}
+
+func (a *Analyzer) loop(ctx context.Context, r *run) {
+	cfg := r.snapshot().Config
+	ticker := time.NewTicker(cfg.Interval.Duration)
+	defer ticker.Stop()
+
+	windowStart := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			canaryStats := a.Metrics.VariantWindow(cfg.Route, cfg.Canary, windowStart)
+			baselineStats := a.Metrics.VariantWindow(cfg.Route, cfg.Baseline, windowStart)
+			windowStart = now
+
+			if done := a.apply(r, canaryStats, baselineStats); done {
+				r.cancel()
+				return
+			}
+		}
+	}
+}
+
+// apply evaluates one window and acts on the decision. It reports whether the
+// analysis has finished.
+func (a *Analyzer) apply(r *run, canaryStats, baselineStats metrics.VariantStats) bool {
+	r.changing.Lock()
+	defer r.changing.Unlock()
+
+	r.mu.Lock()
+	cfg := r.status.Config
+	step := r.status.Step
+	state := r.status.State
+	r.mu.Unlock()
+	if state != StateRunning {
+		// Aborted while this window was being evaluated.
+		return true
+	}
+
+	action, reason := Decide(cfg, canaryStats, baselineStats)
+	weight := cfg.Steps[step]
+
+	switch action {
+	case ActionRollback:
+		weight = 0
+	case ActionPromote:
+		step++
+		if step >= len(cfg.Steps) {
+			action = ActionComplete
+			step = len(cfg.Steps) - 1
+		}
+		weight = cfg.Steps[step]
+	}
+
+	var err error
+	if weight != r.snapshot().Weight {
+		err = a.setWeight(r, weight)
+	}
+
+	decision := Decision{
+		Time:     time.Now(),
+		Action:   action,
+		Reason:   reason,
+		Weight:   weight,
+		Canary:   canaryStats,
+		Baseline: baselineStats,
+	}
+
+	r.mu.Lock()
+	r.status.Step = step
+	r.status.History = append(r.status.History, decision)
+	if len(r.status.History) > maxHistory {
+		r.status.History = r.status.History[len(r.status.History)-maxHistory:]
+	}
+	finished := false
+	switch action {
+	case ActionRollback:
+		r.status.State = StateRolledBack
+		finished = true
+	case ActionComplete:
+		r.status.State = StatePromoted
+		finished = true
+	}
+	if finished {
+		r.status.FinishedAt = decision.Time
+	}
+	r.mu.Unlock()
+
+	summary := fmt.Sprintf("Canary %s on route %s: %s at %.1f%% (%s; canary err=%.1f%% p%.0f=%.0fms, baseline err=%.1f%% p%.0f=%.0fms)",
+		cfg.Canary, cfg.Route, action, weight, reason,
+		canaryStats.ErrorRate*100, cfg.LatencyPercentile, latencyAt(canaryStats, cfg.LatencyPercentile),
+		baselineStats.ErrorRate*100, cfg.LatencyPercentile, latencyAt(baselineStats, cfg.LatencyPercentile))
+	if err != nil {
+		summary += fmt.Sprintf(" [weight update failed: %v]", err)
+	}
+
+	switch action {
+	case ActionRollback:
+		a.publish(events.ErrorEvent, summary)
+	case ActionPromote, ActionComplete:
+		a.publish(events.SuccessEvent, summary)
+	default:
+		a.publish(events.InfoEvent, summary)
+	}
+	return finished
+}
+
+// Decide compares a canary window with the baseline window.
+func Decide(cfg Config, canaryStats, baselineStats metrics.VariantStats) (string, string) {
+	if canaryStats.Requests < cfg.MinRequests {
+		return ActionHold, fmt.Sprintf("insufficient canary traffic (%d/%d requests)", canaryStats.Requests, cfg.MinRequests)
+	}
+	if delta := canaryStats.ErrorRate - baselineStats.ErrorRate; delta > cfg.MaxErrorRateDelta {
+		return ActionRollback, fmt.Sprintf("error rate %.1f%% above baseline (limit %.1f%%)", delta*100, cfg.MaxErrorRateDelta*100)
+	}
+	canaryLatency := latencyAt(canaryStats, cfg.LatencyPercentile)
+	baselineLatency := latencyAt(baselineStats, cfg.LatencyPercentile)
+	if baselineLatency > 0 && canaryLatency > baselineLatency*cfg.MaxLatencyRatio {
+		return ActionRollback, fmt.Sprintf("p%.0f latency %.1fx baseline (limit %.1fx)",
+			cfg.LatencyPercentile, canaryLatency/baselineLatency, cfg.MaxLatencyRatio)
+	}
+	return ActionPromote, "within thresholds"
+}
+
+func latencyAt(stats metrics.VariantStats, percentile float64) float64 {
+	switch percentile {
+	case 50:
+		return stats.P50
+	case 99:
+		return stats.P99
+	default:
+		return stats.P95
+	}
+}
+
+// setWeight gives the canary the requested share of the baseline+canary total.
+func (a *Analyzer) setWeight(r *run, weight float64) error {
+	cfg := r.snapshot().Config
+	_, err := a.Router.SetSplitWeights(cfg.Route, map[string]float64{
+		cfg.Canary:   weight,
+		cfg.Baseline: r.total - weight,
+	})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.status.Weight = weight
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *run) state() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status.State
+}
+
+func (r *run) snapshot() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := r.status
+	status.History = append([]Decision(nil), r.status.History...)
+	return status
+}
+
+func (a *Analyzer) publish(eventType events.EventType, message string) {
+	a.Events.Emit(events.Event{Type: eventType, Source: "canary", Message: message})
+}
@@ -0,0 +1,161 @@
+package canary
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/events"
+	"load-balancer/internal/metrics"
+	"load-balancer/internal/router"
+)
+
+func newTestAnalyzer(t *testing.T) (*Analyzer, *router.Table, *events.EventSystem) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	table := router.NewTable(ctx, &config.Config{
+		HealthCheckInterval: time.Hour,
+		CircuitBreaker:      config.CircuitBreakerConfig{FailureThreshold: 3, CooldownPeriod: time.Second, TrialRequests: 1},
+	})
+	for _, name := range []string{"stable", "next"} {
+		_, err := table.AddPoolConfig(config.PoolConfig{
+			Name:    name,
+			Servers: []config.ServerConfig{{ID: name + "-1", Address: "localhost", Port: 1}},
+		})
+		if err != nil {
+			t.Fatalf("add pool %s: %v", name, err)
+		}
+	}
+	err := table.SetRoute(config.RouteConfig{
+		Name: "web", PathPrefix: "/lb/", Pool: "stable",
+		Split: &config.SplitConfig{Variants: []config.VariantConfig{
+			{Name: "baseline", Weight: 100},
+			{Name: "canary", Pool: "next", Weight: 0},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("set route: %v", err)
+	}
+
+	es := events.NewEventSystem(100)
+	return NewAnalyzer(ctx, table, metrics.NewMetricsManager(nil), es), table, es
+}
+
+func canaryWeight(table *router.Table) float64 {
+	for _, v := range table.Route("web").Config.Split.Variants {
+		if v.Name == "canary" {
+			return v.Weight
+		}
+	}
+	return -1
+}
+
+func TestAnalyzer_PromotesThenRollsBack(t *testing.T) {
+	a, table, es := newTestAnalyzer(t)
+
+	// A long interval keeps the background loop idle; windows are fed directly.
+	_, err := a.Start(Config{
+		Route: "web", Baseline: "baseline", Canary: "canary",
+		Interval: config.Duration{Duration: time.Hour},
+		Steps:    []float64{10, 50, 100},
+	})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if w := canaryWeight(table); w != 10 {
+		t.Fatalf("canary weight after start = %v, want 10", w)
+	}
+	r := a.runs["web"]
+
+	healthy := metrics.VariantStats{Requests: 100, ErrorRate: 0.01, P95: 50}
+	baseline := metrics.VariantStats{Requests: 900, ErrorRate: 0.01, P95: 45}
+
+	if done := a.apply(r, metrics.VariantStats{Requests: 3}, baseline); done {
+		t.Fatal("analysis finished on insufficient data")
+	}
+	if w := canaryWeight(table); w != 10 {
+		t.Fatalf("canary weight after hold = %v, want 10", w)
+	}
+
+	a.apply(r, healthy, baseline)
+	if w := canaryWeight(table); w != 50 {
+		t.Fatalf("canary weight after promotion = %v, want 50", w)
+	}
+
+	slow := metrics.VariantStats{Requests: 100, ErrorRate: 0.01, P95: 200}
+	if done := a.apply(r, slow, baseline); !done {
+		t.Fatal("analysis should finish on a latency breach")
+	}
+	if w := canaryWeight(table); w != 0 {
+		t.Fatalf("canary weight after rollback = %v, want 0", w)
+	}
+
+	status, _ := a.Status("web")
+	if status.State != StateRolledBack || len(status.History) != 3 {
+		t.Fatalf("status = %s with %d decisions, want %s with 3", status.State, len(status.History), StateRolledBack)
+	}
+
+	found := false
+	for _, ev := range es.GetRecentEvents(10) {
+		if ev.Type == events.ErrorEvent && strings.Contains(ev.Message, ActionRollback) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("rollback decision was not published")
+	}
+}
+
+func TestAnalyzer_AbortRacingTheFinalPromotion(t *testing.T) {
+	a, table, _ := newTestAnalyzer(t)
+	_, err := a.Start(Config{
+		Route: "web", Baseline: "baseline", Canary: "canary",
+		Interval: config.Duration{Duration: time.Hour},
+		Steps:    []float64{100},
+	})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	r := a.runs["web"]
+
+	healthy := metrics.VariantStats{Requests: 100, ErrorRate: 0.01, P95: 50}
+	done := make(chan struct{})
+	go func() {
+		a.apply(r, healthy, healthy)
+		close(done)
+	}()
+	status, err := a.Abort("web")
+	<-done
+	if err != nil {
+		t.Fatalf("abort: %v", err)
+	}
+
+	// Whichever came first, the other must not undo it
+	final, _ := a.Status("web")
+	switch final.State {
+	case StateAborted:
+		if w := canaryWeight(table); w != 0 {
+			t.Errorf("aborted with canary weight %v, want 0", w)
+		}
+	case StatePromoted:
+		if status.State != StatePromoted {
+			t.Errorf("abort reported %s after the analysis was promoted", status.State)
+		}
+	default:
+		t.Errorf("final state %s", final.State)
+	}
+}
+
+func TestDecide_ErrorRateBreach(t *testing.T) {
+	cfg := Config{}.withDefaults()
+	action, _ := Decide(cfg,
+		metrics.VariantStats{Requests: 50, ErrorRate: 0.10, P95: 40},
+		metrics.VariantStats{Requests: 500, ErrorRate: 0.01, P95: 40})
+	if action != ActionRollback {
+		t.Fatalf("action = %s, want %s", action, ActionRollback)
+	}
+}
@@ -0,0 +1,200 @@
+// internal/cluster/balancer.go
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"load-balancer/internal/events"
+	"load-balancer/internal/router"
+	"load-balancer/internal/server"
+	ratelimiter "load-balancer/rate_limiter"
+)
+
+// Key prefixes for the balancer state shared through the cluster.
+const (
+	stickyPrefix    = "sticky/"    // sticky/<pool>/<session> -> server ID
+	ejectionPrefix  = "ejection/"  // ejection/<server> -> ejection
+	rateLimitPrefix = "ratelimit/" // ratelimit/<node>/<client> -> requests allowed by that node
+)
+
+// rateLimitTTL is how long a replica's counter for an idle client is kept.
+const rateLimitTTL = time.Minute
+
+// ejection records that a replica's breaker opened for a server.
+type ejection struct {
+	OpenSince time.Time `json:"openSince"`
+}
+
+// Share keeps the sticky bindings and breaker ejections of every pool in
+// table, and the client counters of limiter (if any), consistent with the
+// other replicas in the cluster until ctx is cancelled.
+func Share(ctx context.Context, node *Node, table *router.Table, limiter *ratelimiter.ClientLimiter) {
+	table.OnPoolAdded(func(pool *router.Pool) { sharePool(node, pool) })
+	for _, pool := range table.Pools() {
+		sharePool(node, pool)
+	}
+
+	node.Handle(stickyPrefix, func(e Entry) { applyBinding(table, e) })
+	node.Handle(ejectionPrefix, func(e Entry) { applyEjection(node, table, e) })
+
+	if limiter != nil {
+		limiter.TrackUsage()
+		counters := &rateCounters{
+			node:    node,
+			limiter: limiter,
+			totals:  make(map[string]int64),
+			touched: make(map[string]time.Time),
+			seen:    make(map[string]int64),
+		}
+		node.Handle(rateLimitPrefix, counters.apply)
+		go counters.run(ctx)
+	}
+}
+
+// sharePool publishes the pool's new sticky bindings and breaker trips.
+func sharePool(node *Node, pool *router.Pool) {
+	sticky := pool.Balancer.StickySessionMgr
+	// A binding lives as long as the session stays idle; OnBind renews it
+	// while the session is in use.
+	sticky.OnBind(func(sessionID string, srv *server.Server) {
+		node.Set(stickyPrefix+pool.Name+"/"+sessionID, srv.ID, sticky.TTL())
+	})
+	sticky.OnUnbind(func(sessionID string) {
+		node.Delete(stickyPrefix+pool.Name+"/"+sessionID, stickyTombstoneTTL(sticky.TTL()))
+	})
+	pool.Breaker.OnTrip(func(srv *server.Server) {
+		// The ejection only matters until the breaker's cooldown ends.
+		node.Set(ejectionPrefix+srv.ID, ejection{OpenSince: server.GetBreaker(srv).OpenSince}, pool.Breaker.Settings.CooldownPeriod)
+	})
+}
+
+// stickyTombstoneTTL keeps a deleted binding long enough to outlive the
+// binding itself, or an hour for bindings that never expire.
+func stickyTombstoneTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return time.Hour
+	}
+	return ttl
+}
+
+// applyBinding installs a peer's sticky binding, replacing any local one,
+// or drops the local binding when the peer has deleted it.
+func applyBinding(table *router.Table, e Entry) {
+	poolName, sessionID, ok := strings.Cut(strings.TrimPrefix(e.Key, stickyPrefix), "/")
+	if !ok {
+		return
+	}
+	pool := table.Pool(poolName)
+	if pool == nil {
+		return
+	}
+	if e.Deleted() {
+		pool.Balancer.StickySessionMgr.Unbind(sessionID)
+		return
+	}
+	var serverID string
+	if err := e.Decode(&serverID); err != nil {
+		return
+	}
+	pool.Balancer.StickySessionMgr.RestoreSessions(map[string]string{sessionID: serverID})
+}
+
+// applyEjection opens the local breaker of a server a peer has tripped, with
+// the peer's OpenSince so both recover at the same time.
+func applyEjection(node *Node, table *router.Table, e Entry) {
+	serverID := strings.TrimPrefix(e.Key, ejectionPrefix)
+	var ej ejection
+	if err := e.Decode(&ej); err != nil {
+		return
+	}
+	pool := table.FindServer(serverID)
+	if pool == nil || time.Since(ej.OpenSince) >= pool.Breaker.Settings.CooldownPeriod {
+		return
+	}
+	for _, srv := range pool.Manager.GetAllServers() {
+		if srv.ID == serverID && pool.Breaker.ForceOpen(srv, ej.OpenSince) {
+			node.publish(events.WarningEvent, fmt.Sprintf("Cluster: server %s ejected on advice of peer %s", serverID, e.Node))
+		}
+	}
+}
+
+// rateCounters shares per-client request counts so the rate limit applies
+// across the cluster rather than per replica. Each replica owns one
+// ever-growing counter per client; peers charge their own buckets with the
+// increase they observe.
+type rateCounters struct {
+	node    *Node
+	limiter *ratelimiter.ClientLimiter
+
+	// Owned by run
+	totals  map[string]int64     // this replica's counters by client
+	touched map[string]time.Time // when each local counter last grew
+
+	mu   sync.Mutex
+	seen map[string]int64 // last value seen for each peer counter key
+}
+
+func (rc *rateCounters) run(ctx context.Context) {
+	ticker := time.NewTicker(rc.node.cfg.GossipInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for client, n := range rc.limiter.TakeUsage() {
+				rc.totals[client] += int64(n)
+				rc.touched[client] = now
+				rc.node.Set(rateLimitPrefix+rc.node.ID()+"/"+client, rc.totals[client], rateLimitTTL)
+			}
+			// Forget counters whose entries have expired everywhere.
+			for client, at := range rc.touched {
+				if now.Sub(at) > rateLimitTTL {
+					delete(rc.totals, client)
+					delete(rc.touched, client)
+				}
+			}
+			rc.forgetSeen()
+		}
+	}
+}
+
+func (rc *rateCounters) apply(e Entry) {
+	nodeID, client, ok := strings.Cut(strings.TrimPrefix(e.Key, rateLimitPrefix), "/")
+	if !ok || nodeID == rc.node.ID() {
+		return
+	}
+	var total int64
+	if err := e.Decode(&total); err != nil {
+		return
+	}
+
+	rc.mu.Lock()
+	delta := total - rc.seen[e.Key]
+	if delta < 0 {
+		delta = total // the peer restarted and is counting from zero again
+	}
+	rc.seen[e.Key] = total
+	rc.mu.Unlock()
+
+	if delta > 0 {
+		rc.limiter.Charge(client, int(delta))
+	}
+}
+
+// forgetSeen drops peer counters whose entries have expired.
+func (rc *rateCounters) forgetSeen() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for key := range rc.seen {
+		if _, ok := rc.node.Get(key); !ok {
+			delete(rc.seen, key)
+		}
+	}
+}
@@ -0,0 +1,523 @@
+// internal/cluster/node.go
+package cluster
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/events"
+)
+
+const (
+	// retransmits is how many gossip rounds a new update is pushed for.
+	retransmits = 4
+	// syncEvery is how many rounds pass between full push-pull exchanges,
+	// which repair anything the incremental rounds lost.
+	syncEvery = 10
+	// maxEntriesPerMessage keeps a message well inside one datagram.
+	maxEntriesPerMessage = 200
+	// maxClockSkew bounds how far a message's send time may be from ours, so
+	// a captured message cannot be replayed later.
+	maxClockSkew = 30 * time.Second
+)
+
+// Member is a peer as seen by this node.
+type Member struct {
+	ID          string    `json:"id"`
+	Addr        string    `json:"addr"`
+	Incarnation int64     `json:"incarnation"`
+	Heartbeat   uint64    `json:"heartbeat"`
+	LastSeen    time.Time `json:"lastSeen"` // when its heartbeat last increased
+	Alive       bool      `json:"alive"`
+}
+
+// envelope is one gossip message.
+type envelope struct {
+	From        string         `json:"from"`
+	Addr        string         `json:"addr"`
+	Incarnation int64          `json:"inc"`
+	Heartbeat   uint64         `json:"hb"`
+	Members     []memberDigest `json:"members,omitempty"`
+	Entries     []Entry        `json:"entries,omitempty"`
+	Sync        bool           `json:"sync,omitempty"` // asks the receiver to reply with its full state
+	Sent        int64          `json:"sent"`           // unix milliseconds
+}
+
+// signed is the wire form of every envelope: the encoded envelope and its
+// HMAC-SHA256 under the shared secret.
+type signed struct {
+	Body json.RawMessage `json:"body"`
+	MAC  []byte          `json:"mac"`
+}
+
+type memberDigest struct {
+	ID          string `json:"id"`
+	Addr        string `json:"addr"`
+	Incarnation int64  `json:"inc"`
+	Heartbeat   uint64 `json:"hb"`
+}
+
+// after reports whether d is a later heartbeat than m's. A restarted peer
+// has a new incarnation, so its heartbeat counting from zero still counts.
+func (d memberDigest) after(m *Member) bool {
+	if d.Incarnation != m.Incarnation {
+		return d.Incarnation > m.Incarnation
+	}
+	return d.Heartbeat > m.Heartbeat
+}
+
+type handler struct {
+	prefix string
+	fn     func(Entry)
+}
+
+// Node is one replica in the gossip cluster. It tracks membership with
+// gossiped heartbeats and disseminates Entries epidemically: each update is
+// pushed to Fanout random peers for a few rounds, and periodic full
+// exchanges make every replica converge.
+type Node struct {
+	cfg       config.ClusterConfig
+	transport Transport
+	Events    *events.EventSystem
+
+	mu          sync.Mutex
+	clock       uint64 // Lamport clock for entry versions
+	incarnation int64  // distinguishes this run from earlier runs of the same node
+	heartbeat   uint64
+	round       int
+	entries     store
+	pending     map[string]int // key -> rounds left to push it
+	members     map[string]*Member
+	handlers    []handler
+}
+
+// NewNode creates a cluster node gossiping over transport.
+func NewNode(cfg config.ClusterConfig, transport Transport, es *events.EventSystem) (*Node, error) {
+	if cfg.NodeID == "" {
+		return nil, fmt.Errorf("cluster: node ID is required")
+	}
+	if cfg.Secret == "" {
+		return nil, fmt.Errorf("cluster: shared secret is required")
+	}
+	if cfg.GossipInterval <= 0 {
+		cfg.GossipInterval = 200 * time.Millisecond
+	}
+	if cfg.Fanout <= 0 {
+		cfg.Fanout = 3
+	}
+	if cfg.DeadAfter <= cfg.GossipInterval {
+		cfg.DeadAfter = 25 * cfg.GossipInterval
+	}
+	return &Node{
+		cfg:         cfg,
+		transport:   transport,
+		Events:      es,
+		incarnation: time.Now().UnixNano(),
+		entries:     make(store),
+		pending:     make(map[string]int),
+		members:     make(map[string]*Member),
+	}, nil
+}
+
+// ID returns the node's name.
+func (n *Node) ID() string {
+	return n.cfg.NodeID
+}
+
+// Handle registers fn for every entry with the given key prefix that this
+// node learns from a peer, including tombstones (see Entry.Deleted). Local
+// Set and Delete calls do not invoke handlers.
+func (n *Node) Handle(prefix string, fn func(Entry)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.handlers = append(n.handlers, handler{prefix: prefix, fn: fn})
+}
+
+// Set writes a key locally and queues it for gossip. A ttl of 0 keeps the
+// entry until it is overwritten.
+func (n *Node) Set(key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	n.write(key, data, ttl)
+	return nil
+}
+
+// Delete removes a key everywhere. The tombstone is gossiped like a value
+// and kept for ttl, which must outlast the deleted entry's own TTL so a
+// peer cannot bring it back.
+func (n *Node) Delete(key string, ttl time.Duration) {
+	n.write(key, nil, ttl)
+}
+
+func (n *Node) write(key string, data json.RawMessage, ttl time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	// The clock never falls behind wall-clock milliseconds, so a restarted
+	// node's writes still beat the ones it made before the restart.
+	n.clock++
+	if now := uint64(time.Now().UnixMilli()); n.clock < now {
+		n.clock = now
+	}
+	e := Entry{Key: key, Value: data, Version: n.clock, Node: n.cfg.NodeID}
+	if ttl > 0 {
+		e.Expires = time.Now().Add(ttl).UnixNano()
+	}
+	n.entries[key] = e
+	n.pending[key] = retransmits
+}
+
+// Get returns the current entry for key.
+func (n *Node) Get(key string) (Entry, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	e, ok := n.entries[key]
+	if !ok || e.Deleted() || e.Expired(time.Now()) {
+		return Entry{}, false
+	}
+	return e, true
+}
+
+// Entries returns the live entries whose keys start with prefix.
+func (n *Node) Entries(prefix string) []Entry {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now()
+	var result []Entry
+	for key, e := range n.entries {
+		if strings.HasPrefix(key, prefix) && !e.Deleted() && !e.Expired(now) {
+			result = append(result, e)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
+
+// Members returns the known peers, alive or not.
+func (n *Node) Members() []Member {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	members := make([]Member, 0, len(n.members))
+	for _, m := range n.members {
+		members = append(members, *m)
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+	return members
+}
+
+// Run gossips until ctx is cancelled, then closes the transport.
+func (n *Node) Run(ctx context.Context) {
+	go n.receive()
+
+	ticker := time.NewTicker(n.cfg.GossipInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			n.transport.Close()
+			return
+		case <-ticker.C:
+			n.gossip()
+		}
+	}
+}
+
+// gossip runs one round: bump our heartbeat, age out peers and entries, and
+// push pending updates to random peers.
+func (n *Node) gossip() {
+	now := time.Now()
+
+	n.mu.Lock()
+	n.heartbeat++
+	n.round++
+	n.entries.expire(now)
+	departed := n.reapMembersLocked(now)
+
+	targets := n.pickTargetsLocked(n.cfg.Fanout)
+	var updates []Entry
+	for key, left := range n.pending {
+		if e, ok := n.entries[key]; ok {
+			updates = append(updates, e)
+		}
+		if left <= 1 {
+			delete(n.pending, key)
+		} else {
+			n.pending[key] = left - 1
+		}
+	}
+	base := n.envelopeLocked()
+	syncRound := n.round%syncEvery == 0
+	var full []Entry
+	if syncRound {
+		full = n.allEntriesLocked()
+	}
+	n.mu.Unlock()
+
+	for _, id := range departed {
+		n.publish(events.WarningEvent, fmt.Sprintf("Cluster: peer %s left or failed", id))
+	}
+
+	for _, addr := range targets {
+		n.send(addr, base, updates)
+	}
+	if syncRound && len(targets) > 0 {
+		sync := base
+		sync.Sync = true
+		n.send(targets[rand.Intn(len(targets))], sync, full)
+	}
+}
+
+// pickTargetsLocked chooses up to k random live peers, falling back to the
+// seeds while no peer is known.
+func (n *Node) pickTargetsLocked(k int) []string {
+	var addrs []string
+	for _, m := range n.members {
+		if m.Alive {
+			addrs = append(addrs, m.Addr)
+		}
+	}
+	if len(addrs) == 0 {
+		for _, seed := range n.cfg.Seeds {
+			if seed != n.transport.Addr() {
+				addrs = append(addrs, seed)
+			}
+		}
+	}
+	rand.Shuffle(len(addrs), func(i, j int) { addrs[i], addrs[j] = addrs[j], addrs[i] })
+	if len(addrs) > k {
+		addrs = addrs[:k]
+	}
+	return addrs
+}
+
+// reapMembersLocked marks silent peers dead and forgets long-dead ones. It
+// returns the IDs that just died.
+func (n *Node) reapMembersLocked(now time.Time) []string {
+	var departed []string
+	for id, m := range n.members {
+		silent := now.Sub(m.LastSeen)
+		switch {
+		case silent > 3*n.cfg.DeadAfter:
+			delete(n.members, id)
+		case m.Alive && silent > n.cfg.DeadAfter:
+			m.Alive = false
+			departed = append(departed, id)
+		}
+	}
+	return departed
+}
+
+func (n *Node) envelopeLocked() envelope {
+	env := envelope{From: n.cfg.NodeID, Addr: n.transport.Addr(), Incarnation: n.incarnation, Heartbeat: n.heartbeat}
+	for _, m := range n.members {
+		if m.Alive {
+			env.Members = append(env.Members, memberDigest{ID: m.ID, Addr: m.Addr, Incarnation: m.Incarnation, Heartbeat: m.Heartbeat})
+		}
+	}
+	return env
+}
+
+func (n *Node) allEntriesLocked() []Entry {
+	entries := make([]Entry, 0, len(n.entries))
+	for _, e := range n.entries {
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// send delivers entries to addr, split across as many messages as needed.
+func (n *Node) send(addr string, env envelope, entries []Entry) {
+	for {
+		batch := entries
+		if len(batch) > maxEntriesPerMessage {
+			batch = batch[:maxEntriesPerMessage]
+		}
+		entries = entries[len(batch):]
+
+		env.Entries = batch
+		env.Sent = time.Now().UnixMilli()
+		data, err := n.seal(env)
+		if err == nil {
+			err = n.transport.Send(addr, data)
+		}
+		if err != nil {
+			log.Printf("Cluster: unable to gossip to %s: %v", addr, err)
+		}
+		// Only the first message carries the sync request.
+		env.Sync = false
+		if len(entries) == 0 {
+			return
+		}
+	}
+}
+
+func (n *Node) receive() {
+	for packet := range n.transport.Packets() {
+		var env envelope
+		if !n.open(packet.Data, &env) || !n.accepts(env) {
+			continue
+		}
+		n.handle(env)
+	}
+}
+
+func (n *Node) mac(body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(n.cfg.Secret))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// seal encodes and signs an envelope.
+func (n *Node) seal(env envelope) ([]byte, error) {
+	body, err := json.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(signed{Body: body, MAC: n.mac(body)})
+}
+
+// open checks a sealed envelope's signature and decodes it into env. It
+// reports false for unsigned, forged or malformed packets.
+func (n *Node) open(data []byte, env *envelope) bool {
+	var s signed
+	if err := json.Unmarshal(data, &s); err != nil || len(s.Body) == 0 {
+		return false
+	}
+	if !hmac.Equal(s.MAC, n.mac(s.Body)) {
+		return false
+	}
+	return json.Unmarshal(s.Body, env) == nil
+}
+
+// accepts reports whether a verified envelope is from a peer and recent.
+func (n *Node) accepts(env envelope) bool {
+	skew := time.Since(time.UnixMilli(env.Sent))
+	return env.From != "" && env.From != n.cfg.NodeID && skew < maxClockSkew && skew > -maxClockSkew
+}
+
+// handle merges a peer's membership view and entries.
+func (n *Node) handle(env envelope) {
+	now := time.Now()
+
+	n.mu.Lock()
+	joined := n.observeLocked(memberDigest{ID: env.From, Addr: env.Addr, Incarnation: env.Incarnation, Heartbeat: env.Heartbeat}, now)
+	for _, digest := range env.Members {
+		if digest.ID != n.cfg.NodeID && n.observeLocked(digest, now) {
+			joined = true
+		}
+	}
+
+	var applied []Entry
+	for _, e := range env.Entries {
+		if e.Version > n.clock {
+			n.clock = e.Version
+		}
+		if n.entries.merge(e, now) {
+			n.pending[e.Key] = retransmits
+			applied = append(applied, e)
+		}
+	}
+	handlers := append([]handler(nil), n.handlers...)
+
+	var reply envelope
+	var full []Entry
+	if env.Sync {
+		reply = n.envelopeLocked()
+		full = n.allEntriesLocked()
+	}
+	n.mu.Unlock()
+
+	if joined {
+		n.publish(events.InfoEvent, fmt.Sprintf("Cluster: %d peers alive", n.aliveCount()))
+	}
+	for _, e := range applied {
+		for _, h := range handlers {
+			if strings.HasPrefix(e.Key, h.prefix) {
+				h.fn(e)
+			}
+		}
+	}
+	if env.Sync {
+		n.send(env.Addr, reply, full)
+	}
+}
+
+// observeLocked records a heartbeat and reports whether the member is new or
+// came back to life.
+func (n *Node) observeLocked(digest memberDigest, now time.Time) bool {
+	m, ok := n.members[digest.ID]
+	if !ok {
+		n.members[digest.ID] = &Member{ID: digest.ID, Addr: digest.Addr, Incarnation: digest.Incarnation,
+			Heartbeat: digest.Heartbeat, LastSeen: now, Alive: true}
+		return true
+	}
+	if !digest.after(m) {
+		return false
+	}
+	m.Incarnation, m.Heartbeat, m.Addr, m.LastSeen = digest.Incarnation, digest.Heartbeat, digest.Addr, now
+	if !m.Alive {
+		m.Alive = true
+		return true
+	}
+	return false
+}
+
+func (n *Node) aliveCount() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	alive := 0
+	for _, m := range n.members {
+		if m.Alive {
+			alive++
+		}
+	}
+	return alive
+}
+
+func (n *Node) publish(eventType events.EventType, message string) {
+	log.Print(message)
+	if n.Events != nil {
+		n.Events.Emit(events.Event{Type: eventType, Source: "cluster", Message: message})
+	}
+}
+
+// Status summarises the node for the admin API.
+type Status struct {
+	Node    string         `json:"node"`
+	Addr    string         `json:"addr"`
+	Members []Member       `json:"members"`
+	Keys    map[string]int `json:"keys"` // live entries per key prefix
+}
+
+// Status returns the node's membership view and entry counts.
+func (n *Node) Status() Status {
+	status := Status{Node: n.cfg.NodeID, Addr: n.transport.Addr(), Members: n.Members(), Keys: make(map[string]int)}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	now := time.Now()
+	for key, e := range n.entries {
+		if !e.Deleted() && !e.Expired(now) {
+			prefix, _, _ := strings.Cut(key, "/")
+			status.Keys[prefix]++
+		}
+	}
+	return status
+}
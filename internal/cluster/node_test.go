@@ -0,0 +1,211 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/router"
+	"load-balancer/internal/server"
+	ratelimiter "load-balancer/rate_limiter"
+)
+
+const testSecret = "cluster-test-secret"
+
+type replica struct {
+	node    *Node
+	table   *router.Table
+	limiter *ratelimiter.ClientLimiter
+}
+
+func newReplica(t *testing.T, ctx context.Context, network *MemoryNetwork, id string, seeds []string) *replica {
+	t.Helper()
+	table := router.NewTable(ctx, &config.Config{
+		HealthCheckInterval: time.Hour,
+		UseStickySessions:   true,
+		StickySessionTTL:    time.Minute,
+		CircuitBreaker:      config.CircuitBreakerConfig{FailureThreshold: 2, CooldownPeriod: time.Minute, TrialRequests: 1},
+	})
+	_, err := table.AddPoolConfig(config.PoolConfig{
+		Name: "web",
+		Servers: []config.ServerConfig{
+			{ID: "web-1", Address: "localhost", Port: 1},
+			{ID: "web-2", Address: "localhost", Port: 2},
+		},
+	})
+	if err != nil {
+		t.Fatalf("add pool: %v", err)
+	}
+
+	node, err := NewNode(config.ClusterConfig{
+		NodeID:         id,
+		Seeds:          seeds,
+		GossipInterval: 10 * time.Millisecond,
+		Fanout:         2,
+		DeadAfter:      200 * time.Millisecond,
+		Secret:         testSecret,
+	}, network.Transport(id), nil)
+	if err != nil {
+		t.Fatalf("new node: %v", err)
+	}
+	limiter := ratelimiter.NewClientLimiter(1, 5)
+	Share(ctx, node, table, limiter)
+	go node.Run(ctx)
+	return &replica{node: node, table: table, limiter: limiter}
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func aliveMembers(n *Node) int {
+	alive := 0
+	for _, m := range n.Members() {
+		if m.Alive {
+			alive++
+		}
+	}
+	return alive
+}
+
+func TestCluster_SharesStateBetweenInProcessReplicas(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	network := NewMemoryNetwork()
+	var replicas []*replica
+	for i := 0; i < 3; i++ {
+		replicas = append(replicas, newReplica(t, ctx, network, fmt.Sprintf("lb-%d", i), []string{"lb-0"}))
+	}
+	a, b, c := replicas[0], replicas[1], replicas[2]
+
+	eventually(t, "full membership", func() bool {
+		return aliveMembers(a.node) == 2 && aliveMembers(b.node) == 2 && aliveMembers(c.node) == 2
+	})
+
+	// A sticky binding made on one replica is honoured by the others.
+	web2 := a.table.Pool("web").Manager.GetAllServers()[1]
+	a.table.Pool("web").Balancer.StickySessionMgr.BindSessionToServer("alice", web2)
+	eventually(t, "the binding to reach every replica", func() bool {
+		for _, r := range replicas[1:] {
+			if r.table.Pool("web").Balancer.StickySessionMgr.Sessions()["alice"] != "web-2" {
+				return false
+			}
+		}
+		return true
+	})
+	if e, _ := c.node.Get(stickyPrefix + "web/alice"); e.Expires == 0 {
+		t.Errorf("sticky binding shared without a TTL")
+	}
+
+	// Dropping the binding, here because web-2 drains, drops it everywhere.
+	a.table.Pool("web").Balancer.StickySessionMgr.ForgetServer(web2)
+	eventually(t, "the binding to be dropped on every replica", func() bool {
+		for _, r := range replicas[1:] {
+			if _, ok := r.table.Pool("web").Balancer.StickySessionMgr.Sessions()["alice"]; ok {
+				return false
+			}
+		}
+		_, ok := c.node.Get(stickyPrefix + "web/alice")
+		return !ok
+	})
+
+	// A breaker tripped on one replica ejects the server everywhere.
+	pool := b.table.Pool("web")
+	web1 := pool.Manager.GetAllServers()[0]
+	pool.Breaker.RecordFailure(web1)
+	pool.Breaker.RecordFailure(web1)
+	eventually(t, "the ejection to reach every replica", func() bool {
+		return server.GetBreakerState(c.table.Pool("web").Manager.GetAllServers()[0]) == server.CBStateOpen &&
+			server.GetBreakerState(a.table.Pool("web").Manager.GetAllServers()[0]) == server.CBStateOpen
+	})
+
+	// Requests allowed by one replica count against the client everywhere.
+	for i := 0; i < 5; i++ {
+		if !a.limiter.Allow("198.51.100.7") {
+			t.Fatalf("request %d rejected by the first replica", i+1)
+		}
+	}
+	eventually(t, "the client's usage to reach every replica", func() bool {
+		_, ok := c.node.Get(rateLimitPrefix + "lb-0/198.51.100.7")
+		return ok
+	})
+	if c.limiter.Allow("198.51.100.7") {
+		t.Errorf("another replica still allowed a client that used its whole burst elsewhere")
+	}
+
+	// A replica that drops off the network is declared dead.
+	network.SetDown("lb-2", true)
+	eventually(t, "the partitioned replica to be declared dead", func() bool {
+		return aliveMembers(a.node) == 1
+	})
+}
+
+func TestNode_DropsUnsignedAndForgedGossip(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	network := NewMemoryNetwork()
+	r := newReplica(t, ctx, network, "lb-0", nil)
+	mallory := network.Transport("mallory")
+
+	gossip := func(key string) envelope {
+		return envelope{From: "mallory", Addr: "mallory", Incarnation: 1, Heartbeat: 1, Sent: time.Now().UnixMilli(),
+			Entries: []Entry{{Key: key, Value: []byte(`"web-2"`), Version: 1, Node: "mallory"}}}
+	}
+	unsigned, _ := json.Marshal(gossip(stickyPrefix + "web/unsigned"))
+	forger := &Node{cfg: config.ClusterConfig{Secret: "guess"}}
+	forged, _ := forger.seal(gossip(stickyPrefix + "web/forged"))
+	stale := gossip(stickyPrefix + "web/replayed")
+	stale.Sent = time.Now().Add(-time.Hour).UnixMilli()
+	replayed, _ := r.node.seal(stale)
+	signed, _ := r.node.seal(gossip(stickyPrefix + "web/signed"))
+
+	// Packets arrive in order, so once the signed one is in, the others were
+	// already seen.
+	for _, data := range [][]byte{unsigned, forged, replayed, signed} {
+		mallory.Send("lb-0", data)
+	}
+	eventually(t, "the signed entry to be applied", func() bool {
+		_, ok := r.node.Get(stickyPrefix + "web/signed")
+		return ok
+	})
+	for _, name := range []string{"unsigned", "forged", "replayed"} {
+		if _, ok := r.node.Get(stickyPrefix + "web/" + name); ok {
+			t.Errorf("applied a %s entry", name)
+		}
+	}
+}
+
+func TestEntry_ConflictsResolveTheSameEverywhere(t *testing.T) {
+	now := time.Now()
+	older := Entry{Key: "sticky/web/bob", Value: []byte(`"web-1"`), Version: 7, Node: "lb-1"}
+	newer := Entry{Key: "sticky/web/bob", Value: []byte(`"web-2"`), Version: 8, Node: "lb-0"}
+	tie := Entry{Key: "sticky/web/bob", Value: []byte(`"web-3"`), Version: 8, Node: "lb-2"}
+
+	orders := [][]Entry{{older, newer, tie}, {tie, newer, older}, {newer, tie, older}}
+	for _, order := range orders {
+		s := make(store)
+		for _, e := range order {
+			s.merge(e, now)
+		}
+		if got := string(s["sticky/web/bob"].Value); got != `"web-3"` {
+			t.Errorf("order %v settled on %s, want web-3", order, got)
+		}
+	}
+
+	expired := Entry{Key: "ratelimit/lb-0/x", Version: 99, Node: "lb-0", Expires: now.Add(-time.Second).UnixNano()}
+	if make(store).merge(expired, now) {
+		t.Errorf("merged an expired entry")
+	}
+}
@@ -0,0 +1,66 @@
+// internal/cluster/state.go
+package cluster
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Entry is one replicated key. Concurrent writes are resolved last-writer-
+// wins on the Lamport version, with the writing node's ID breaking ties, so
+// every replica settles on the same value whatever order updates arrive in.
+type Entry struct {
+	Key     string          `json:"k"`
+	Value   json.RawMessage `json:"v,omitempty"` // empty once deleted
+	Version uint64          `json:"n"`
+	Node    string          `json:"o"`
+	Expires int64           `json:"x,omitempty"` // unix nanoseconds; 0 never expires
+}
+
+// Newer reports whether e supersedes other.
+func (e Entry) Newer(other Entry) bool {
+	if e.Version != other.Version {
+		return e.Version > other.Version
+	}
+	return e.Node > other.Node
+}
+
+// Expired reports whether the entry has outlived its TTL.
+func (e Entry) Expired(now time.Time) bool {
+	return e.Expires != 0 && now.UnixNano() > e.Expires
+}
+
+// Deleted reports whether the entry is a tombstone left by Delete.
+func (e Entry) Deleted() bool {
+	return len(e.Value) == 0
+}
+
+// Decode unmarshals the entry's value into v.
+func (e Entry) Decode(v interface{}) error {
+	return json.Unmarshal(e.Value, v)
+}
+
+// store holds the replicated entries; callers hold Node.mu.
+type store map[string]Entry
+
+// merge keeps e if it is newer than what the store has, and reports whether
+// it did.
+func (s store) merge(e Entry, now time.Time) bool {
+	if e.Expired(now) {
+		return false
+	}
+	if current, ok := s[e.Key]; ok && !e.Newer(current) {
+		return false
+	}
+	s[e.Key] = e
+	return true
+}
+
+// expire drops entries past their TTL.
+func (s store) expire(now time.Time) {
+	for key, e := range s {
+		if e.Expired(now) {
+			delete(s, key)
+		}
+	}
+}
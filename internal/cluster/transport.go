@@ -0,0 +1,175 @@
+// internal/cluster/transport.go
+package cluster
+
+import (
+	"fmt"
+	"net"
+	"sync"
+)
+
+// Packet is one gossip message and the address it came from.
+type Packet struct {
+	From string
+	Data []byte
+}
+
+// Transport moves gossip messages between nodes. Delivery is best effort:
+// messages may be lost, duplicated or reordered.
+type Transport interface {
+	// Addr is the address peers use to reach this node.
+	Addr() string
+	// Send delivers data to the node at addr.
+	Send(addr string, data []byte) error
+	// Packets yields incoming messages until the transport is closed.
+	Packets() <-chan Packet
+	Close() error
+}
+
+// maxPacketSize bounds a single gossip datagram.
+const maxPacketSize = 64 * 1024
+
+// UDPTransport gossips over UDP datagrams.
+type UDPTransport struct {
+	conn    *net.UDPConn
+	packets chan Packet
+}
+
+// NewUDPTransport listens for gossip on bind (host:port).
+func NewUDPTransport(bind string) (*UDPTransport, error) {
+	addr, err := net.ResolveUDPAddr("udp", bind)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: invalid bind address %q: %w", bind, err)
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: %w", err)
+	}
+
+	t := &UDPTransport{conn: conn, packets: make(chan Packet, 256)}
+	go t.read()
+	return t, nil
+}
+
+func (t *UDPTransport) read() {
+	defer close(t.packets)
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, from, err := t.conn.ReadFromUDP(buf)
+		if err != nil {
+			return // socket closed
+		}
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		select {
+		case t.packets <- Packet{From: from.String(), Data: data}:
+		default:
+			// Receiver is behind; gossip repairs the loss on a later round.
+		}
+	}
+}
+
+// Addr returns the bound UDP address.
+func (t *UDPTransport) Addr() string {
+	return t.conn.LocalAddr().String()
+}
+
+// Send writes one datagram to addr.
+func (t *UDPTransport) Send(addr string, data []byte) error {
+	if len(data) > maxPacketSize {
+		return fmt.Errorf("cluster: message of %d bytes exceeds %d", len(data), maxPacketSize)
+	}
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return err
+	}
+	_, err = t.conn.WriteToUDP(data, udpAddr)
+	return err
+}
+
+// Packets returns the incoming message channel.
+func (t *UDPTransport) Packets() <-chan Packet {
+	return t.packets
+}
+
+// Close stops listening.
+func (t *UDPTransport) Close() error {
+	return t.conn.Close()
+}
+
+// MemoryNetwork connects in-process transports, for running several nodes
+// in one test.
+type MemoryNetwork struct {
+	mu    sync.RWMutex
+	nodes map[string]*MemoryTransport
+	down  map[string]bool // addresses cut off from the network
+}
+
+// NewMemoryNetwork creates an empty in-process network.
+func NewMemoryNetwork() *MemoryNetwork {
+	return &MemoryNetwork{
+		nodes: make(map[string]*MemoryTransport),
+		down:  make(map[string]bool),
+	}
+}
+
+// Transport attaches a new transport at addr.
+func (mn *MemoryNetwork) Transport(addr string) *MemoryTransport {
+	mn.mu.Lock()
+	defer mn.mu.Unlock()
+
+	t := &MemoryTransport{network: mn, addr: addr, packets: make(chan Packet, 1024)}
+	mn.nodes[addr] = t
+	return t
+}
+
+// SetDown cuts addr off from (or reconnects it to) the network.
+func (mn *MemoryNetwork) SetDown(addr string, down bool) {
+	mn.mu.Lock()
+	defer mn.mu.Unlock()
+	mn.down[addr] = down
+}
+
+// MemoryTransport is one node's attachment to a MemoryNetwork.
+type MemoryTransport struct {
+	network *MemoryNetwork
+	addr    string
+	packets chan Packet
+	once    sync.Once
+}
+
+// Addr returns the transport's in-process address.
+func (t *MemoryTransport) Addr() string {
+	return t.addr
+}
+
+// Send delivers data to addr unless either end is down or gone.
+func (t *MemoryTransport) Send(addr string, data []byte) error {
+	t.network.mu.RLock()
+	defer t.network.mu.RUnlock()
+
+	peer, ok := t.network.nodes[addr]
+	if !ok || t.network.down[addr] || t.network.down[t.addr] {
+		return nil // lost, as it would be on a real network
+	}
+	select {
+	case peer.packets <- Packet{From: t.addr, Data: append([]byte(nil), data...)}:
+	default:
+	}
+	return nil
+}
+
+// Packets returns the incoming message channel.
+func (t *MemoryTransport) Packets() <-chan Packet {
+	return t.packets
+}
+
+// Close detaches the transport from the network.
+func (t *MemoryTransport) Close() error {
+	t.once.Do(func() {
+		t.network.mu.Lock()
+		delete(t.network.nodes, t.addr)
+		t.network.mu.Unlock()
+		close(t.packets)
+	})
+	return nil
+}
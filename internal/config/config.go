@@ -4,7 +4,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,15 +17,33 @@ type Config struct {
 	HealthCheckInterval time.Duration
 	UseIPHash           bool
 	UseStickySessions   bool
+	StickySessionTTL    time.Duration // idle sticky bindings are dropped after this; 0 keeps them
 	CircuitBreaker      CircuitBreakerConfig
-	StartTestServers    bool // Whether to start test servers
+	Upstream            UpstreamConfig
+	TrustedProxies      []string // CIDRs whose forwarding headers are honoured
+	RateLimit           RateLimitConfig
+	SlowStart           SlowStartConfig
+	DrainTimeout        time.Duration // How long a drain waits for in-flight requests
+	Shutdown            ShutdownConfig
+	Handoff             HandoffConfig
+	Snapshot            SnapshotConfig
+	History             HistoryConfig
+	HA                  HAConfig
+	Cluster             ClusterConfig
+	MirrorMaxInFlight   int    // Concurrent shadow requests before mirrors are dropped
+	SyntheticProbePath  string // /lb/ path that /api/test probes are sent to
+	StartTestServers    bool   // Whether to start test servers
+
+	// Routing table: named backend pools and the routes that feed them
+	Pools  []PoolConfig
+	Routes []RouteConfig
 }
 
 // ServerConfig represents each backend server's config
 type ServerConfig struct {
-	ID      string
-	Address string
-	Port    int
+	ID      string `json:"id"`
+	Address string `json:"address"`
+	Port    int    `json:"port"`
 }
 
 // CircuitBreakerConfig for controlling circuit breaker thresholds
@@ -33,6 +53,81 @@ type CircuitBreakerConfig struct {
 	TrialRequests    int
 }
 
+// UpstreamConfig controls the per-backend connection pools used for proxying
+type UpstreamConfig struct {
+	MaxIdleConns          int
+	MaxConns              int
+	IdleConnTimeout       time.Duration
+	KeepAlive             time.Duration
+	DisableKeepAlives     bool
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	RequestTimeout        time.Duration
+}
+
+// SlowStartConfig ramps a recovering or newly added server's share of traffic
+type SlowStartConfig struct {
+	Duration  time.Duration // 0 disables slow start
+	Curve     string        // linear or exponential
+	MinFactor float64       // share of full weight at the start of the window
+}
+
+// ShutdownConfig controls the balancer's own graceful shutdown
+type ShutdownConfig struct {
+	PreStopDelay  time.Duration // /readyz fails this long before the listener closes
+	DrainDeadline time.Duration // time given to in-flight proxied requests
+}
+
+// HandoffConfig controls zero-downtime upgrades (SIGUSR2)
+type HandoffConfig struct {
+	SocketPath   string        // unix socket used to pass listeners to the new process
+	ReadyTimeout time.Duration // how long the new process has to report ready
+}
+
+// SnapshotConfig controls persisting runtime state across restarts
+type SnapshotConfig struct {
+	Path     string        // file the snapshot is written to; empty disables snapshots
+	Interval time.Duration // how often to write it while running; 0 writes only on shutdown
+}
+
+// HistoryConfig controls the on-disk event and packet history
+type HistoryConfig struct {
+	Dir          string        // directory of segment files; empty disables the history
+	SegmentBytes int64         // size at which a new segment is started
+	MaxBytes     int64         // oldest segments are deleted past this total; 0 keeps everything
+	MaxAge       time.Duration // segments older than this are deleted; 0 keeps everything
+}
+
+// HAConfig controls active/passive leader election between balancer instances
+type HAConfig struct {
+	NodeID            string        // unique name of this instance
+	Bind              string        // host:port for UDP heartbeats and TCP state replication; empty disables HA
+	Peers             []string      // host:port of the other instances
+	Priority          int           // higher wins a simultaneous election
+	HeartbeatInterval time.Duration // how often every node announces itself
+	FailoverTimeout   time.Duration // standby takes over after this long without a leader heartbeat
+	ReplicateInterval time.Duration // how often the leader pushes sessions and breaker state
+	Secret            string        // shared key signing heartbeats and replicated state; required with Bind
+}
+
+// ClusterConfig controls gossip-based state sharing between balancer replicas
+type ClusterConfig struct {
+	NodeID         string        // unique name of this replica
+	Bind           string        // host:port for gossip over UDP; empty disables clustering
+	Seeds          []string      // host:port of replicas to join through
+	GossipInterval time.Duration // how often state is pushed to random peers
+	Fanout         int           // peers contacted per gossip round
+	DeadAfter      time.Duration // a peer silent this long is considered gone
+	Secret         string        // shared key signing gossip messages; required with Bind
+}
+
+// RateLimitConfig controls per-client rate limiting on the proxy path
+type RateLimitConfig struct {
+	RequestsPerSecond int // 0 disables rate limiting
+	Burst             int
+}
+
 // LoadConfig loads config from environment variables or from defaults
 func LoadConfig() (*Config, error) {
 	// Read LB_PORT from env
@@ -91,17 +186,97 @@ func LoadConfig() (*Config, error) {
 		healthCheckInterval = 5 // default 5 seconds
 	}
 
+	upstream := UpstreamConfig{
+		MaxIdleConns:          envInt("UPSTREAM_MAX_IDLE_CONNS", 32),
+		MaxConns:              envInt("UPSTREAM_MAX_CONNS", 256),
+		IdleConnTimeout:       envDuration("UPSTREAM_IDLE_CONN_TIMEOUT", 90*time.Second),
+		KeepAlive:             envDuration("UPSTREAM_KEEP_ALIVE", 30*time.Second),
+		DisableKeepAlives:     envBool("UPSTREAM_DISABLE_KEEP_ALIVES", false),
+		DialTimeout:           envDuration("UPSTREAM_DIAL_TIMEOUT", 2*time.Second),
+		TLSHandshakeTimeout:   envDuration("UPSTREAM_TLS_HANDSHAKE_TIMEOUT", 3*time.Second),
+		ResponseHeaderTimeout: envDuration("UPSTREAM_RESPONSE_HEADER_TIMEOUT", 5*time.Second),
+		RequestTimeout:        envDuration("UPSTREAM_REQUEST_TIMEOUT", 5*time.Second),
+	}
+
+	// Read TRUSTED_PROXIES from env (comma-separated CIDRs or IPs)
+	trustedProxies := []string{"127.0.0.1/32", "::1/128"}
+	if raw, ok := os.LookupEnv("TRUSTED_PROXIES"); ok {
+		trustedProxies = nil
+		for _, cidr := range strings.Split(raw, ",") {
+			if cidr = strings.TrimSpace(cidr); cidr != "" {
+				trustedProxies = append(trustedProxies, cidr)
+			}
+		}
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+
 	cfg := &Config{
 		LBPort:              lbPort,
 		HealthCheckInterval: time.Duration(healthCheckInterval) * time.Second,
 		UseIPHash:           useIPHash,
 		UseStickySessions:   useStickySessions,
+		StickySessionTTL:    envDuration("STICKY_SESSION_TTL", 30*time.Minute),
 		StartTestServers:    startTestServers,
 		CircuitBreaker: CircuitBreakerConfig{
 			FailureThreshold: failureThreshold,
 			CooldownPeriod:   time.Duration(cooldownPeriod) * time.Second,
 			TrialRequests:    trialRequests,
 		},
+		Upstream:       upstream,
+		TrustedProxies: trustedProxies,
+		RateLimit: RateLimitConfig{
+			RequestsPerSecond: envInt("RATE_LIMIT_RPS", 0),
+			Burst:             envInt("RATE_LIMIT_BURST", 0),
+		},
+		SlowStart: SlowStartConfig{
+			Duration:  envDuration("SLOW_START_DURATION", 30*time.Second),
+			Curve:     envString("SLOW_START_CURVE", "linear"),
+			MinFactor: float64(envInt("SLOW_START_MIN_PERCENT", 10)) / 100,
+		},
+		DrainTimeout: envDuration("DRAIN_TIMEOUT", 30*time.Second),
+		Handoff: HandoffConfig{
+			SocketPath:   envString("HANDOFF_SOCKET", filepath.Join(os.TempDir(), fmt.Sprintf("loadbalancer-%d.sock", lbPort))),
+			ReadyTimeout: envDuration("HANDOFF_READY_TIMEOUT", 30*time.Second),
+		},
+		Snapshot: SnapshotConfig{
+			Path:     envString("SNAPSHOT_FILE", filepath.Join(os.TempDir(), fmt.Sprintf("loadbalancer-%d.snapshot.json", lbPort))),
+			Interval: envDuration("SNAPSHOT_INTERVAL", 30*time.Second),
+		},
+		History: HistoryConfig{
+			Dir:          envString("HISTORY_DIR", filepath.Join(os.TempDir(), fmt.Sprintf("loadbalancer-%d-history", lbPort))),
+			SegmentBytes: int64(envInt("HISTORY_SEGMENT_MB", 16)) << 20,
+			MaxBytes:     int64(envInt("HISTORY_MAX_MB", 256)) << 20,
+			MaxAge:       envDuration("HISTORY_MAX_AGE", 7*24*time.Hour),
+		},
+		HA: HAConfig{
+			NodeID:            envString("HA_NODE_ID", fmt.Sprintf("%s:%d", hostname, lbPort)),
+			Bind:              envString("HA_BIND", ""),
+			Peers:             envList("HA_PEERS"),
+			Priority:          envInt("HA_PRIORITY", 100),
+			HeartbeatInterval: envDuration("HA_HEARTBEAT_INTERVAL", 500*time.Millisecond),
+			FailoverTimeout:   envDuration("HA_FAILOVER_TIMEOUT", 2*time.Second),
+			ReplicateInterval: envDuration("HA_REPLICATE_INTERVAL", time.Second),
+			Secret:            envString("HA_SECRET", ""),
+		},
+		Cluster: ClusterConfig{
+			NodeID:         envString("CLUSTER_NODE_ID", fmt.Sprintf("%s:%d", hostname, lbPort)),
+			Bind:           envString("CLUSTER_BIND", ""),
+			Seeds:          envList("CLUSTER_SEEDS"),
+			GossipInterval: envDuration("CLUSTER_GOSSIP_INTERVAL", 200*time.Millisecond),
+			Fanout:         envInt("CLUSTER_FANOUT", 3),
+			DeadAfter:      envDuration("CLUSTER_DEAD_AFTER", 5*time.Second),
+			Secret:         envString("CLUSTER_SECRET", ""),
+		},
+		Shutdown: ShutdownConfig{
+			PreStopDelay:  envDuration("SHUTDOWN_PRESTOP_DELAY", 5*time.Second),
+			DrainDeadline: envDuration("SHUTDOWN_DRAIN_TIMEOUT", 30*time.Second),
+		},
+		MirrorMaxInFlight:  envInt("MIRROR_MAX_IN_FLIGHT", 64),
+		SyntheticProbePath: envString("SYNTHETIC_PROBE_PATH", "/lb/probe"),
 		Servers: []ServerConfig{
 			{
 				ID:      "server-1",
@@ -122,15 +297,143 @@ func LoadConfig() (*Config, error) {
 		},
 	}
 
+	if err := loadRouting(cfg); err != nil {
+		return nil, err
+	}
+	if cfg.HA.Bind != "" && cfg.HA.Secret == "" {
+		return nil, fmt.Errorf("HA_SECRET is required when HA_BIND is set")
+	}
+	if cfg.Cluster.Bind != "" && cfg.Cluster.Secret == "" {
+		return nil, fmt.Errorf("CLUSTER_SECRET is required when CLUSTER_BIND is set")
+	}
+	if !strings.HasPrefix(cfg.SyntheticProbePath, "/lb/") {
+		return nil, fmt.Errorf("SYNTHETIC_PROBE_PATH must start with /lb/, got %q", cfg.SyntheticProbePath)
+	}
+
 	fmt.Printf("[CONFIG] Load Balancer Port: %d\n", cfg.LBPort)
 	fmt.Printf("[CONFIG] IP Hash: %v\n", cfg.UseIPHash)
-	fmt.Printf("[CONFIG] Sticky Sessions: %v\n", cfg.UseStickySessions)
+	fmt.Printf("[CONFIG] Sticky Sessions: %v (idle TTL %v)\n", cfg.UseStickySessions, cfg.StickySessionTTL)
 	fmt.Printf("[CONFIG] Start Test Servers: %v\n", cfg.StartTestServers)
 	fmt.Printf("[CONFIG] Health Check Interval: %v\n", cfg.HealthCheckInterval)
 	fmt.Printf("[CONFIG] Circuit Breaker: Failure Threshold=%d, Cooldown=%v, Trial Requests=%d\n",
 		cfg.CircuitBreaker.FailureThreshold,
 		cfg.CircuitBreaker.CooldownPeriod,
 		cfg.CircuitBreaker.TrialRequests)
+	fmt.Printf("[CONFIG] Upstream Pool: MaxIdle=%d, MaxConns=%d, IdleTimeout=%v, KeepAlive=%v\n",
+		cfg.Upstream.MaxIdleConns,
+		cfg.Upstream.MaxConns,
+		cfg.Upstream.IdleConnTimeout,
+		cfg.Upstream.KeepAlive)
+	fmt.Printf("[CONFIG] Upstream Timeouts: Dial=%v, TLS=%v, ResponseHeader=%v, Request=%v\n",
+		cfg.Upstream.DialTimeout,
+		cfg.Upstream.TLSHandshakeTimeout,
+		cfg.Upstream.ResponseHeaderTimeout,
+		cfg.Upstream.RequestTimeout)
+	fmt.Printf("[CONFIG] Trusted Proxies: %v\n", cfg.TrustedProxies)
+	fmt.Printf("[CONFIG] Synthetic Probe Path: %s\n", cfg.SyntheticProbePath)
+	fmt.Printf("[CONFIG] Rate Limit: %d rps per client (burst %d)\n",
+		cfg.RateLimit.RequestsPerSecond,
+		cfg.RateLimit.Burst)
+	fmt.Printf("[CONFIG] Slow Start: %v %s ramp from %.0f%%\n",
+		cfg.SlowStart.Duration,
+		cfg.SlowStart.Curve,
+		cfg.SlowStart.MinFactor*100)
+	fmt.Printf("[CONFIG] Drain Timeout: %v\n", cfg.DrainTimeout)
+	fmt.Printf("[CONFIG] Handoff: socket=%s, ready timeout=%v\n",
+		cfg.Handoff.SocketPath,
+		cfg.Handoff.ReadyTimeout)
+	fmt.Printf("[CONFIG] Snapshot: file=%s, interval=%v\n",
+		cfg.Snapshot.Path,
+		cfg.Snapshot.Interval)
+	fmt.Printf("[CONFIG] History: dir=%s, segments of %d MB, keep %d MB for %v\n",
+		cfg.History.Dir,
+		cfg.History.SegmentBytes>>20,
+		cfg.History.MaxBytes>>20,
+		cfg.History.MaxAge)
+	if cfg.HA.Bind != "" {
+		fmt.Printf("[CONFIG] HA: node=%s, bind=%s, peers=%v, priority=%d, failover=%v\n",
+			cfg.HA.NodeID,
+			cfg.HA.Bind,
+			cfg.HA.Peers,
+			cfg.HA.Priority,
+			cfg.HA.FailoverTimeout)
+	}
+	if cfg.Cluster.Bind != "" {
+		fmt.Printf("[CONFIG] Cluster: node=%s, bind=%s, seeds=%v, gossip every %v to %d peers\n",
+			cfg.Cluster.NodeID,
+			cfg.Cluster.Bind,
+			cfg.Cluster.Seeds,
+			cfg.Cluster.GossipInterval,
+			cfg.Cluster.Fanout)
+	}
+	fmt.Printf("[CONFIG] Shutdown: pre-stop delay=%v, drain deadline=%v\n",
+		cfg.Shutdown.PreStopDelay,
+		cfg.Shutdown.DrainDeadline)
+	for _, pool := range cfg.Pools {
+		fmt.Printf("[CONFIG] Pool %s: %d servers, strategy=%s\n", pool.Name, len(pool.Servers), pool.Strategy)
+	}
+	for _, route := range cfg.Routes {
+		fmt.Printf("[CONFIG] Route %s -> pool %s\n", route.Name, route.Pool)
+		if route.Mirror != nil {
+			fmt.Printf("[CONFIG] Route %s mirrors %.1f%% to pool %s\n", route.Name, route.Mirror.Percentage, route.Mirror.Pool)
+		}
+	}
 
 	return cfg, nil
 }
+
+// envInt reads a non-negative integer from the environment, falling back to def.
+func envInt(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || v < 0 {
+		return def
+	}
+	return v
+}
+
+// envString reads a string from the environment, falling back to def.
+func envString(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// envList reads a comma-separated list from the environment.
+func envList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// envBool reads a boolean flag ("true"/"1" or "false"/"0") from the environment.
+func envBool(key string, def bool) bool {
+	switch os.Getenv(key) {
+	case "true", "1":
+		return true
+	case "false", "0":
+		return false
+	default:
+		return def
+	}
+}
+
+// envDuration reads a duration from the environment. Both Go duration strings
+// ("750ms", "2s") and bare integers (seconds) are accepted.
+func envDuration(key string, def time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	if secs, err := strconv.Atoi(raw); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if d, err := time.ParseDuration(raw); err == nil && d >= 0 {
+		return d
+	}
+	return def
+}
@@ -0,0 +1,226 @@
+// internal/config/routing.go
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration that reads and writes JSON as a Go duration
+// string ("10s"). Bare numbers are read as seconds.
+type Duration struct {
+	time.Duration
+}
+
+// MarshalJSON encodes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts "1m30s" style strings or a number of seconds.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	switch v := raw.(type) {
+	case float64:
+		d.Duration = time.Duration(v * float64(time.Second))
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", v, err)
+		}
+		d.Duration = parsed
+	default:
+		return fmt.Errorf("invalid duration %s", string(data))
+	}
+	return nil
+}
+
+// PoolConfig describes a named pool of backend servers with its own
+// balancing strategy, health check and circuit breaker settings. Zero values
+// inherit the global settings.
+type PoolConfig struct {
+	Name                string                    `json:"name"`
+	Strategy            string                    `json:"strategy,omitempty"` // weighted-round-robin, least-connections, ip-hash
+	UseStickySessions   *bool                     `json:"useStickySessions,omitempty"`
+	StickySessionTTL    Duration                  `json:"stickySessionTtl,omitempty"`
+	HealthCheckInterval Duration                  `json:"healthCheckInterval,omitempty"`
+	HealthCheckPath     string                    `json:"healthCheckPath,omitempty"` // empty keeps simulated metrics
+	CircuitBreaker      *PoolCircuitBreakerConfig `json:"circuitBreaker,omitempty"`
+	SlowStart           *PoolSlowStartConfig      `json:"slowStart,omitempty"`
+	Servers             []ServerConfig            `json:"servers"`
+}
+
+// PoolCircuitBreakerConfig overrides the global breaker thresholds for a pool.
+type PoolCircuitBreakerConfig struct {
+	FailureThreshold int      `json:"failureThreshold,omitempty"`
+	CooldownPeriod   Duration `json:"cooldownPeriod,omitempty"`
+	TrialRequests    int      `json:"trialRequests,omitempty"`
+}
+
+// PoolSlowStartConfig overrides the global slow-start ramp for a pool.
+type PoolSlowStartConfig struct {
+	Duration  Duration `json:"duration,omitempty"`
+	Curve     string   `json:"curve,omitempty"`     // linear or exponential
+	MinFactor float64  `json:"minFactor,omitempty"` // 0-1
+}
+
+// RouteConfig matches incoming requests and sends them to a named pool.
+// Every populated matcher must match. Routes are evaluated by descending
+// priority, then longest path prefix, then number of other matchers; ties keep
+// the order in which they were added.
+type RouteConfig struct {
+	Name        string            `json:"name"`
+	Priority    int               `json:"priority,omitempty"`
+	Host        string            `json:"host,omitempty"`       // exact host or "*.example.com"
+	PathPrefix  string            `json:"pathPrefix,omitempty"` // e.g. "/lb/api/"
+	PathRegex   string            `json:"pathRegex,omitempty"`
+	Methods     []string          `json:"methods,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"` // empty value means "present"
+	Pool        string            `json:"pool"`
+	StripPrefix string            `json:"stripPrefix,omitempty"`
+	Rewrite     *RewriteConfig    `json:"rewrite,omitempty"`
+	Split       *SplitConfig      `json:"split,omitempty"`
+	Mirror      *MirrorConfig     `json:"mirror,omitempty"`
+}
+
+// SplitConfig divides a route's traffic between variants by percentage, for
+// canary and blue/green releases. Clients are assigned to a variant by hashing
+// their session cookie (or client IP), so assignment is sticky while the
+// percentages are unchanged.
+type SplitConfig struct {
+	Variants       []VariantConfig `json:"variants"`
+	OverrideHeader string          `json:"overrideHeader,omitempty"` // default X-LB-Variant
+	OverrideCookie string          `json:"overrideCookie,omitempty"` // default lb_variant
+}
+
+// VariantConfig is one arm of a traffic split. Pool defaults to the route's
+// pool; Servers optionally restricts the variant to a subset of that pool.
+type VariantConfig struct {
+	Name    string   `json:"name"`
+	Pool    string   `json:"pool,omitempty"`
+	Servers []string `json:"servers,omitempty"`
+	Weight  float64  `json:"weight"` // percentage of traffic, all variants sum to 100
+}
+
+// MirrorConfig shadows a percentage of a route's requests to a secondary
+// pool. Shadow responses are discarded; they are only measured and, if Diff is
+// set, compared with the response the client received.
+type MirrorConfig struct {
+	Pool       string   `json:"pool"`
+	Percentage float64  `json:"percentage"`        // 0-100 of matching requests
+	Diff       bool     `json:"diff,omitempty"`    // compare status and latency with the primary
+	Timeout    Duration `json:"timeout,omitempty"` // default 5s
+}
+
+// RewriteConfig rewrites the request path with a regular expression after
+// any prefix has been stripped.
+type RewriteConfig struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// RoutingConfig is the on-disk format of LB_ROUTES_FILE.
+type RoutingConfig struct {
+	Pools  []PoolConfig  `json:"pools"`
+	Routes []RouteConfig `json:"routes"`
+}
+
+// DefaultPoolName is the pool built from the top-level server list.
+const DefaultPoolName = "default"
+
+// loadRouting reads the routing file if one is configured, otherwise it
+// builds a single default pool behind the classic /lb/ prefix.
+func loadRouting(cfg *Config) error {
+	path := os.Getenv("LB_ROUTES_FILE")
+	if path == "" {
+		cfg.UseDefaultRouting()
+	} else {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading routes file: %w", err)
+		}
+		var routing RoutingConfig
+		if err := json.Unmarshal(data, &routing); err != nil {
+			return fmt.Errorf("parsing routes file: %w", err)
+		}
+		cfg.Pools = routing.Pools
+		cfg.Routes = routing.Routes
+	}
+
+	for i := range cfg.Pools {
+		cfg.Pools[i] = cfg.ResolvePool(cfg.Pools[i])
+	}
+	return nil
+}
+
+// UseDefaultRouting sends every request under /lb/ to a single default pool
+// of cfg.Servers.
+func (cfg *Config) UseDefaultRouting() {
+	cfg.Pools = []PoolConfig{{Name: DefaultPoolName, Servers: cfg.Servers}}
+	cfg.Routes = []RouteConfig{{
+		Name:        DefaultPoolName,
+		PathPrefix:  "/lb/",
+		StripPrefix: "/lb",
+		Pool:        DefaultPoolName,
+	}}
+}
+
+// ResolvePool fills unset pool settings from the global configuration.
+func (cfg *Config) ResolvePool(pool PoolConfig) PoolConfig {
+	if pool.Strategy == "" {
+		pool.Strategy = "weighted-round-robin"
+		if cfg.UseIPHash {
+			pool.Strategy = "ip-hash"
+		}
+	}
+	pool.Strategy = strings.ToLower(pool.Strategy)
+
+	if pool.UseStickySessions == nil {
+		sticky := cfg.UseStickySessions
+		pool.UseStickySessions = &sticky
+	}
+	if pool.StickySessionTTL.Duration <= 0 {
+		pool.StickySessionTTL.Duration = cfg.StickySessionTTL
+	}
+	if pool.HealthCheckInterval.Duration <= 0 {
+		pool.HealthCheckInterval.Duration = cfg.HealthCheckInterval
+	}
+
+	cb := PoolCircuitBreakerConfig{}
+	if pool.CircuitBreaker != nil {
+		cb = *pool.CircuitBreaker
+	}
+	if cb.FailureThreshold <= 0 {
+		cb.FailureThreshold = cfg.CircuitBreaker.FailureThreshold
+	}
+	if cb.CooldownPeriod.Duration <= 0 {
+		cb.CooldownPeriod.Duration = cfg.CircuitBreaker.CooldownPeriod
+	}
+	if cb.TrialRequests <= 0 {
+		cb.TrialRequests = cfg.CircuitBreaker.TrialRequests
+	}
+	pool.CircuitBreaker = &cb
+
+	ss := PoolSlowStartConfig{}
+	if pool.SlowStart != nil {
+		ss = *pool.SlowStart
+	}
+	if ss.Duration.Duration <= 0 {
+		ss.Duration.Duration = cfg.SlowStart.Duration
+	}
+	if ss.Curve == "" {
+		ss.Curve = cfg.SlowStart.Curve
+	}
+	ss.Curve = strings.ToLower(ss.Curve)
+	if ss.MinFactor <= 0 {
+		ss.MinFactor = cfg.SlowStart.MinFactor
+	}
+	pool.SlowStart = &ss
+	return pool
+}
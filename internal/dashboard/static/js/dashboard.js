@@ -185,6 +185,8 @@ document.addEventListener('DOMContentLoaded', function () {
                 cbIcon = '<i class="fas fa-exclamation-circle" style="color: #fcee0b;"></i>';
             }
 
+            const adminState = server.AdminState || 'active';
+
             const serverCard = document.createElement('div');
             serverCard.className = `server-card ${serverHealth}`;
 
@@ -195,12 +197,16 @@ document.addEventListener('DOMContentLoaded', function () {
                         <button class="action-button toggle-server" data-id="${server.ID}">
                             ${server.PingStatus ? 'Disable' : 'Enable'}
                         </button>
+                        <button class="action-button drain-server" data-id="${server.ID}" data-state="${adminState}">
+                            ${adminState === 'active' ? 'Drain' : 'Activate'}
+                        </button>
                         <button class="action-button reset-server" data-id="${server.ID}">Reset</button>
                     </div>
                 </div>
                 <div class="server-details">
                     <div>${server.Address}:${server.Port}</div>
                     <div>Circuit Breaker: ${cbIcon} ${cbState}</div>
+                    <div>State: ${adminState}</div>
                 </div>
                 <div class="server-metrics">
                     <div class="metric-row">
@@ -231,6 +237,11 @@ document.addEventListener('DOMContentLoaded', function () {
                         <span class="metric-label">Weight:</span>
                         <span>${(server.CurrentWeight * 100).toFixed(1)}%</span>
                     </div>
+                    ${server.rampFactor !== undefined && server.rampFactor < 1 ? `
+                    <div class="metric-row">
+                        <span class="metric-label">Warming Up:</span>
+                        <span>${(server.rampFactor * 100).toFixed(0)}%</span>
+                    </div>` : ''}
                 </div>
             `;
 
@@ -243,7 +254,7 @@ document.addEventListener('DOMContentLoaded', function () {
                 flowNode.innerHTML = `
                     <div class="node-core">${index + 1}</div>
                     <span class="node-label">${server.ID.slice(0, 8)}</span>
-                    <span class="node-meta">${server.PingStatus ? 'Online' : 'Offline'} | ${cbState}</span>
+                    <span class="node-meta">${server.PingStatus ? 'Online' : 'Offline'} | ${cbState}${adminState !== 'active' ? ' | ' + adminState : ''}</span>
                 `;
                 flowServersContainer.appendChild(flowNode);
             }
@@ -262,6 +273,12 @@ document.addEventListener('DOMContentLoaded', function () {
             });
         });
 
+        document.querySelectorAll('.drain-server').forEach(button => {
+            button.addEventListener('click', function () {
+                drainServer(this.dataset.id, this.dataset.state);
+            });
+        });
+
         document.querySelectorAll('.reset-server').forEach(button => {
             button.addEventListener('click', function () {
                 resetServer(this.dataset.id);
@@ -316,6 +333,30 @@ document.addEventListener('DOMContentLoaded', function () {
             });
     }
 
+    function drainServer(serverId, adminState) {
+        const action = adminState === 'active' ? 'drain' : 'activate';
+        setStatusMessage(`${action === 'drain' ? 'Draining' : 'Activating'} ${serverId}...`);
+        fetch(`/api/servers/${serverId}/${action}`, {
+            method: 'POST'
+        })
+            .then(response => {
+                if (!response.ok) {
+                    throw new Error(`Server responded with status: ${response.status}`);
+                }
+                return response.json();
+            })
+            .then(data => {
+                logEvent(`Server ${serverId} is ${data.adminState}`, data.adminState === 'active' ? 'success' : 'warning');
+                setStatusMessage(`Server ${serverId} is ${data.adminState}`);
+                fetchServers();
+            })
+            .catch(error => {
+                console.error(`Error during ${action}:`, error);
+                logEvent(`Error during ${action}: ` + error.message, 'error');
+                setStatusMessage(`Failed to ${action} server`);
+            });
+    }
+
     function resetServer(serverId) {
         setStatusMessage(`Resetting ${serverId}...`);
         fetch(`/api/servers/${serverId}/reset`, {
@@ -378,70 +419,71 @@ document.addEventListener('DOMContentLoaded', function () {
         setScenarioBusyState(scenarioBusy, null);
     }
 
-    async function runFailureScenario() {
-        const target = servers.find(server => server.PingStatus);
-        if (!target) {
-            setStatusMessage('No active servers available for failure drill');
-            logEvent('Failure scenario skipped: no active servers', 'warning');
-            return;
-        }
-        await fetch(`/api/servers/${target.ID}/toggle`, { method: 'POST' });
-        logEvent(`Failure drill toggled ${target.ID}`, 'warning');
-        setStatusMessage(`Server ${target.ID} disabled for failure drill`);
-        fetchServers();
+    // Scenarios run server-side; the dashboard starts one and follows its
+    // progress stream until the final report arrives.
+    function runServerScenario(name, label) {
+        return fetch('/api/scenarios', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ name })
+        })
+            .then(response => {
+                if (!response.ok) {
+                    return response.text().then(text => {
+                        throw new Error(text.trim() || `HTTP ${response.status}`);
+                    });
+                }
+                return response.json();
+            })
+            .then(run => new Promise((resolve, reject) => {
+                setStatusMessage(`${label} running...`);
+                const stream = new EventSource(`/api/scenarios/runs/${run.id}/events`);
+                stream.addEventListener('step', event => {
+                    const progress = JSON.parse(event.data);
+                    logEvent(`${label}: step ${progress.step} ${progress.stepName || ''}`.trim(), 'info');
+                    fetchServers();
+                });
+                stream.addEventListener('progress', event => {
+                    const progress = JSON.parse(event.data);
+                    setStatusMessage(`${label}: ${progress.requests} requests, ${progress.errors} errors`);
+                });
+                stream.addEventListener('done', event => {
+                    stream.close();
+                    const status = JSON.parse(event.data);
+                    fetchServers();
+                    if (status.state === 'failed') {
+                        reject(new Error(status.error || 'scenario failed'));
+                        return;
+                    }
+                    const report = status.report || {};
+                    const latency = report.latency || {};
+                    const summary = `${label} ${status.state}: ${report.requests || 0} requests, ` +
+                        `${report.errors || 0} errors, p95 ${Math.round(latency.p95 || 0)}ms`;
+                    logEvent(summary, status.state === 'completed' ? 'success' : 'warning');
+                    setStatusMessage(summary);
+                    resolve(status);
+                });
+                stream.onerror = () => {
+                    stream.close();
+                    reject(new Error('lost the scenario progress stream'));
+                };
+            }));
     }
 
-    async function runHeavyScenario() {
-        const hasOnline = servers.some(server => server.PingStatus);
-        if (!hasOnline) {
-            setStatusMessage('No online servers available for heavy load burst');
-            logEvent('Heavy load scenario skipped: no online servers', 'warning');
-            return;
-        }
-        const burst = requestsPerSecond ? normalizeRpsValue(requestsPerSecond.value) : rpsStops[1];
-        const clamped = Math.min(Math.max(burst, 5), 60);
-        await runTrafficBurst(clamped, 'high');
-        setStatusMessage(`Heavy load burst dispatched (${clamped} requests)`);
-        logEvent(`Heavy load burst dispatched (${clamped} requests)`, 'info');
+    function runFailureScenario() {
+        return runServerScenario('failure', 'Failure drill');
     }
 
-    async function runPriorityScenario() {
-        const hasOnline = servers.some(server => server.PingStatus);
-        if (!hasOnline) {
-            setStatusMessage('No online servers available for priority spike');
-            logEvent('Priority scenario skipped: no online servers', 'warning');
-            return;
-        }
-        await runTrafficBurst(8, 'critical');
-        await runTrafficBurst(6, 'medium');
-        setStatusMessage('Priority spike executed');
-        logEvent('Priority spike executed', 'success');
+    function runHeavyScenario() {
+        return runServerScenario('heavy', 'Heavy load');
     }
 
-    async function runRecoveryScenario() {
-        if (!servers || servers.length === 0) {
-            setStatusMessage('No servers registered for recovery sweep');
-            logEvent('Recovery scenario skipped: no servers available', 'warning');
-            return;
-        }
-        const actions = servers.map(async server => {
-            if (!server.PingStatus) {
-                await fetch(`/api/servers/${server.ID}/toggle`, { method: 'POST' });
-            }
-            await fetch(`/api/servers/${server.ID}/reset`, { method: 'POST' });
-        });
-        await Promise.allSettled(actions);
-        setStatusMessage('Recovery sweep complete');
-        logEvent('Recovery scenario executed', 'success');
-        fetchServers();
+    function runPriorityScenario() {
+        return runServerScenario('priority', 'Priority spike');
     }
 
-    function runTrafficBurst(count, priority) {
-        const tasks = [];
-        for (let i = 0; i < count; i++) {
-            tasks.push(sendTestRequest({ priority, burstId: `${priority || 'normal'}-${Date.now()}-${i}` }));
-        }
-        return Promise.allSettled(tasks);
+    function runRecoveryScenario() {
+        return runServerScenario('recovery', 'Recovery sweep');
     }
 
     function handlePacketEvent(packet) {
@@ -950,29 +992,36 @@ document.addEventListener('DOMContentLoaded', function () {
         responseTimeChart.update('none'); // Update without animation for smoother updates
     }
 
+    let lastEventId = null;
+
     function connectEventSource() {
-        const eventSource = new EventSource('/api/events');
+        // Resume after the last event seen, so a reconnect replays the gap
+        const eventSource = new EventSource(lastEventId ? `/api/events?lastEventId=${lastEventId}` : '/api/events');
 
-        eventSource.onmessage = function (event) {
+        const handleEvent = function (event) {
             try {
+                lastEventId = event.lastEventId || lastEventId;
                 const eventData = JSON.parse(event.data);
                 if (eventData.type === 'packet') {
-                    if (eventData.message) {
-                        try {
-                            const packet = JSON.parse(eventData.message);
-                            handlePacketEvent(packet);
-                        } catch (parseError) {
-                            console.error('Failed to parse packet payload:', parseError);
-                        }
+                    if (eventData.data) {
+                        handlePacketEvent(eventData.data);
                     }
                     return;
                 }
-                logEvent(eventData.message, eventData.type);
+                // Types without their own style (e.g. breaker) take their severity's
+                const styled = ['info', 'success', 'warning', 'error'];
+                logEvent(eventData.message, styled.includes(eventData.type) ? eventData.type : eventData.severity);
                 setStatusMessage(eventData.message);
             } catch (error) {
                 console.error('Error parsing event data:', error);
             }
         };
+        ['info', 'success', 'warning', 'error', 'packet', 'breaker'].forEach(function (type) {
+            eventSource.addEventListener(type, handleEvent);
+        });
+        eventSource.addEventListener('gap', function () {
+            logEvent('Some events were missed while reconnecting', 'warning');
+        });
 
         eventSource.onerror = function () {
             console.error("EventSource connection error");
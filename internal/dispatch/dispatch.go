@@ -0,0 +1,337 @@
+// internal/dispatch/dispatch.go
+package dispatch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"load-balancer/internal/events"
+	"load-balancer/internal/lb"
+	"load-balancer/internal/metrics"
+	"load-balancer/internal/mirror"
+	"load-balancer/internal/proxy"
+	"load-balancer/internal/router"
+	"load-balancer/internal/server"
+)
+
+// Request is a request being dispatched to a pool. PrePick filters may
+// adjust HTTP (headers, path) before the first server is picked.
+type Request struct {
+	HTTP      *http.Request
+	Body      []byte
+	Target    *router.Target
+	ID        string
+	Priority  string
+	ClientIP  string
+	Synthetic bool // a /api/test probe; kept out of the real request metrics
+	Attempt   int  // 1-based number of the attempt in progress
+}
+
+// Filter hooks into dispatch. Every hook is optional.
+type Filter struct {
+	Name string
+
+	// PrePick runs once before any server is picked. An error rejects the
+	// request: with the status of a *Rejection, otherwise 500.
+	PrePick func(req *Request) error
+
+	// PostPick runs after each pick, before forwarding. An error skips the
+	// server and the request is rerouted to another.
+	PostPick func(req *Request, srv *server.Server) error
+
+	// OnResponse runs on the answer about to be returned to the client and
+	// may rewrite its status, headers or body.
+	OnResponse func(req *Request, srv *server.Server, res *proxy.Result)
+
+	// OnError runs for every failed attempt: transport errors and 5xx answers.
+	OnError func(req *Request, srv *server.Server, err error)
+}
+
+// Rejection is a filter error that answers the client with Status.
+type Rejection struct {
+	Status  int
+	Message string
+}
+
+func (r *Rejection) Error() string { return r.Message }
+
+// Reject returns a Rejection for a PrePick filter to return.
+func Reject(status int, message string) error {
+	return &Rejection{Status: status, Message: message}
+}
+
+// Middleware wraps the dispatcher's handler, outside route matching.
+type Middleware func(http.Handler) http.Handler
+
+// Dispatcher matches requests to a route and proxies them to the route's pool:
+// it picks servers, skips busy ones, retries failures on other servers, feeds
+// the circuit breaker and metrics and publishes packet events. It serves /lb/
+// and the synthetic probes behind /api/test.
+type Dispatcher struct {
+	Routes    *router.Table
+	Upstreams *proxy.Registry
+	Mirrors   *mirror.Sender
+	Metrics   *metrics.MetricsManager
+	Events    *events.EventSystem
+
+	mu         sync.RWMutex
+	middleware []Middleware
+	filters    []Filter
+	handler    http.Handler
+}
+
+// New creates a dispatcher with no middleware or filters.
+func New(routes *router.Table, upstreams *proxy.Registry, mirrors *mirror.Sender,
+	mm *metrics.MetricsManager, es *events.EventSystem) *Dispatcher {
+	d := &Dispatcher{
+		Routes:    routes,
+		Upstreams: upstreams,
+		Mirrors:   mirrors,
+		Metrics:   mm,
+		Events:    es,
+	}
+	d.handler = http.HandlerFunc(d.route)
+	return d
+}
+
+// Use wraps the dispatcher in middleware. The first middleware added is the
+// outermost.
+func (d *Dispatcher) Use(mw ...Middleware) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h := http.Handler(http.HandlerFunc(d.route))
+	d.middleware = append(d.middleware, mw...)
+	for i := len(d.middleware) - 1; i >= 0; i-- {
+		h = d.middleware[i](h)
+	}
+	d.handler = h
+}
+
+// AddFilter appends filters; hooks run in the order filters were added.
+func (d *Dispatcher) AddFilter(filters ...Filter) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.filters = append(d.filters, filters...)
+}
+
+// ServeHTTP runs the middleware chain, then routes and dispatches r.
+func (d *Dispatcher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.RLock()
+	h := d.handler
+	d.mu.RUnlock()
+	h.ServeHTTP(w, r)
+}
+
+// route matches a route, rewrites the path for its pool and dispatches.
+func (d *Dispatcher) route(w http.ResponseWriter, r *http.Request) {
+	target := d.Routes.Match(r)
+	if target == nil {
+		d.event(events.WarningEvent, "", "", fmt.Sprintf("No route matches %s %s", r.Method, r.URL.Path))
+		http.Error(w, "Not Found (no matching route)", http.StatusNotFound)
+		return
+	}
+	target.Route.Apply(r)
+	d.Dispatch(target, w, r)
+}
+
+// Dispatch proxies r to target's pool, retrying on other servers when one is
+// busy, unreachable or answers with a 5xx.
+func (d *Dispatcher) Dispatch(target *router.Target, w http.ResponseWriter, r *http.Request) {
+	d.mu.RLock()
+	filters := d.filters
+	d.mu.RUnlock()
+
+	balancer := target.Pool.Balancer
+	cbc := target.Pool.Breaker
+	attempted := target.Exclude()
+
+	totalServers := len(balancer.ServerManager.GetAllServers()) - len(attempted)
+	if totalServers <= 0 {
+		d.event(events.ErrorEvent, "", "", "Request failed: No backend servers registered")
+		http.Error(w, "Service Unavailable (no backend servers)", http.StatusServiceUnavailable)
+		return
+	}
+
+	req := &Request{
+		HTTP:      r,
+		Target:    target,
+		ID:        d.Metrics.GeneratePacketID(),
+		Priority:  lb.ExtractPriority(r),
+		ClientIP:  proxy.ClientIP(r),
+		Synthetic: metrics.IsSynthetic(r.Context()),
+	}
+	kind := "Request"
+	if req.Synthetic {
+		kind = "Synthetic probe"
+	}
+
+	if r.Body != nil {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			d.event(events.ErrorEvent, req.ID, "", fmt.Sprintf("Failed to read request body: %v", err))
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		req.Body = body
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	for _, f := range filters {
+		if f.PrePick == nil {
+			continue
+		}
+		if err := f.PrePick(req); err != nil {
+			var rejection *Rejection
+			if errors.As(err, &rejection) {
+				http.Error(w, rejection.Message, rejection.Status)
+			} else {
+				d.event(events.ErrorEvent, req.ID, "", fmt.Sprintf("Filter %s failed %s %s: %v", f.Name, kind, req.ID, err))
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+	r = req.HTTP
+
+	// Shadow a copy to the route's mirror pool, if this request was sampled.
+	// Probes are never mirrored.
+	var shadow *mirror.Shadow
+	if target.Mirror != nil && !req.Synthetic {
+		shadow = d.Mirrors.Send(target.Route.Config.Name, *target.Route.Config.Mirror, target.Mirror, r, req.Body)
+	}
+	requestStart := time.Now()
+
+	var lastErr error
+
+	for attempt := 0; attempt < totalServers; attempt++ {
+		srv := balancer.PickServerWithExclude(r, attempted)
+		if srv == nil {
+			break
+		}
+		attempted[srv.ID] = true
+		req.Attempt = attempt + 1
+
+		active := server.BeginRequest(srv)
+		d.packet(req, srv, "dispatch", "", 0, active)
+
+		if active > lb.BusyThreshold {
+			d.packet(req, srv, "rerouted", "busy", 0, server.EndRequest(srv))
+			d.event(events.WarningEvent, req.ID, srv.ID, fmt.Sprintf("Server %s busy; rerouting request %s", srv.ID, req.ID))
+			continue
+		}
+
+		if name, err := postPick(filters, req, srv); err != nil {
+			d.packet(req, srv, "rerouted", err.Error(), 0, server.EndRequest(srv))
+			d.event(events.WarningEvent, req.ID, srv.ID, fmt.Sprintf("Filter %s skipped server %s for request %s: %v", name, srv.ID, req.ID, err))
+			continue
+		}
+
+		start := time.Now()
+		result, err := proxy.Forward(d.Upstreams.Get(srv), srv, r, req.Body)
+		responseMs := float64(time.Since(start).Milliseconds())
+
+		if err != nil || result.StatusCode >= http.StatusInternalServerError {
+			activeAfter := server.EndRequest(srv)
+			cbc.RecordFailure(srv)
+			d.record(req, srv.ID, responseMs, true)
+
+			if err != nil {
+				d.packet(req, srv, "failed", err.Error(), responseMs, activeAfter)
+				d.event(events.ErrorEvent, req.ID, srv.ID, fmt.Sprintf("Request to %s failed: %v", srv.ID, err))
+			} else {
+				err = fmt.Errorf("backend status %d", result.StatusCode)
+				d.packet(req, srv, "failed", fmt.Sprintf("status %d", result.StatusCode), responseMs, activeAfter)
+				d.event(events.WarningEvent, req.ID, srv.ID, fmt.Sprintf("Request to %s returned status %d", srv.ID, result.StatusCode))
+			}
+			for _, f := range filters {
+				if f.OnError != nil {
+					f.OnError(req, srv, err)
+				}
+			}
+
+			lastErr = err
+			continue
+		}
+
+		cbc.RecordSuccess(srv)
+		d.record(req, srv.ID, responseMs, false)
+		d.packet(req, srv, "completed", "", responseMs, server.EndRequest(srv))
+
+		d.event(events.InfoEvent, req.ID, srv.ID, fmt.Sprintf("%s %s from %s served by %s in %.0fms", kind, req.ID, req.ClientIP, srv.ID, responseMs))
+
+		shadow.Compare(result.StatusCode, responseMs)
+		result.Header.Set("X-Served-By", srv.ID)
+		for _, f := range filters {
+			if f.OnResponse != nil {
+				f.OnResponse(req, srv, result)
+			}
+		}
+		proxy.WriteResponse(w, result)
+		return
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no healthy downstream servers")
+	}
+	d.event(events.ErrorEvent, req.ID, "", fmt.Sprintf("%s %s from %s failed: %v", kind, req.ID, req.ClientIP, lastErr))
+	shadow.Compare(http.StatusServiceUnavailable, float64(time.Since(requestStart).Milliseconds()))
+	http.Error(w, "Service Unavailable (no healthy servers)", http.StatusServiceUnavailable)
+}
+
+// postPick runs the PostPick hooks, returning the first filter to object.
+func postPick(filters []Filter, req *Request, srv *server.Server) (string, error) {
+	for _, f := range filters {
+		if f.PostPick == nil {
+			continue
+		}
+		if err := f.PostPick(req, srv); err != nil {
+			return f.Name, err
+		}
+	}
+	return "", nil
+}
+
+// event publishes a dispatch event about a request and, when known, a server.
+func (d *Dispatcher) event(t events.EventType, requestID, serverID, message string) {
+	d.Events.Emit(events.Event{Type: t, Source: "dispatch", RequestID: requestID, ServerID: serverID, Message: message})
+}
+
+// packet records and broadcasts a packet event for the current attempt.
+func (d *Dispatcher) packet(req *Request, srv *server.Server, status, reason string, responseMs float64, active int64) {
+	d.Metrics.RecordAndBroadcastPacketEvent(d.Events, metrics.PacketEvent{
+		RequestID:      req.ID,
+		Attempt:        req.Attempt,
+		Priority:       req.Priority,
+		ClientIP:       req.ClientIP,
+		Route:          req.Target.Route.Config.Name,
+		Variant:        req.Target.Variant,
+		ServerID:       srv.ID,
+		ServerAddress:  fmt.Sprintf("%s:%d", srv.Address, srv.Port),
+		Status:         status,
+		Reason:         reason,
+		Timestamp:      time.Now(),
+		ResponseTime:   responseMs,
+		ActiveRequests: active,
+		Synthetic:      req.Synthetic,
+	})
+}
+
+// record feeds one finished attempt to the request and split-variant metrics,
+// or only to the synthetic metrics for a probe.
+func (d *Dispatcher) record(req *Request, serverID string, responseMs float64, isError bool) {
+	if req.Synthetic {
+		d.Metrics.RecordSyntheticRequest(serverID, responseMs, isError)
+		return
+	}
+	d.Metrics.RecordRequest(serverID, responseMs, isError)
+	if req.Target.Variant != "" {
+		d.Metrics.RecordVariantRequest(req.Target.Route.Config.Name, req.Target.Variant, responseMs, isError)
+	}
+}
@@ -0,0 +1,205 @@
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/events"
+	"load-balancer/internal/metrics"
+	"load-balancer/internal/mirror"
+	"load-balancer/internal/proxy"
+	"load-balancer/internal/router"
+	"load-balancer/internal/server"
+)
+
+// newTestDispatcher fronts one pool of the given backends with a dispatcher.
+func newTestDispatcher(t *testing.T, backends ...http.HandlerFunc) *Dispatcher {
+	t.Helper()
+	var servers []config.ServerConfig
+	for i, h := range backends {
+		ts := httptest.NewServer(h)
+		t.Cleanup(ts.Close)
+		addr := ts.Listener.Addr().(*net.TCPAddr)
+		servers = append(servers, config.ServerConfig{ID: "server-" + strconv.Itoa(i+1), Address: "127.0.0.1", Port: addr.Port})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	table := router.NewTable(ctx, &config.Config{
+		HealthCheckInterval: time.Hour,
+		CircuitBreaker:      config.CircuitBreakerConfig{FailureThreshold: 3, CooldownPeriod: time.Second, TrialRequests: 1},
+	})
+	pool, err := table.AddPoolConfig(config.PoolConfig{Name: "web", Servers: servers})
+	if err != nil {
+		t.Fatalf("add pool: %v", err)
+	}
+	for _, srv := range pool.Manager.GetAllServers() {
+		srv.PingStatus = true
+		srv.CurrentWeight = 1
+	}
+	if err := table.SetRoute(config.RouteConfig{Name: "default", PathPrefix: "/lb/", StripPrefix: "/lb", Pool: "web"}); err != nil {
+		t.Fatalf("set route: %v", err)
+	}
+
+	upstreams := proxy.NewRegistry(proxy.PoolSettings{MaxIdleConns: 4, RequestTimeout: time.Second})
+	t.Cleanup(upstreams.CloseAll)
+	mm := metrics.NewMetricsManager(pool.Manager)
+	return New(table, upstreams, mirror.NewSender(upstreams, mm, 4), mm, events.NewEventSystem(100))
+}
+
+func named(id string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Backend", id)
+		w.Write([]byte(id + " " + r.URL.Path))
+	}
+}
+
+func serve(d *Dispatcher, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestDispatcher_FilterHooksRunInOrder(t *testing.T) {
+	d := newTestDispatcher(t, named("a"), named("b"))
+
+	var calls []string
+	d.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, "middleware")
+			next.ServeHTTP(w, r)
+		})
+	})
+	d.AddFilter(
+		Filter{
+			Name: "rewrite",
+			PrePick: func(req *Request) error {
+				calls = append(calls, "pre-pick")
+				req.HTTP.URL.Path = "/rewritten"
+				return nil
+			},
+			PostPick: func(req *Request, srv *server.Server) error {
+				calls = append(calls, "post-pick "+srv.ID)
+				if srv.ID == "server-1" {
+					return errors.New("not this one")
+				}
+				return nil
+			},
+		},
+		Filter{
+			Name: "stamp",
+			OnResponse: func(req *Request, srv *server.Server, res *proxy.Result) {
+				calls = append(calls, "on-response")
+				res.Header.Set("X-Filtered", req.ID)
+			},
+		},
+	)
+
+	// Whichever server is picked first, server-1 is always skipped
+	for i := 0; i < 2; i++ {
+		calls = nil
+		rec := serve(d, "/lb/hello")
+		if rec.Code != http.StatusOK || rec.Header().Get("X-Filtered") == "" {
+			t.Fatalf("response = %d %v", rec.Code, rec.Header())
+		}
+		if got := rec.Body.String(); got != "b /rewritten" {
+			t.Errorf("body = %q, want server-2 to get the rewritten path", got)
+		}
+		want := []string{"middleware", "pre-pick"}
+		if calls[2] == "post-pick server-1" {
+			want = append(want, "post-pick server-1")
+		}
+		want = append(want, "post-pick server-2", "on-response")
+		if strings.Join(calls, ",") != strings.Join(want, ",") {
+			t.Errorf("calls = %v, want %v", calls, want)
+		}
+	}
+}
+
+func TestDispatcher_RejectionsAndErrors(t *testing.T) {
+	failing := func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}
+	d := newTestDispatcher(t, failing, named("b"))
+
+	var failures []string
+	d.AddFilter(Filter{
+		Name: "auth",
+		PrePick: func(req *Request) error {
+			if req.HTTP.Header.Get("Authorization") == "" {
+				return Reject(http.StatusUnauthorized, "Unauthorized")
+			}
+			return nil
+		},
+		OnError: func(req *Request, srv *server.Server, err error) {
+			failures = append(failures, srv.ID+": "+err.Error())
+		},
+	})
+
+	if rec := serve(d, "/lb/"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unauthenticated = %d, want 401", rec.Code)
+	}
+
+	for i := 0; i < 4; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/lb/", nil)
+		req.Header.Set("Authorization", "Bearer x")
+		rec := httptest.NewRecorder()
+		d.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "b ") {
+			t.Errorf("request %d = %d %q, want a retry onto server-2", i, rec.Code, rec.Body.String())
+		}
+	}
+	if len(failures) == 0 || failures[0] != "server-1: backend status 503" {
+		t.Errorf("OnError saw %v", failures)
+	}
+
+	if rec := serve(d, "/elsewhere"); rec.Code != http.StatusNotFound {
+		t.Errorf("unrouted = %d, want 404", rec.Code)
+	}
+}
+
+func TestDispatcher_MirrorsSampledRequestsToTheShadowPool(t *testing.T) {
+	d := newTestDispatcher(t, named("primary"))
+
+	shadowed := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		shadowed <- r.URL.Path
+		w.Write([]byte("shadow"))
+	}))
+	t.Cleanup(ts.Close)
+	port := ts.Listener.Addr().(*net.TCPAddr).Port
+	if _, err := d.Routes.AddPoolConfig(config.PoolConfig{
+		Name:    "shadow",
+		Servers: []config.ServerConfig{{ID: "shadow-1", Address: "127.0.0.1", Port: port}},
+	}); err != nil {
+		t.Fatalf("add pool: %v", err)
+	}
+	err := d.Routes.SetRoute(config.RouteConfig{
+		Name: "default", PathPrefix: "/lb/", StripPrefix: "/lb", Pool: "web",
+		Mirror: &config.MirrorConfig{Pool: "shadow", Percentage: 100},
+	})
+	if err != nil {
+		t.Fatalf("set route: %v", err)
+	}
+
+	rec := serve(d, "/lb/orders")
+	if rec.Code != http.StatusOK || rec.Body.String() != "primary /orders" {
+		t.Fatalf("client got %d %q, want the primary's response", rec.Code, rec.Body)
+	}
+	select {
+	case path := <-shadowed:
+		if path != "/orders" {
+			t.Errorf("shadow pool received %s, want /orders", path)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("shadow pool never received the request")
+	}
+}
@@ -0,0 +1,30 @@
+// internal/dispatch/filters.go
+package dispatch
+
+import (
+	"fmt"
+	"net/http"
+
+	"load-balancer/internal/events"
+	ratelimiter "load-balancer/rate_limiter"
+)
+
+// RateLimit rejects clients over their per-IP rate with 429 before a server
+// is picked.
+func RateLimit(limiter *ratelimiter.ClientLimiter, es *events.EventSystem) Filter {
+	return Filter{
+		Name: "rate-limit",
+		PrePick: func(req *Request) error {
+			if limiter.Allow(req.ClientIP) {
+				return nil
+			}
+			es.Emit(events.Event{
+				Type:      events.WarningEvent,
+				Source:    "rate-limit",
+				RequestID: req.ID,
+				Message:   fmt.Sprintf("Rate limit exceeded for client %s", req.ClientIP),
+			})
+			return Reject(http.StatusTooManyRequests, "Too Many Requests")
+		},
+	}
+}
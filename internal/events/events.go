@@ -17,14 +17,33 @@ const (
 	SuccessEvent EventType = "success"
 	WarningEvent EventType = "warning"
 	ErrorEvent   EventType = "error"
-	PacketEvent  EventType = "packet"
+	PacketEvent  EventType = "packet"  // Data is a metrics.PacketEvent
+	BreakerEvent EventType = "breaker" // a server's circuit breaker opened
 )
 
-// Event represents a system event
+// Event represents a system event. IDs increase with every event published,
+// packets included. ServerID and RequestID are set when the event concerns
+// one server or request; Data carries a typed payload, such as the packet of
+// a PacketEvent.
 type Event struct {
-	Type      EventType `json:"type"`
-	Message   string    `json:"message"`
-	Timestamp time.Time `json:"timestamp"`
+	ID        uint64          `json:"id"`
+	Type      EventType       `json:"type"`
+	Severity  Severity        `json:"severity"`
+	Source    string          `json:"source,omitempty"` // the component that published it, e.g. "dispatch"
+	ServerID  string          `json:"serverId,omitempty"`
+	RequestID string          `json:"requestId,omitempty"`
+	Message   string          `json:"message"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// Payload marshals v for Event.Data, returning nil if it cannot.
+func Payload(v interface{}) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
 }
 
 // Subscriber is a channel that receives event notifications
@@ -32,11 +51,18 @@ type Subscriber chan string
 
 // EventSystem manages pub-sub for system events
 type EventSystem struct {
-	subscribers      map[Subscriber]bool
-	subscribersMutex sync.RWMutex
-	events           []Event
-	eventsMutex      sync.RWMutex
-	maxEvents        int
+	// subscribersMutex also orders publishing: it guards the last ID and the
+	// replay log, so subscribers see events in ID order.
+	subscribers      map[Subscriber]*subscription
+	subscribersMutex sync.Mutex
+	lastID           uint64
+	nextSubscriber   int
+	replay           replayLog
+	recorders        []func(Event)
+
+	events      []Event
+	eventsMutex sync.RWMutex
+	maxEvents   int
 }
 
 // NewEventSystem creates a new event system
@@ -46,7 +72,8 @@ func NewEventSystem(maxEvents int) *EventSystem {
 	}
 
 	return &EventSystem{
-		subscribers: make(map[Subscriber]bool),
+		subscribers: make(map[Subscriber]*subscription),
+		replay:      replayLog{entries: make([]retained, replayLogSize)},
 		events:      make([]Event, 0, maxEvents),
 		maxEvents:   maxEvents,
 	}
@@ -54,12 +81,27 @@ func NewEventSystem(maxEvents int) *EventSystem {
 
 // Subscribe registers a new subscriber channel
 func (es *EventSystem) Subscribe() Subscriber {
+	return es.SubscribeFilter(Filter{})
+}
+
+// SubscribeFilter registers a subscriber that only receives events matching
+// filter. Events that find its buffer full are dropped and counted.
+func (es *EventSystem) SubscribeFilter(filter Filter) Subscriber {
 	es.subscribersMutex.Lock()
 	defer es.subscribersMutex.Unlock()
 
-	subscriber := make(Subscriber, 10) // Buffer size of 10
-	es.subscribers[subscriber] = true
+	return es.subscribe(filter, false, 10) // Buffer size of 10
+}
 
+func (es *EventSystem) subscribe(filter Filter, stream bool, buffer int) Subscriber {
+	es.nextSubscriber++
+	subscriber := make(Subscriber, buffer)
+	es.subscribers[subscriber] = &subscription{
+		id:     es.nextSubscriber,
+		filter: filter,
+		stream: stream,
+		since:  time.Now(),
+	}
 	return subscriber
 }
 
@@ -68,22 +110,59 @@ func (es *EventSystem) Unsubscribe(subscriber Subscriber) {
 	es.subscribersMutex.Lock()
 	defer es.subscribersMutex.Unlock()
 
-	if _, exists := es.subscribers[subscriber]; exists {
+	if sub, exists := es.subscribers[subscriber]; exists {
 		delete(es.subscribers, subscriber)
-		close(subscriber)
+		if !sub.closed {
+			close(subscriber)
+		}
 	}
 }
 
-// Publish broadcasts an event to all subscribers
+// OnEvent registers fn to be called with every event, packets included, in
+// ID order. fn runs while publishing is held, so it must not block or
+// publish itself.
+func (es *EventSystem) OnEvent(fn func(Event)) {
+	es.subscribersMutex.Lock()
+	defer es.subscribersMutex.Unlock()
+	es.recorders = append(es.recorders, fn)
+}
+
+// ContinueAfter moves the next ID past id, so IDs keep increasing across
+// restarts when events are stored elsewhere.
+func (es *EventSystem) ContinueAfter(id uint64) {
+	es.subscribersMutex.Lock()
+	defer es.subscribersMutex.Unlock()
+	if id > es.lastID {
+		es.lastID = id
+	}
+}
+
+// Publish broadcasts an event with just a type and message
 func (es *EventSystem) Publish(eventType EventType, message string) {
-	event := Event{
-		Type:      eventType,
-		Message:   message,
-		Timestamp: time.Now(),
+	es.Emit(Event{Type: eventType, Message: message})
+}
+
+// Emit broadcasts a structured event to matching subscribers, filling in
+// the ID, the timestamp and, from the type, the severity when unset.
+func (es *EventSystem) Emit(event Event) {
+	if event.Severity == "" {
+		event.Severity = DefaultSeverity(event.Type)
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	es.subscribersMutex.Lock()
+	defer es.subscribersMutex.Unlock()
+
+	es.lastID++
+	event.ID = es.lastID
+	for _, record := range es.recorders {
+		record(event)
 	}
 
 	// Store event in history
-	if eventType != PacketEvent {
+	if event.Type != PacketEvent {
 		es.eventsMutex.Lock()
 		if len(es.events) >= es.maxEvents {
 			// Remove oldest event
@@ -100,19 +179,28 @@ func (es *EventSystem) Publish(eventType EventType, message string) {
 		log.Printf("Error marshaling event: %v", err)
 		return
 	}
+	es.replay.add(retained{event: event, json: string(eventJSON)})
 
 	// Send to all subscribers
-	es.subscribersMutex.RLock()
-	defer es.subscribersMutex.RUnlock()
-
-	for subscriber := range es.subscribers {
+	for subscriber, sub := range es.subscribers {
+		if sub.closed || !sub.filter.Match(event) {
+			continue
+		}
 		// Non-blocking send
 		select {
 		case subscriber <- string(eventJSON):
-			// Message sent successfully
+			sub.delivered++
 		default:
-			// Channel buffer is full, log and continue
-			log.Printf("Subscriber channel full, event dropped")
+			sub.dropped++
+			if sub.stream {
+				// A stream can resume from the replay log, so cut it off
+				// rather than leave a gap it cannot see
+				sub.closed = true
+				close(subscriber)
+				log.Printf("Event subscriber %d fell %d events behind; disconnected", sub.id, cap(subscriber))
+			} else {
+				log.Printf("Event subscriber %d channel full, event %d dropped (%d so far)", sub.id, event.ID, sub.dropped)
+			}
 		}
 	}
 }
@@ -138,3 +226,40 @@ func (es *EventSystem) GetRecentEvents(limit int) []Event {
 
 	return result
 }
+
+// RestoreEvents puts saved events in front of the current history, keeping
+// the newest maxEvents, and moves the next ID past theirs. Restored events
+// are not sent to subscribers.
+func (es *EventSystem) RestoreEvents(saved []Event) int {
+	es.subscribersMutex.Lock()
+	defer es.subscribersMutex.Unlock()
+	es.eventsMutex.Lock()
+	defer es.eventsMutex.Unlock()
+
+	history := make([]Event, 0, len(saved)+len(es.events))
+	for _, event := range saved {
+		// Keep IDs increasing across restarts
+		if event.ID > es.lastID {
+			es.lastID = event.ID
+		}
+		if event.Type != PacketEvent {
+			if event.Severity == "" { // saved before events had one
+				event.Severity = DefaultSeverity(event.Type)
+			}
+			history = append(history, event)
+		}
+	}
+	restored := len(history)
+	history = append(history, es.events...)
+
+	if len(history) > es.maxEvents {
+		dropped := len(history) - es.maxEvents
+		history = history[dropped:]
+		restored -= dropped
+		if restored < 0 {
+			restored = 0
+		}
+	}
+	es.events = history
+	return restored
+}
@@ -0,0 +1,94 @@
+package events
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSubscribeFilter(t *testing.T) {
+	es := NewEventSystem(10)
+	filter, err := ParseFilter(url.Values{"types": {"packet,breaker"}, "server": {"server-1"}, "minSeverity": {"warning"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := es.SubscribeFilter(filter)
+	all := es.Subscribe()
+
+	es.Emit(Event{Type: PacketEvent, ServerID: "server-1", Message: "completed"})                           // debug
+	es.Emit(Event{Type: BreakerEvent, ServerID: "server-2", Message: "server-2 tripped"})                   // other server
+	es.Emit(Event{Type: WarningEvent, ServerID: "server-1", Message: "busy"})                               // other type
+	es.Emit(Event{Type: PacketEvent, Severity: SeverityWarning, ServerID: "server-1", Message: "failed"})   // match
+	es.Emit(Event{Type: BreakerEvent, ServerID: "server-1", Message: "server-1 tripped", Data: Payload(3)}) // match
+	es.Unsubscribe(sub)
+	es.Unsubscribe(all)
+
+	var got []Event
+	for msg := range sub {
+		var e Event
+		if err := json.Unmarshal([]byte(msg), &e); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, e)
+	}
+	if len(got) != 2 || got[0].Message != "failed" || got[1].Message != "server-1 tripped" {
+		t.Fatalf("filtered subscriber got %+v", got)
+	}
+	if got[1].Severity != SeverityWarning || string(got[1].Data) != "3" || got[1].Timestamp.IsZero() {
+		t.Errorf("breaker event not filled in: %+v", got[1])
+	}
+	if len(all) != 5 {
+		t.Errorf("unfiltered subscriber got %d events, want 5", len(all))
+	}
+
+	if _, err := ParseFilter(url.Values{"minSeverity": {"loud"}}); err == nil {
+		t.Error("unknown severity accepted")
+	}
+}
+
+func TestResumeAndOverflow(t *testing.T) {
+	es := NewEventSystem(10)
+	for i := 0; i < 5; i++ {
+		es.Publish(InfoEvent, "before")
+	}
+	es.Emit(Event{Type: PacketEvent, Message: "packet"})
+
+	// A client that saw event 3 gets 4, 5 and the packet again
+	sub, replay, complete := es.Resume(Filter{}, 3)
+	if !complete || len(replay) != 3 || !strings.Contains(replay[0], `"id":4`) || !strings.Contains(replay[2], `"type":"packet"`) {
+		t.Fatalf("Resume(3): complete %v, replay %v", complete, replay)
+	}
+	es.Unsubscribe(sub)
+
+	// One from a previous process is told the history is gone
+	sub, replay, complete = es.Resume(Filter{}, 99)
+	if complete || len(replay) != 6 {
+		t.Errorf("Resume(99): complete %v, %d replayed", complete, len(replay))
+	}
+	es.Unsubscribe(sub)
+
+	// A stream that falls behind is closed, not left with a silent gap
+	stream := es.SubscribeStream(Filter{})
+	for i := 0; i <= streamBuffer; i++ {
+		es.Publish(InfoEvent, "flood")
+	}
+	if overflowed, dropped := es.Overflowed(stream); !overflowed || dropped != 1 {
+		t.Errorf("stream after overflow: overflowed %v, dropped %d", overflowed, dropped)
+	}
+	for range stream {
+	}
+	stats := es.Subscribers()
+	if len(stats) != 1 || !stats[0].Stream || stats[0].Delivered != streamBuffer || stats[0].Dropped != 1 {
+		t.Errorf("subscriber stats: %+v", stats)
+	}
+	es.Unsubscribe(stream)
+
+	// IDs keep increasing after a restart restores the history
+	fresh := NewEventSystem(10)
+	fresh.RestoreEvents(es.GetRecentEvents(0))
+	fresh.Publish(InfoEvent, "after restart")
+	if got := fresh.GetRecentEvents(1)[0].ID; got != uint64(7+streamBuffer+1) {
+		t.Errorf("first ID after restore = %d, want %d", got, 7+streamBuffer+1)
+	}
+}
@@ -0,0 +1,76 @@
+// internal/events/filter.go
+package events
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Severity ranks events from routine to failure.
+type Severity string
+
+const (
+	SeverityDebug   Severity = "debug"
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+var severityRank = map[Severity]int{
+	SeverityDebug:   0,
+	SeverityInfo:    1,
+	SeverityWarning: 2,
+	SeverityError:   3,
+}
+
+// DefaultSeverity is the severity of an event published without one.
+func DefaultSeverity(t EventType) Severity {
+	switch t {
+	case PacketEvent:
+		return SeverityDebug
+	case WarningEvent, BreakerEvent:
+		return SeverityWarning
+	case ErrorEvent:
+		return SeverityError
+	default:
+		return SeverityInfo
+	}
+}
+
+// Filter selects events for a subscriber. Empty fields match everything.
+type Filter struct {
+	Types       []EventType `json:"types,omitempty"`
+	ServerID    string      `json:"server,omitempty"`
+	MinSeverity Severity    `json:"minSeverity,omitempty"`
+}
+
+// Match reports whether e passes the filter.
+func (f Filter) Match(e Event) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, e.Type) {
+		return false
+	}
+	if f.ServerID != "" && e.ServerID != f.ServerID {
+		return false
+	}
+	return f.MinSeverity == "" || severityRank[e.Severity] >= severityRank[f.MinSeverity]
+}
+
+// ParseFilter reads ?types=packet,breaker&server=server-1&minSeverity=warning.
+func ParseFilter(q url.Values) (Filter, error) {
+	var f Filter
+	for _, t := range strings.Split(q.Get("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			f.Types = append(f.Types, EventType(t))
+		}
+	}
+	f.ServerID = q.Get("server")
+	if raw := q.Get("minSeverity"); raw != "" {
+		f.MinSeverity = Severity(raw)
+		if _, ok := severityRank[f.MinSeverity]; !ok {
+			return Filter{}, fmt.Errorf("unknown severity %q; use debug, info, warning or error", raw)
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,138 @@
+// internal/events/stream.go
+package events
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// replayLogSize is how many recent events, packets included, a
+	// reconnecting stream can catch up on.
+	replayLogSize = 1000
+
+	// streamBuffer is how far a stream subscriber may fall behind before it
+	// is disconnected.
+	streamBuffer = 64
+)
+
+// subscription is the state of one subscriber.
+type subscription struct {
+	id        int
+	filter    Filter
+	stream    bool // disconnected, rather than dropping events, when full
+	since     time.Time
+	delivered uint64
+	dropped   uint64
+	closed    bool // disconnected for falling behind
+}
+
+// retained is an event kept for replay, with its encoding.
+type retained struct {
+	event Event
+	json  string
+}
+
+// replayLog is a ring of the most recent events.
+type replayLog struct {
+	entries []retained
+	start   int
+	count   int
+}
+
+func (l *replayLog) add(r retained) {
+	if l.count < len(l.entries) {
+		l.entries[(l.start+l.count)%len(l.entries)] = r
+		l.count++
+		return
+	}
+	l.entries[l.start] = r
+	l.start = (l.start + 1) % len(l.entries)
+}
+
+func (l *replayLog) at(i int) retained {
+	return l.entries[(l.start+i)%len(l.entries)]
+}
+
+// SubscribeStream registers a subscriber for a long-lived stream. Unlike
+// SubscribeFilter, a stream that falls behind is disconnected (see
+// Overflowed) instead of silently missing events, and can then Resume.
+func (es *EventSystem) SubscribeStream(filter Filter) Subscriber {
+	es.subscribersMutex.Lock()
+	defer es.subscribersMutex.Unlock()
+
+	return es.subscribe(filter, true, streamBuffer)
+}
+
+// Resume registers a stream subscriber for a client that last saw event
+// lastID. It also returns the retained events after lastID that match
+// filter, to be sent first, and false if the log no longer reaches back to
+// lastID, so some events were lost.
+func (es *EventSystem) Resume(filter Filter, lastID uint64) (Subscriber, []string, bool) {
+	es.subscribersMutex.Lock()
+	defer es.subscribersMutex.Unlock()
+
+	complete := true
+	if lastID > es.lastID {
+		// The ID is from before a restart that lost the history
+		lastID, complete = 0, false
+	}
+	if es.replay.count > 0 && es.replay.at(0).event.ID > lastID+1 {
+		complete = false
+	}
+
+	// Events are logged in ID order, so find the first one after lastID
+	first := sort.Search(es.replay.count, func(i int) bool { return es.replay.at(i).event.ID > lastID })
+	var replay []string
+	for i := first; i < es.replay.count; i++ {
+		if r := es.replay.at(i); filter.Match(r.event) {
+			replay = append(replay, r.json)
+		}
+	}
+	return es.subscribe(filter, true, streamBuffer), replay, complete
+}
+
+// Overflowed reports whether subscriber was disconnected for falling
+// behind, and how many events it missed.
+func (es *EventSystem) Overflowed(subscriber Subscriber) (bool, uint64) {
+	es.subscribersMutex.Lock()
+	defer es.subscribersMutex.Unlock()
+
+	sub, ok := es.subscribers[subscriber]
+	if !ok {
+		return false, 0
+	}
+	return sub.closed, sub.dropped
+}
+
+// SubscriberStats describes one subscriber for the admin API.
+type SubscriberStats struct {
+	ID        int       `json:"id"`
+	Filter    Filter    `json:"filter"`
+	Stream    bool      `json:"stream"`
+	Since     time.Time `json:"since"`
+	Delivered uint64    `json:"delivered"`
+	Dropped   uint64    `json:"dropped"`
+	Pending   int       `json:"pending"` // buffered, not yet read
+}
+
+// Subscribers reports every subscriber's delivery and drop counts.
+func (es *EventSystem) Subscribers() []SubscriberStats {
+	es.subscribersMutex.Lock()
+	defer es.subscribersMutex.Unlock()
+
+	stats := make([]SubscriberStats, 0, len(es.subscribers))
+	for subscriber, sub := range es.subscribers {
+		stats = append(stats, SubscriberStats{
+			ID:        sub.id,
+			Filter:    sub.filter,
+			Stream:    sub.stream,
+			Since:     sub.since,
+			Delivered: sub.delivered,
+			Dropped:   sub.dropped,
+			Pending:   len(subscriber),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ID < stats[j].ID })
+	return stats
+}
@@ -0,0 +1,330 @@
+// internal/ha/node.go
+package ha
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/events"
+	"load-balancer/internal/handoff"
+)
+
+// Role is a node's part in the active/passive pair.
+type Role string
+
+const (
+	RoleStandby Role = "standby"
+	RoleLeader  Role = "leader"
+)
+
+// Node runs leader election with its peers. Every node heartbeats over UDP;
+// a standby that misses the leader's heartbeats for FailoverTimeout starts a
+// new term and takes over. Terms fence a stale leader: as soon as it hears a
+// higher term it steps down, and state from an older term is ignored.
+type Node struct {
+	cfg    config.HAConfig
+	Events *events.EventSystem
+
+	// Capture produces the state the leader replicates; Apply installs it on
+	// a standby.
+	Capture func() *handoff.State
+	Apply   func(*handoff.State)
+
+	mu        sync.Mutex
+	role      Role
+	term      uint64
+	leader    string
+	lastHeard time.Time // last heartbeat from the current leader
+	resigned  bool
+	replicas  int64 // states applied from the leader
+	peers     map[string]*PeerStatus
+
+	udp *net.UDPConn
+	tcp net.Listener
+}
+
+// PeerStatus is what a node last heard from one of its peers.
+type PeerStatus struct {
+	ID       string    `json:"id"`
+	Addr     string    `json:"addr"`
+	Role     Role      `json:"role"`
+	Term     uint64    `json:"term"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// Status reports a node's role for the admin API.
+type Status struct {
+	Node       string       `json:"node"`
+	Role       Role         `json:"role"`
+	Term       uint64       `json:"term"`
+	Leader     string       `json:"leader,omitempty"`
+	Replicated int64        `json:"replicated"`
+	Peers      []PeerStatus `json:"peers"`
+}
+
+// NewNode binds the heartbeat (UDP) and replication (TCP) sockets on
+// cfg.Bind. The node starts as a standby.
+func NewNode(cfg config.HAConfig, es *events.EventSystem, capture func() *handoff.State, apply func(*handoff.State)) (*Node, error) {
+	if cfg.NodeID == "" {
+		return nil, fmt.Errorf("ha: node ID is required")
+	}
+	if cfg.Secret == "" {
+		return nil, fmt.Errorf("ha: shared secret is required")
+	}
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = 500 * time.Millisecond
+	}
+	if cfg.FailoverTimeout <= cfg.HeartbeatInterval {
+		cfg.FailoverTimeout = 4 * cfg.HeartbeatInterval
+	}
+	if cfg.ReplicateInterval <= 0 {
+		cfg.ReplicateInterval = time.Second
+	}
+
+	udpAddr, err := net.ResolveUDPAddr("udp", cfg.Bind)
+	if err != nil {
+		return nil, fmt.Errorf("ha: invalid bind address %q: %w", cfg.Bind, err)
+	}
+	udp, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return nil, fmt.Errorf("ha: %w", err)
+	}
+	// Replication listens on the same port number, so peers need one address.
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	if err != nil {
+		udp.Close()
+		return nil, fmt.Errorf("ha: %w", err)
+	}
+
+	return &Node{
+		cfg:       cfg,
+		Events:    es,
+		Capture:   capture,
+		Apply:     apply,
+		role:      RoleStandby,
+		lastHeard: time.Now(),
+		peers:     make(map[string]*PeerStatus),
+		udp:       udp,
+		tcp:       tcp,
+	}, nil
+}
+
+// Addr returns the address peers should use to reach this node.
+func (n *Node) Addr() string {
+	return n.udp.LocalAddr().String()
+}
+
+// IsLeader reports whether this node currently holds the active role.
+func (n *Node) IsLeader() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.role == RoleLeader
+}
+
+// Status returns the node's role, term and view of its peers.
+func (n *Node) Status() Status {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	status := Status{
+		Node:       n.cfg.NodeID,
+		Role:       n.role,
+		Term:       n.term,
+		Leader:     n.leader,
+		Replicated: n.replicas,
+		Peers:      make([]PeerStatus, 0, len(n.peers)),
+	}
+	for _, peer := range n.peers {
+		status.Peers = append(status.Peers, *peer)
+	}
+	sort.Slice(status.Peers, func(i, j int) bool { return status.Peers[i].ID < status.Peers[j].ID })
+	return status
+}
+
+// Run heartbeats, watches for leader failure and replicates state until ctx
+// is cancelled, then closes the sockets.
+func (n *Node) Run(ctx context.Context) {
+	go n.receiveHeartbeats()
+	go n.receiveState()
+
+	heartbeat := time.NewTicker(n.cfg.HeartbeatInterval)
+	defer heartbeat.Stop()
+	replicate := time.NewTicker(n.cfg.ReplicateInterval)
+	defer replicate.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			n.udp.Close()
+			n.tcp.Close()
+			return
+		case <-heartbeat.C:
+			n.checkLeader()
+			n.sendHeartbeat(false)
+		case <-replicate.C:
+			if n.IsLeader() {
+				n.replicate()
+			}
+		}
+	}
+}
+
+// Resign gives up the leader role ahead of shutdown and tells the peers, so
+// a standby takes over without waiting for the failover timeout. The node
+// never stands for election again.
+func (n *Node) Resign() {
+	// Push the final state while this node can still vouch for it as leader.
+	if n.IsLeader() {
+		n.replicate()
+	}
+
+	n.mu.Lock()
+	n.resigned = true
+	wasLeader := n.role == RoleLeader
+	n.role = RoleStandby
+	if wasLeader {
+		n.leader = ""
+	}
+	n.mu.Unlock()
+
+	if wasLeader {
+		n.publish(events.WarningEvent, fmt.Sprintf("HA: node %s resigned leadership", n.cfg.NodeID))
+	}
+	n.sendHeartbeat(true)
+}
+
+// checkLeader promotes this node when the leader has gone quiet.
+func (n *Node) checkLeader() {
+	n.mu.Lock()
+	if n.role == RoleLeader || n.resigned || time.Since(n.lastHeard) < n.cfg.FailoverTimeout {
+		n.mu.Unlock()
+		return
+	}
+	previous := n.leader
+	n.term++
+	n.role = RoleLeader
+	n.leader = n.cfg.NodeID
+	term := n.term
+	n.mu.Unlock()
+
+	if previous == "" {
+		n.publish(events.SuccessEvent, fmt.Sprintf("HA: node %s became leader (term %d)", n.cfg.NodeID, term))
+	} else {
+		n.publish(events.SuccessEvent, fmt.Sprintf("HA: node %s took over from %s (term %d)", n.cfg.NodeID, previous, term))
+	}
+	n.sendHeartbeat(false)
+	n.replicate()
+}
+
+// observe applies what a peer said about its role and term. from is the
+// peer's heartbeat address, or empty when the message arrived over TCP.
+func (n *Node) observe(msg message, from string) {
+	n.mu.Lock()
+
+	peer := n.peers[msg.Node]
+	if peer == nil {
+		peer = &PeerStatus{ID: msg.Node}
+		n.peers[msg.Node] = peer
+	}
+	if from != "" {
+		peer.Addr = from
+	}
+	peer.Role, peer.Term, peer.LastSeen = msg.Role, msg.Term, time.Now()
+	from = peer.Addr
+
+	if msg.Term < n.term {
+		// A stale leader: answer so it learns the newer term and fences itself.
+		stale := msg.Role == RoleLeader
+		n.mu.Unlock()
+		if stale && from != "" {
+			n.sendTo(from, false)
+		}
+		return
+	}
+
+	wasLeader := n.role == RoleLeader
+	if msg.Term > n.term {
+		n.term = msg.Term
+		if wasLeader {
+			n.role = RoleStandby
+			n.leader = ""
+		}
+	}
+
+	switch {
+	case msg.Leaving && msg.Node == n.leader:
+		// The leader resigned: take over on the next heartbeat tick.
+		n.leader = ""
+		n.lastHeard = time.Time{}
+	case msg.Role == RoleLeader && n.role == RoleLeader:
+		// Two leaders in one term after a simultaneous election.
+		if n.outranks(msg) {
+			n.mu.Unlock()
+			if from != "" {
+				n.sendTo(from, false)
+			}
+			return
+		}
+		n.role = RoleStandby
+		n.leader = msg.Node
+		n.lastHeard = time.Now()
+	case msg.Role == RoleLeader:
+		n.leader = msg.Node
+		n.lastHeard = time.Now()
+	}
+
+	steppedDown := wasLeader && n.role != RoleLeader
+	leader, term := n.leader, n.term
+	n.mu.Unlock()
+
+	if steppedDown {
+		n.publish(events.WarningEvent, fmt.Sprintf("HA: node %s stepped down to standby; %s leads term %d",
+			n.cfg.NodeID, orUnknown(leader), term))
+	}
+}
+
+// outranks breaks a tie between two leaders of the same term.
+func (n *Node) outranks(msg message) bool {
+	if n.cfg.Priority != msg.Priority {
+		return n.cfg.Priority > msg.Priority
+	}
+	return n.cfg.NodeID > msg.Node
+}
+
+// applyState installs replicated state if it comes from the current leader.
+func (n *Node) applyState(msg stateMessage) {
+	n.observe(msg.message, "")
+
+	n.mu.Lock()
+	// A leader that just resigned still owns its term's state.
+	accept := n.role == RoleStandby && msg.Role == RoleLeader && msg.Term == n.term &&
+		(msg.Node == n.leader || n.leader == "")
+	if accept {
+		n.replicas++
+	}
+	n.mu.Unlock()
+
+	if accept && n.Apply != nil && msg.State != nil {
+		n.Apply(msg.State)
+	}
+}
+
+func (n *Node) publish(eventType events.EventType, message string) {
+	log.Print(message)
+	if n.Events != nil {
+		n.Events.Emit(events.Event{Type: eventType, Source: "ha", Message: message})
+	}
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "(unknown)"
+	}
+	return s
+}
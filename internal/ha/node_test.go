@@ -0,0 +1,130 @@
+package ha
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/handoff"
+)
+
+func newTestNode(t *testing.T, id string, priority int, applied *atomic.Int64) *Node {
+	t.Helper()
+	capture := func() *handoff.State {
+		return &handoff.State{Pools: map[string]handoff.PoolState{
+			"web": {Sessions: map[string]string{"alice": "web-1"}},
+		}}
+	}
+	apply := func(state *handoff.State) {
+		if state.Pools["web"].Sessions["alice"] == "web-1" {
+			applied.Add(1)
+		}
+	}
+	node, err := NewNode(config.HAConfig{
+		NodeID:            id,
+		Bind:              "127.0.0.1:0",
+		Priority:          priority,
+		HeartbeatInterval: 20 * time.Millisecond,
+		FailoverTimeout:   150 * time.Millisecond,
+		ReplicateInterval: 30 * time.Millisecond,
+		Secret:            "test-secret",
+	}, nil, capture, apply)
+	if err != nil {
+		t.Fatalf("new node %s: %v", id, err)
+	}
+	return node
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNode_ElectsOneLeaderAndFailsOver(t *testing.T) {
+	var appliedA, appliedB atomic.Int64
+	a := newTestNode(t, "a", 100, &appliedA)
+	b := newTestNode(t, "b", 50, &appliedB)
+	a.cfg.Peers = []string{b.Addr()}
+	b.cfg.Peers = []string{a.Addr()}
+
+	ctxA, stopA := context.WithCancel(context.Background())
+	ctxB, stopB := context.WithCancel(context.Background())
+	defer stopA()
+	defer stopB()
+	go a.Run(ctxA)
+	go b.Run(ctxB)
+
+	// Both start at once; the tie is broken by priority.
+	eventually(t, "a single leader", func() bool { return a.IsLeader() && !b.IsLeader() })
+	eventually(t, "state replicated to the standby", func() bool { return appliedB.Load() > 0 })
+	if appliedA.Load() != 0 {
+		t.Errorf("leader applied replicated state")
+	}
+
+	term := a.Status().Term
+	a.Resign()
+	stopA()
+
+	eventually(t, "the standby to take over", b.IsLeader)
+	if got := b.Status().Term; got <= term {
+		t.Errorf("new leader term %d, want greater than %d", got, term)
+	}
+}
+
+func TestNode_StaleLeaderStepsDown(t *testing.T) {
+	var applied atomic.Int64
+	n := newTestNode(t, "a", 100, &applied)
+	defer n.udp.Close()
+	defer n.tcp.Close()
+
+	n.role, n.term, n.leader = RoleLeader, 3, "a"
+	n.observe(message{Node: "b", Term: 4, Role: RoleLeader, Priority: 1}, "")
+
+	status := n.Status()
+	if status.Role != RoleStandby || status.Term != 4 || status.Leader != "b" {
+		t.Fatalf("after hearing a higher term: %+v", status)
+	}
+
+	// State from the fenced-off term is ignored.
+	n.applyState(stateMessage{message: message{Node: "c", Term: 3, Role: RoleLeader}, State: &handoff.State{}})
+	if n.Status().Replicated != 0 {
+		t.Errorf("applied state from a stale term")
+	}
+}
+
+func TestNode_RejectsUnsignedAndForgedMessages(t *testing.T) {
+	var applied atomic.Int64
+	n := newTestNode(t, "a", 100, &applied)
+	defer n.udp.Close()
+	defer n.tcp.Close()
+
+	claim := message{Node: "b", Term: 9, Role: RoleLeader, Sent: time.Now().UnixMilli()}
+	unsigned, _ := json.Marshal(claim)
+	forger := &Node{cfg: config.HAConfig{Secret: "guess"}}
+	forged, _ := forger.seal(claim)
+	stale := claim
+	stale.Sent = time.Now().Add(-time.Hour).UnixMilli()
+	replayed, _ := n.seal(stale)
+
+	for name, data := range map[string][]byte{"unsigned": unsigned, "forged": forged, "replayed": replayed} {
+		var msg message
+		if n.open(data, &msg) && n.accepts(msg) {
+			t.Errorf("accepted a %s message", name)
+		}
+	}
+
+	signed, _ := n.seal(claim)
+	var msg message
+	if !n.open(signed, &msg) || !n.accepts(msg) || msg.Term != 9 {
+		t.Errorf("rejected a signed message: %+v", msg)
+	}
+}
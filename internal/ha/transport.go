@@ -0,0 +1,182 @@
+// internal/ha/transport.go
+package ha
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"log"
+	"net"
+	"time"
+
+	"load-balancer/internal/handoff"
+)
+
+// Timeouts for one replication push.
+const (
+	replicateDialTimeout = time.Second
+	replicateIOTimeout   = 5 * time.Second
+)
+
+// maxClockSkew bounds how far a message's send time may be from ours, so a
+// captured message cannot be replayed later.
+const maxClockSkew = 30 * time.Second
+
+// message is a heartbeat, sent as a single UDP datagram.
+type message struct {
+	Node     string `json:"node"`
+	Term     uint64 `json:"term"`
+	Role     Role   `json:"role"`
+	Priority int    `json:"priority"`
+	Leaving  bool   `json:"leaving,omitempty"`
+	Sent     int64  `json:"sent"` // unix milliseconds
+}
+
+// signed is the wire form of every message: the encoded message and its
+// HMAC-SHA256 under the shared secret.
+type signed struct {
+	Body json.RawMessage `json:"body"`
+	MAC  []byte          `json:"mac"`
+}
+
+func (n *Node) mac(body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(n.cfg.Secret))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// seal encodes and signs a message.
+func (n *Node) seal(v interface{}) ([]byte, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(signed{Body: body, MAC: n.mac(body)})
+}
+
+// open checks a sealed message's signature and decodes it into v. It
+// reports false for unsigned, forged or malformed messages.
+func (n *Node) open(data []byte, v interface{}) bool {
+	var s signed
+	if err := json.Unmarshal(data, &s); err != nil || len(s.Body) == 0 {
+		return false
+	}
+	if !hmac.Equal(s.MAC, n.mac(s.Body)) {
+		return false
+	}
+	return json.Unmarshal(s.Body, v) == nil
+}
+
+// accepts reports whether a verified message is from a peer and recent.
+func (n *Node) accepts(msg message) bool {
+	skew := time.Since(time.UnixMilli(msg.Sent))
+	return msg.Node != "" && msg.Node != n.cfg.NodeID && skew < maxClockSkew && skew > -maxClockSkew
+}
+
+// stateMessage carries replicated state over TCP; it is too large for UDP.
+type stateMessage struct {
+	message
+	State *handoff.State `json:"state"`
+}
+
+func (n *Node) heartbeat(leaving bool) message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return message{Node: n.cfg.NodeID, Term: n.term, Role: n.role, Priority: n.cfg.Priority, Leaving: leaving,
+		Sent: time.Now().UnixMilli()}
+}
+
+// sendHeartbeat announces this node to every configured peer.
+func (n *Node) sendHeartbeat(leaving bool) {
+	data, err := n.seal(n.heartbeat(leaving))
+	if err != nil {
+		return
+	}
+	for _, peer := range n.cfg.Peers {
+		n.write(peer, data)
+	}
+}
+
+// sendTo answers a single peer, e.g. to fence a stale leader.
+func (n *Node) sendTo(addr string, leaving bool) {
+	data, err := n.seal(n.heartbeat(leaving))
+	if err != nil {
+		return
+	}
+	n.write(addr, data)
+}
+
+func (n *Node) write(addr string, data []byte) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		log.Printf("HA: invalid peer address %s: %v", addr, err)
+		return
+	}
+	// Unreachable peers are expected during failover; the election handles it.
+	n.udp.WriteToUDP(data, udpAddr)
+}
+
+func (n *Node) receiveHeartbeats() {
+	buf := make([]byte, 64*1024)
+	for {
+		size, from, err := n.udp.ReadFromUDP(buf)
+		if err != nil {
+			return // socket closed
+		}
+		var msg message
+		if !n.open(buf[:size], &msg) || !n.accepts(msg) {
+			continue
+		}
+		n.observe(msg, from.String())
+	}
+}
+
+// replicate pushes the current state to every peer.
+func (n *Node) replicate() {
+	if n.Capture == nil {
+		return
+	}
+	msg := stateMessage{message: n.heartbeat(false), State: n.Capture()}
+	data, err := n.seal(msg)
+	if err != nil {
+		log.Printf("HA: unable to encode state: %v", err)
+		return
+	}
+	for _, peer := range n.cfg.Peers {
+		go push(peer, data)
+	}
+}
+
+func push(addr string, data []byte) {
+	conn, err := net.DialTimeout("tcp", addr, replicateDialTimeout)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetWriteDeadline(time.Now().Add(replicateIOTimeout))
+	conn.Write(data)
+}
+
+func (n *Node) receiveState() {
+	for {
+		conn, err := n.tcp.Accept()
+		if err != nil {
+			return // listener closed
+		}
+		go func() {
+			defer conn.Close()
+			conn.SetReadDeadline(time.Now().Add(replicateIOTimeout))
+
+			var raw json.RawMessage
+			if err := json.NewDecoder(conn).Decode(&raw); err != nil {
+				return
+			}
+			var msg stateMessage
+			if !n.open(raw, &msg) || !n.accepts(msg.message) {
+				log.Printf("HA: rejected unsigned or stale state from %s", conn.RemoteAddr())
+				return
+			}
+			n.applyState(msg)
+		}()
+	}
+}
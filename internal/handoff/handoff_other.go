@@ -0,0 +1,45 @@
+//go:build !unix
+
+// internal/handoff/handoff_other.go
+package handoff
+
+import (
+	"errors"
+	"net"
+	"os"
+	"time"
+)
+
+// Supported reports whether listener handoff works on this platform.
+const Supported = false
+
+var errUnsupported = errors.New("listener handoff is not supported on this platform")
+
+// Notify is a no-op: there is no upgrade signal on this platform.
+func Notify(ch chan<- os.Signal) {}
+
+// IsUpgradeSignal always reports false on this platform.
+func IsUpgradeSignal(sig os.Signal) bool { return false }
+
+// Upgrade is not supported on this platform.
+func Upgrade(socketPath string, listeners map[string]net.Listener, state *State, readyTimeout time.Duration) (*os.Process, error) {
+	return nil, errUnsupported
+}
+
+// Inherited holds what a new process received from its predecessor.
+type Inherited struct {
+	Listeners map[string]net.Listener
+	State     *State
+}
+
+// Receive always reports a normal start on this platform.
+func Receive() (*Inherited, error) { return nil, nil }
+
+// Listener returns the inherited listener with the given name, or nil.
+func (in *Inherited) Listener(name string) net.Listener { return nil }
+
+// Ready is a no-op on this platform.
+func (in *Inherited) Ready() error { return nil }
+
+// Fail is a no-op on this platform.
+func (in *Inherited) Fail(reason error) {}
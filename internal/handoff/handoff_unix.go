@@ -0,0 +1,219 @@
+//go:build unix
+
+// internal/handoff/handoff_unix.go
+package handoff
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// EnvSocket tells a freshly started process where to collect its listeners.
+const EnvSocket = "LB_HANDOFF_SOCKET"
+
+// Supported reports whether listener handoff works on this platform.
+const Supported = true
+
+// hello is sent after the file descriptors; Listeners names them in order.
+type hello struct {
+	Listeners []string `json:"listeners"`
+	State     *State   `json:"state"`
+}
+
+// Notify relays upgrade requests (SIGUSR2) to ch.
+func Notify(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGUSR2)
+}
+
+// IsUpgradeSignal reports whether sig requests an upgrade.
+func IsUpgradeSignal(sig os.Signal) bool {
+	return sig == syscall.SIGUSR2
+}
+
+// Upgrade starts a new copy of the current executable and passes it the
+// listening sockets and a state snapshot over a unix socket at socketPath.
+// It returns once the new process reports ready; on failure the new process
+// is killed and the caller keeps serving.
+func Upgrade(socketPath string, listeners map[string]net.Listener, state *State, readyTimeout time.Duration) (*os.Process, error) {
+	names := make([]string, 0, len(listeners))
+	for name := range listeners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	files := make([]*os.File, 0, len(names))
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	fds := make([]int, 0, len(names))
+	for _, name := range names {
+		tcp, ok := listeners[name].(*net.TCPListener)
+		if !ok {
+			return nil, fmt.Errorf("listener %s is not a TCP listener", name)
+		}
+		f, err := tcp.File()
+		if err != nil {
+			return nil, fmt.Errorf("duplicating listener %s: %w", name, err)
+		}
+		files = append(files, f)
+		fds = append(fds, int(f.Fd()))
+	}
+
+	os.Remove(socketPath)
+	sock, err := net.ListenUnix("unix", &net.UnixAddr{Name: socketPath, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("listening on handoff socket: %w", err)
+	}
+	defer os.Remove(socketPath)
+	defer sock.Close()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), EnvSocket+"="+socketPath)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting new process: %w", err)
+	}
+	go cmd.Wait() // reap the child if it exits while we are still around
+
+	fail := func(err error) (*os.Process, error) {
+		cmd.Process.Kill()
+		return nil, err
+	}
+
+	deadline := time.Now().Add(readyTimeout)
+	sock.SetDeadline(deadline)
+	conn, err := sock.AcceptUnix()
+	if err != nil {
+		return fail(fmt.Errorf("waiting for new process: %w", err))
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+
+	// The descriptors ride on a single marker byte, followed by the JSON hello.
+	if _, _, err := conn.WriteMsgUnix([]byte{'F'}, syscall.UnixRights(fds...), nil); err != nil {
+		return fail(fmt.Errorf("sending listeners: %w", err))
+	}
+	if err := json.NewEncoder(conn).Encode(hello{Listeners: names, State: state}); err != nil {
+		return fail(fmt.Errorf("sending state: %w", err))
+	}
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fail(fmt.Errorf("new process did not report ready: %w", err))
+	}
+	if strings.TrimSpace(line) != "ready" {
+		return fail(fmt.Errorf("new process failed: %s", strings.TrimSpace(line)))
+	}
+	return cmd.Process, nil
+}
+
+// Inherited holds what a new process received from its predecessor.
+type Inherited struct {
+	Listeners map[string]net.Listener
+	State     *State
+	conn      *net.UnixConn
+}
+
+// Receive collects listeners and state when this process was started by
+// Upgrade. It returns nil if the process was started normally.
+func Receive() (*Inherited, error) {
+	path := os.Getenv(EnvSocket)
+	if path == "" {
+		return nil, nil
+	}
+	os.Unsetenv(EnvSocket) // later upgrades start from a clean environment
+
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("connecting to handoff socket: %w", err)
+	}
+
+	marker := make([]byte, 1)
+	oob := make([]byte, syscall.CmsgSpace(64*4))
+	_, oobn, _, _, err := conn.ReadMsgUnix(marker, oob)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("receiving listeners: %w", err)
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	var fds []int
+	for _, msg := range msgs {
+		rights, err := syscall.ParseUnixRights(&msg)
+		if err != nil {
+			continue
+		}
+		fds = append(fds, rights...)
+	}
+
+	var h hello
+	if err := json.NewDecoder(conn).Decode(&h); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("receiving state: %w", err)
+	}
+	if len(h.Listeners) != len(fds) {
+		conn.Close()
+		return nil, fmt.Errorf("expected %d listeners, received %d", len(h.Listeners), len(fds))
+	}
+
+	in := &Inherited{Listeners: make(map[string]net.Listener), State: h.State, conn: conn}
+	for i, name := range h.Listeners {
+		f := os.NewFile(uintptr(fds[i]), name)
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			in.Fail(err)
+			return nil, fmt.Errorf("restoring listener %s: %w", name, err)
+		}
+		in.Listeners[name] = l
+	}
+	return in, nil
+}
+
+// Listener returns the inherited listener with the given name, or nil.
+func (in *Inherited) Listener(name string) net.Listener {
+	if in == nil {
+		return nil
+	}
+	return in.Listeners[name]
+}
+
+// Ready tells the old process it may start draining.
+func (in *Inherited) Ready() error {
+	if in == nil || in.conn == nil {
+		return nil
+	}
+	defer in.conn.Close()
+	_, err := in.conn.Write([]byte("ready\n"))
+	in.conn = nil
+	return err
+}
+
+// Fail tells the old process to keep serving.
+func (in *Inherited) Fail(reason error) {
+	if in == nil || in.conn == nil {
+		return
+	}
+	fmt.Fprintf(in.conn, "error: %v\n", reason)
+	in.conn.Close()
+	in.conn = nil
+}
@@ -0,0 +1,93 @@
+// internal/handoff/state.go
+package handoff
+
+import (
+	"time"
+
+	"load-balancer/internal/router"
+	"load-balancer/internal/server"
+)
+
+// State is the in-memory balancer state carried across an upgrade.
+type State struct {
+	Taken time.Time            `json:"taken"`
+	Pools map[string]PoolState `json:"pools"`
+}
+
+// PoolState holds one pool's sticky sessions and per-server state.
+type PoolState struct {
+	Sessions map[string]string      `json:"sessions"` // session ID -> server ID
+	Servers  map[string]ServerState `json:"servers"`
+}
+
+// ServerState is the runtime state of one server that is not derived from
+// configuration.
+type ServerState struct {
+	CircuitBreakerState server.CBState    `json:"circuitBreakerState"`
+	FailureCount        int               `json:"failureCount"`
+	TrialSuccessCount   int               `json:"trialSuccessCount"`
+	OpenSince           time.Time         `json:"openSince"`
+	AdminState          server.AdminState `json:"adminState"`
+	SlowStartSince      time.Time         `json:"slowStartSince"`
+}
+
+// Capture snapshots sticky sessions and breaker state for every pool.
+func Capture(table *router.Table) *State {
+	state := &State{Taken: time.Now(), Pools: make(map[string]PoolState)}
+	for _, pool := range table.Pools() {
+		ps := PoolState{
+			Sessions: pool.Balancer.StickySessionMgr.Sessions(),
+			Servers:  make(map[string]ServerState),
+		}
+		for _, srv := range pool.Manager.GetAllServers() {
+			breaker := server.GetBreaker(srv)
+			ps.Servers[srv.ID] = ServerState{
+				CircuitBreakerState: breaker.State,
+				FailureCount:        breaker.Failures,
+				TrialSuccessCount:   breaker.Trials,
+				OpenSince:           breaker.OpenSince,
+				AdminState:          server.GetAdminState(srv),
+				SlowStartSince:      server.GetSlowStartSince(srv),
+			}
+		}
+		state.Pools[pool.Name] = ps
+	}
+	return state
+}
+
+// Restore applies a snapshot to pools and servers that still exist. Breaker
+// state goes through the pool's coordinator, so it is safe while the pool
+// serves. It returns the number of sessions and servers restored.
+func Restore(table *router.Table, state *State) (sessions, servers int) {
+	if state == nil {
+		return 0, 0
+	}
+	for name, ps := range state.Pools {
+		pool := table.Pool(name)
+		if pool == nil {
+			continue
+		}
+		for _, srv := range pool.Manager.GetAllServers() {
+			saved, ok := ps.Servers[srv.ID]
+			if !ok {
+				continue
+			}
+			pool.Breaker.Restore(srv, server.Breaker{
+				State:     saved.CircuitBreakerState,
+				Failures:  saved.FailureCount,
+				Trials:    saved.TrialSuccessCount,
+				OpenSince: saved.OpenSince,
+			})
+			server.Update(srv, func(s *server.Server) { s.SlowStartSince = saved.SlowStartSince })
+			// In-flight requests of a draining server stay with the old
+			// process, so there is nothing left to wait for here.
+			if saved.AdminState == server.AdminDraining {
+				saved.AdminState = server.AdminMaintenance
+			}
+			server.SetAdminState(srv, saved.AdminState)
+			servers++
+		}
+		sessions += pool.Balancer.StickySessionMgr.RestoreSessions(ps.Sessions)
+	}
+	return sessions, servers
+}
@@ -0,0 +1,74 @@
+package handoff
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/router"
+	"load-balancer/internal/server"
+)
+
+func newTable(t *testing.T) *router.Table {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	table := router.NewTable(ctx, &config.Config{
+		HealthCheckInterval: time.Hour,
+		UseStickySessions:   true,
+		CircuitBreaker:      config.CircuitBreakerConfig{FailureThreshold: 3, CooldownPeriod: time.Second, TrialRequests: 1},
+	})
+	_, err := table.AddPoolConfig(config.PoolConfig{
+		Name: "web",
+		Servers: []config.ServerConfig{
+			{ID: "web-1", Address: "localhost", Port: 1},
+			{ID: "web-2", Address: "localhost", Port: 2},
+		},
+	})
+	if err != nil {
+		t.Fatalf("add pool: %v", err)
+	}
+	return table
+}
+
+func TestState_RoundTrip(t *testing.T) {
+	old := newTable(t)
+	pool := old.Pool("web")
+	servers := pool.Manager.GetAllServers()
+	pool.Balancer.StickySessionMgr.BindSessionToServer("alice", servers[1])
+	server.Update(servers[0], func(s *server.Server) {
+		s.CircuitBreakerState = server.CBStateOpen
+		s.FailureCount = 3
+		s.OpenSince = time.Now()
+	})
+	server.SetAdminState(servers[1], server.AdminDraining)
+
+	data, err := json.Marshal(Capture(old))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	fresh := newTable(t)
+	sessions, restored := Restore(fresh, &state)
+	if sessions != 1 || restored != 2 {
+		t.Fatalf("restored %d sessions and %d servers, want 1 and 2", sessions, restored)
+	}
+
+	got := fresh.Pool("web").Manager.GetAllServers()
+	if breaker := server.GetBreaker(got[0]); breaker.State != server.CBStateOpen || breaker.Failures != 3 {
+		t.Errorf("breaker state not restored: %v/%d", breaker.State, breaker.Failures)
+	}
+	if state := server.GetAdminState(got[1]); state != server.AdminMaintenance {
+		t.Errorf("draining server restored as %s, want maintenance", state)
+	}
+	if bound := fresh.Pool("web").Balancer.StickySessionMgr.Sessions()["alice"]; bound != "web-2" {
+		t.Errorf("session bound to %q, want web-2", bound)
+	}
+}
@@ -0,0 +1,312 @@
+// internal/harness/harness.go
+package harness
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"load-balancer/internal/app"
+	"load-balancer/internal/config"
+	"load-balancer/internal/server"
+	"load-balancer/internal/testserver"
+)
+
+// Options shape the balancer and backends a harness starts.
+type Options struct {
+	// Backends is the number of test servers; default 3
+	Backends int
+
+	// Backend adjusts each test server's config before it starts
+	Backend func(i int, cfg *testserver.ServerConfig)
+
+	// Configure adjusts the balancer config before it is wired
+	Configure func(cfg *config.Config)
+}
+
+// Harness is a fully wired balancer and its test servers, all on ephemeral
+// ports, torn down when the test ends.
+type Harness struct {
+	T        testing.TB
+	App      *app.App
+	URL      string // base URL of the balancer, e.g. http://127.0.0.1:53211
+	Backends []*testserver.TestServer
+	Client   *http.Client
+
+	server *http.Server
+}
+
+// Start boots the backends and a balancer in front of them. The defaults
+// suit fast tests: real /health probes every 50ms, a breaker that trips after
+// 3 failures and cools down for 500ms, no slow start, no snapshots.
+func Start(t testing.TB, opts Options) *Harness {
+	t.Helper()
+	if opts.Backends <= 0 {
+		opts.Backends = 3
+	}
+
+	h := &Harness{T: t, Client: &http.Client{Timeout: 5 * time.Second}}
+
+	var servers []config.ServerConfig
+	for i := 0; i < opts.Backends; i++ {
+		l := listen(t)
+		tsCfg := testserver.ServerConfig{
+			ID:   fmt.Sprintf("server-%d", i+1),
+			Port: l.Addr().(*net.TCPAddr).Port,
+		}
+		tsCfg.Latency.Min, tsCfg.Latency.Max = 1, 5
+		if opts.Backend != nil {
+			opts.Backend(i, &tsCfg)
+		}
+		ts := testserver.NewTestServer(tsCfg)
+		go ts.Serve(l)
+		t.Cleanup(func() { ts.Stop() })
+
+		h.Backends = append(h.Backends, ts)
+		servers = append(servers, config.ServerConfig{ID: tsCfg.ID, Address: "127.0.0.1", Port: tsCfg.Port})
+	}
+
+	l := listen(t)
+	cfg := &config.Config{
+		LBPort:              l.Addr().(*net.TCPAddr).Port,
+		Servers:             servers,
+		HealthCheckInterval: 50 * time.Millisecond,
+		UseStickySessions:   true,
+		CircuitBreaker: config.CircuitBreakerConfig{
+			FailureThreshold: 3,
+			CooldownPeriod:   500 * time.Millisecond,
+			TrialRequests:    1,
+		},
+		Upstream: config.UpstreamConfig{
+			MaxIdleConns:          16,
+			MaxConns:              64,
+			IdleConnTimeout:       30 * time.Second,
+			KeepAlive:             30 * time.Second,
+			DialTimeout:           time.Second,
+			TLSHandshakeTimeout:   time.Second,
+			ResponseHeaderTimeout: 2 * time.Second,
+			RequestTimeout:        2 * time.Second,
+		},
+		TrustedProxies:     []string{"127.0.0.1/32", "::1/128"},
+		SlowStart:          config.SlowStartConfig{Curve: "linear"},
+		DrainTimeout:       5 * time.Second,
+		MirrorMaxInFlight:  16,
+		SyntheticProbePath: "/lb/probe",
+	}
+	cfg.UseDefaultRouting()
+	cfg.Pools[0].HealthCheckPath = "/health"
+	if opts.Configure != nil {
+		opts.Configure(cfg)
+	}
+
+	a, err := app.New(cfg, nil)
+	if err != nil {
+		l.Close()
+		t.Fatalf("harness: wiring balancer: %v", err)
+	}
+	h.App = a
+	h.URL = "http://" + l.Addr().String()
+	h.server = &http.Server{Handler: a.Handler}
+	go h.server.Serve(l)
+	a.Lifecycle.MarkReady()
+
+	t.Cleanup(func() {
+		h.server.Close()
+		a.Close()
+	})
+	return h
+}
+
+func listen(t testing.TB) net.Listener {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("harness: listen: %v", err)
+	}
+	return l
+}
+
+// Backend returns the test server with the given ID.
+func (h *Harness) Backend(id string) *testserver.TestServer {
+	for _, ts := range h.Backends {
+		if ts.Config.ID == id {
+			return ts
+		}
+	}
+	h.T.Fatalf("harness: no backend %s", id)
+	return nil
+}
+
+// Server returns the balancer's view of a backend.
+func (h *Harness) Server(id string) *server.Server {
+	if pool := h.App.Routes.FindServer(id); pool != nil {
+		for _, srv := range pool.Manager.GetAllServers() {
+			if srv.ID == id {
+				return srv
+			}
+		}
+	}
+	h.T.Fatalf("harness: balancer has no server %s", id)
+	return nil
+}
+
+// Response is the outcome of one request through the balancer.
+type Response struct {
+	Status int
+	Server string // from X-Served-By; empty when no backend answered
+	Header http.Header
+	Body   []byte
+	Err    error
+}
+
+// RequestOption adjusts a request before it is sent.
+type RequestOption func(*http.Request)
+
+// WithHeader sets a request header.
+func WithHeader(key, value string) RequestOption {
+	return func(r *http.Request) { r.Header.Set(key, value) }
+}
+
+// WithSession sends the session_id cookie sticky sessions key on.
+func WithSession(id string) RequestOption {
+	return func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "session_id", Value: id}) }
+}
+
+// WithPriority sets X-Task-Priority.
+func WithPriority(priority string) RequestOption {
+	return WithHeader("X-Task-Priority", priority)
+}
+
+// Get sends one request to path (e.g. "/lb/hello") through the balancer.
+func (h *Harness) Get(path string, opts ...RequestOption) Response {
+	req, err := http.NewRequest(http.MethodGet, h.URL+path, nil)
+	if err != nil {
+		return Response{Err: err}
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return Response{Err: err}
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return Response{
+		Status: resp.StatusCode,
+		Server: resp.Header.Get("X-Served-By"),
+		Header: resp.Header,
+		Body:   body,
+		Err:    err,
+	}
+}
+
+// Send issues n sequential requests and tallies where they went.
+func (h *Harness) Send(n int, path string, opts ...RequestOption) Distribution {
+	d := Distribution{Servers: make(map[string]int)}
+	for i := 0; i < n; i++ {
+		resp := h.Get(path, opts...)
+		d.Total++
+		switch {
+		case resp.Err != nil || resp.Status >= 400:
+			d.Errors++
+		default:
+			d.Servers[resp.Server]++
+		}
+	}
+	return d
+}
+
+// WaitFor polls cond until it holds, failing the test after timeout.
+func (h *Harness) WaitFor(what string, timeout time.Duration, cond func() bool) {
+	h.T.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			h.T.Fatalf("harness: timed out after %v waiting for %s", timeout, what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// WaitForBreaker waits for a server's circuit breaker to reach state.
+func (h *Harness) WaitForBreaker(id string, state server.CBState, timeout time.Duration) {
+	h.T.Helper()
+	srv := h.Server(id)
+	h.WaitFor(fmt.Sprintf("%s breaker to be %s", id, breakerName(state)), timeout, func() bool {
+		return server.GetBreakerState(srv) == state
+	})
+}
+
+// WaitForHealth waits for the health checker to mark a server up or down.
+func (h *Harness) WaitForHealth(id string, up bool, timeout time.Duration) {
+	h.T.Helper()
+	srv := h.Server(id)
+	want := "down"
+	if up {
+		want = "up"
+	}
+	h.WaitFor(fmt.Sprintf("%s to be %s", id, want), timeout, func() bool {
+		return server.IsUp(srv) == up
+	})
+}
+
+func breakerName(state server.CBState) string {
+	switch state {
+	case server.CBStateOpen:
+		return "open"
+	case server.CBStateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Distribution tallies which backends answered a batch of requests.
+type Distribution struct {
+	Total   int
+	Errors  int
+	Servers map[string]int
+}
+
+// Share is the fraction of successful requests a server answered.
+func (d Distribution) Share(id string) float64 {
+	ok := d.Total - d.Errors
+	if ok == 0 {
+		return 0
+	}
+	return float64(d.Servers[id]) / float64(ok)
+}
+
+// AssertNoErrors fails the test if any request failed.
+func (d Distribution) AssertNoErrors(t testing.TB) {
+	t.Helper()
+	if d.Errors > 0 {
+		t.Errorf("%d of %d requests failed (served: %v)", d.Errors, d.Total, d.Servers)
+	}
+}
+
+// AssertServed fails the test unless every listed server answered at least
+// min requests.
+func (d Distribution) AssertServed(t testing.TB, min int, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if d.Servers[id] < min {
+			t.Errorf("%s served %d requests, want at least %d (distribution %v)", id, d.Servers[id], min, d.Servers)
+		}
+	}
+}
+
+// AssertNotServed fails the test if any listed server answered a request.
+func (d Distribution) AssertNotServed(t testing.TB, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if d.Servers[id] > 0 {
+			t.Errorf("%s served %d requests, want none (distribution %v)", id, d.Servers[id], d.Servers)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package health
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"time"
 
 	"load-balancer/internal/server"
@@ -13,6 +15,16 @@ type Checker struct {
 	Interval      time.Duration
 	ServerManager *server.Manager
 	doneCh        chan bool
+
+	// ProbePath, when set, makes the checker issue a real GET to each server
+	// and derive PingStatus and ResponseTime from it instead of simulating them.
+	ProbePath string
+	client    *http.Client
+
+	// probedUp holds each server's last probe result. Simulated metrics
+	// overwrite PingStatus every cycle, so it cannot tell a recovery apart.
+	// Only checkServers uses it.
+	probedUp map[string]bool
 }
 
 // NewChecker creates a new health checker.
@@ -21,6 +33,8 @@ func NewChecker(interval time.Duration, mgr *server.Manager) *Checker {
 		Interval:      interval,
 		ServerManager: mgr,
 		doneCh:        make(chan bool),
+		client:        &http.Client{Timeout: 2 * time.Second},
+		probedUp:      make(map[string]bool),
 	}
 }
 
@@ -62,40 +76,87 @@ func (hc *Checker) checkServers() {
 	epsilon := 0.05 // Ping status importance
 
 	// 1) Fetch updated metrics and calculate health scores
+	if hc.ProbePath != "" {
+		hc.forgetRemoved(servers)
+	}
 	for _, srv := range servers {
 		server.FetchMetrics(srv) // Fetch all metrics at once
+		if hc.ProbePath != "" {
+			hc.probe(srv)
+		}
 
 		// Calculate health score:
 		// H = α(1 - CPU) + β(1 - MEM) + γ(1 - Resp) + δ(1 - Error) + ε*Ping
 		// Assumes CPU, MEM, Resp, Error are normalized in [0..1]
-		H := alpha*(1-srv.CPUUsage) +
-			beta*(1-srv.MemUsage) +
-			gamma*(1-srv.ResponseTime/500.0) + // Normalizing response time (max 500ms)
-			delta*(1-srv.ErrorRate) +
-			epsilon*boolToFloat64(srv.PingStatus)
-
-		srv.HealthScore = H
+		server.Update(srv, func(s *server.Server) {
+			s.HealthScore = alpha*(1-s.CPUUsage) +
+				beta*(1-s.MemUsage) +
+				gamma*(1-s.ResponseTime/500.0) + // Normalizing response time (max 500ms)
+				delta*(1-s.ErrorRate) +
+				epsilon*boolToFloat64(s.PingStatus)
+		})
 	}
 
 	// 2) Normalize health scores into weights
 	totalHealth := 0.0
 	for _, srv := range servers {
-		totalHealth += srv.HealthScore
+		server.View(srv, func(s *server.Server) { totalHealth += s.HealthScore })
 	}
 
-	if totalHealth > 0 {
-		for _, srv := range servers {
-			srv.CurrentWeight = srv.HealthScore / totalHealth
-		}
-	} else {
-		// Edge case: If total health <= 0, set all weights to 0
-		for _, srv := range servers {
-			srv.CurrentWeight = 0
-		}
+	for _, srv := range servers {
+		server.Update(srv, func(s *server.Server) {
+			if totalHealth > 0 {
+				s.CurrentWeight = s.HealthScore / totalHealth
+			} else {
+				// Edge case: If total health <= 0, set all weights to 0
+				s.CurrentWeight = 0
+			}
+		})
+	}
+
+	// Weights are written in place on the shared *Server values, so the
+	// manager's list is not replaced here; doing so would race with servers
+	// being added or removed through the API.
+}
+
+// probe performs an HTTP health check against the server's probe path.
+func (hc *Checker) probe(srv *server.Server) {
+	url := fmt.Sprintf("http://%s:%d%s", srv.Address, srv.Port, hc.ProbePath)
+
+	start := time.Now()
+	resp, err := hc.client.Get(url)
+	elapsed := float64(time.Since(start).Milliseconds())
+	up := false
+	if err == nil {
+		resp.Body.Close()
+		up = resp.StatusCode >= 200 && resp.StatusCode < 300
+	}
+
+	server.Update(srv, func(s *server.Server) {
+		s.PingStatus = up
+		s.ResponseTime = elapsed
+	})
+	// A server probed for the first time was just added, which starts its
+	// own slow start
+	wasUp, probed := hc.probedUp[srv.ID]
+	hc.probedUp[srv.ID] = up
+	if up && probed && !wasUp {
+		// Recovered: let the balancer warm it up rather than flood it
+		server.BeginSlowStart(srv)
 	}
+}
 
-	// 3) Update the server manager with recalculated weights
-	hc.ServerManager.UpdateServers(servers)
+// forgetRemoved drops the probe results of servers no longer in the pool.
+func (hc *Checker) forgetRemoved(servers []*server.Server) {
+	current := make(map[string]bool, len(servers))
+	for _, srv := range servers {
+		current[srv.ID] = true
+	}
+	for id := range hc.probedUp {
+		if !current[id] {
+			delete(hc.probedUp, id)
+		}
+	}
 }
 
 // boolToFloat64 converts a boolean value to float64 (1 for true, 0 for false).
@@ -0,0 +1,44 @@
+package health
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"load-balancer/internal/server"
+)
+
+func TestChecker_SlowStartsOnlyOnRecovery(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	srv := &server.Server{ID: "web-1", Address: "127.0.0.1", Port: ts.Listener.Addr().(*net.TCPAddr).Port}
+	hc := NewChecker(time.Hour, server.NewManager([]*server.Server{srv}))
+	hc.ProbePath = "/health"
+
+	// Simulated metrics report the server down now and then; the probe
+	// result is what counts
+	for i := 0; i < 200; i++ {
+		hc.checkServers()
+	}
+	if since := server.GetSlowStartSince(srv); !since.IsZero() {
+		t.Fatalf("a server that stayed healthy entered slow start at %v", since)
+	}
+
+	healthy.Store(false)
+	hc.checkServers()
+	healthy.Store(true)
+	hc.checkServers()
+	if server.GetSlowStartSince(srv).IsZero() {
+		t.Fatal("a recovered server did not enter slow start")
+	}
+}
@@ -0,0 +1,167 @@
+// internal/history/query.go
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"load-balancer/internal/events"
+)
+
+// Page sizes for Query, when none is given and at most.
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Query selects stored events. Zero fields match everything; Status and
+// Priority only match packet events.
+type Query struct {
+	From      time.Time
+	To        time.Time
+	Filter    events.Filter // types, server and minimum severity
+	RequestID string
+	Status    string // packet status: dispatch, rerouted, failed or completed
+	Priority  string
+	After     uint64 // only events with a higher ID, to page through results
+	Limit     int
+}
+
+// Page is one page of query results, oldest first. Next is the After to
+// request the following page with, or 0 on the last page.
+type Page struct {
+	Events []events.Event `json:"events"`
+	Next   uint64         `json:"next,omitempty"`
+}
+
+// packetFields are the parts of a packet event's data a query can match.
+type packetFields struct {
+	Status   string `json:"status"`
+	Priority string `json:"priority"`
+}
+
+// Match reports whether event is selected by q, ignoring After and Limit.
+func (q Query) Match(event events.Event) bool {
+	if !q.From.IsZero() && event.Timestamp.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && event.Timestamp.After(q.To) {
+		return false
+	}
+	if q.RequestID != "" && event.RequestID != q.RequestID {
+		return false
+	}
+	if !q.Filter.Match(event) {
+		return false
+	}
+	if q.Status == "" && q.Priority == "" {
+		return true
+	}
+	if event.Type != events.PacketEvent {
+		return false
+	}
+	var packet packetFields
+	if err := json.Unmarshal(event.Data, &packet); err != nil {
+		return false
+	}
+	return (q.Status == "" || packet.Status == q.Status) &&
+		(q.Priority == "" || packet.Priority == q.Priority)
+}
+
+// ParseQuery reads a query from from and to (RFC 3339), types, server,
+// minSeverity, requestId, status, priority, after and limit parameters.
+func ParseQuery(values url.Values) (Query, error) {
+	filter, err := events.ParseFilter(values)
+	if err != nil {
+		return Query{}, err
+	}
+	q := Query{
+		Filter:    filter,
+		RequestID: values.Get("requestId"),
+		Status:    values.Get("status"),
+		Priority:  values.Get("priority"),
+		Limit:     DefaultLimit,
+	}
+	for key, t := range map[string]*time.Time{"from": &q.From, "to": &q.To} {
+		if raw := values.Get(key); raw != "" {
+			if *t, err = time.Parse(time.RFC3339, raw); err != nil {
+				return Query{}, fmt.Errorf("invalid %s time %q: use RFC 3339", key, raw)
+			}
+		}
+	}
+	if raw := values.Get("after"); raw != "" {
+		if q.After, err = strconv.ParseUint(raw, 10, 64); err != nil {
+			return Query{}, fmt.Errorf("invalid after %q", raw)
+		}
+	}
+	if raw := values.Get("limit"); raw != "" {
+		if q.Limit, err = strconv.Atoi(raw); err != nil || q.Limit <= 0 {
+			return Query{}, fmt.Errorf("invalid limit %q", raw)
+		}
+	}
+	return q, nil
+}
+
+// Query returns the stored events q selects, oldest first, at most q.Limit
+// (capped at MaxLimit) at a time.
+func (s *Store) Query(q Query) (Page, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+
+	page := Page{Events: []events.Event{}}
+	segments := s.snapshot()
+	for i, seg := range segments {
+		// Skip segments that end at or before the cursor, or whose last
+		// write was before the range starts
+		if i+1 < len(segments) && segments[i+1].first <= q.After+1 {
+			continue
+		}
+		if !q.From.IsZero() && seg.modified.Before(q.From) {
+			continue
+		}
+
+		full := false
+		err := readSegment(seg.path, func(event events.Event) bool {
+			if event.ID <= q.After || !q.Match(event) {
+				return true
+			}
+			if len(page.Events) == limit {
+				full = true
+				return false
+			}
+			page.Events = append(page.Events, event)
+			return true
+		})
+		if err != nil {
+			return page, err
+		}
+		if full {
+			page.Next = page.Events[len(page.Events)-1].ID
+			break
+		}
+	}
+	return page, nil
+}
+
+// All pages through every event q selects.
+func (s *Store) All(q Query) ([]events.Event, error) {
+	var all []events.Event
+	q.Limit = MaxLimit
+	for {
+		page, err := s.Query(q)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, page.Events...)
+		if page.Next == 0 {
+			return all, nil
+		}
+		q.After = page.Next
+	}
+}
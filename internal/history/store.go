@@ -0,0 +1,401 @@
+// internal/history/store.go
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"load-balancer/internal/events"
+)
+
+const (
+	// queueSize is how many events may wait for the writer before new ones
+	// are dropped rather than slow down publishing.
+	queueSize = 4096
+
+	// retentionInterval is how often segments are checked against MaxAge.
+	retentionInterval = time.Minute
+
+	segmentSuffix = ".jsonl"
+)
+
+// Options configures a Store.
+type Options struct {
+	Dir          string        // directory holding the segment files
+	SegmentBytes int64         // a segment is closed and a new one started past this size
+	MaxBytes     int64         // oldest segments are deleted past this total, checked as segments start; 0 keeps everything
+	MaxAge       time.Duration // segments last written longer ago are deleted; 0 keeps everything
+}
+
+// segment is one file of the store. Segments are named after the ID of their
+// first event, so each holds the IDs up to the next segment's first.
+type segment struct {
+	path     string
+	first    uint64
+	size     int64
+	modified time.Time
+}
+
+// Store is an append-only event log on local disk, one JSON event per line,
+// split into segments that are deleted oldest first once the store exceeds
+// its size or age limit. Events are written by a background goroutine so
+// Append never blocks publishing.
+type Store struct {
+	opts    Options
+	queue   chan events.Event
+	dropped atomic.Uint64
+
+	// mu guards the segment list and the active segment's writer
+	mu        sync.Mutex
+	segments  []segment
+	active    *os.File
+	writer    *bufio.Writer
+	lastID    uint64
+	suspended bool
+	failing   bool // a write error has been logged
+
+	started   bool
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Open loads the segment list from opts.Dir, creating the directory if
+// needed. Call Start to begin writing.
+func Open(opts Options) (*Store, error) {
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		opts:  opts,
+		queue: make(chan events.Event, queueSize),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	for _, entry := range entries {
+		first, ok := segmentID(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		s.segments = append(s.segments, segment{
+			path:     filepath.Join(opts.Dir, entry.Name()),
+			first:    first,
+			size:     info.Size(),
+			modified: info.ModTime(),
+		})
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].first < s.segments[j].first })
+
+	// The newest segment may end in a torn line after a crash, so look back
+	// until one yields an event
+	for i := len(s.segments) - 1; i >= 0 && s.lastID == 0; i-- {
+		readSegment(s.segments[i].path, func(event events.Event) bool {
+			s.lastID = max(s.lastID, event.ID)
+			return true
+		})
+	}
+	return s, nil
+}
+
+func segmentName(first uint64) string {
+	return fmt.Sprintf("%020d%s", first, segmentSuffix)
+}
+
+func segmentID(name string) (uint64, bool) {
+	if !strings.HasSuffix(name, segmentSuffix) {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+	return id, err == nil
+}
+
+// LastID is the highest event ID stored when the store was opened or
+// written since.
+func (s *Store) LastID() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastID
+}
+
+// Dropped is how many events were not stored because the writer fell behind.
+func (s *Store) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Append queues an event to be stored. It never blocks: if the writer has
+// fallen queueSize events behind, the event is dropped and counted.
+func (s *Store) Append(event events.Event) {
+	select {
+	case s.queue <- event:
+	default:
+		if s.dropped.Add(1) == 1 {
+			log.Printf("Event history writer fell behind; dropping events")
+		}
+	}
+}
+
+// Start runs the writer until Close.
+func (s *Store) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	go s.run()
+}
+
+func (s *Store) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-s.queue:
+			s.mu.Lock()
+			s.write(event)
+			// Flush once the queue is drained: bursts share a write, and a
+			// quiet store is always readable up to date
+			if len(s.queue) == 0 {
+				s.flush()
+			}
+			s.mu.Unlock()
+		case <-ticker.C:
+			s.mu.Lock()
+			s.enforceRetention(time.Now())
+			s.mu.Unlock()
+		case <-s.stop:
+			s.mu.Lock()
+			s.drain()
+			s.closeSegment()
+			s.mu.Unlock()
+			return
+		}
+	}
+}
+
+// Suspend writes out the queued events, closes the active segment and
+// drops everything appended until Resume, e.g. while another process takes
+// over the store. Queries keep working.
+func (s *Store) Suspend() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drain()
+	s.closeSegment()
+	s.suspended = true
+}
+
+// Resume undoes Suspend. Writing continues in a new segment.
+func (s *Store) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.suspended = false
+}
+
+// Close writes out the queued events and stops the writer.
+func (s *Store) Close() {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		started := s.started
+		s.mu.Unlock()
+		close(s.stop)
+		if started {
+			<-s.done
+			return
+		}
+		s.mu.Lock()
+		s.drain()
+		s.closeSegment()
+		s.mu.Unlock()
+	})
+}
+
+// drain writes every queued event. The caller holds mu.
+func (s *Store) drain() {
+	for {
+		select {
+		case event := <-s.queue:
+			s.write(event)
+		default:
+			s.flush()
+			return
+		}
+	}
+}
+
+// write appends one event, rotating first if the active segment is full.
+// The caller holds mu.
+func (s *Store) write(event events.Event) {
+	if s.suspended {
+		return
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	if s.active != nil && s.opts.SegmentBytes > 0 && s.current().size+int64(len(line)) > s.opts.SegmentBytes {
+		s.closeSegment()
+	}
+	if s.active == nil {
+		if err := s.openSegment(event.ID); err != nil {
+			s.fail(err)
+			return
+		}
+	}
+
+	if _, err := s.writer.Write(line); err != nil {
+		s.fail(err)
+		return
+	}
+	seg := s.current()
+	seg.size += int64(len(line))
+	seg.modified = time.Now()
+	s.lastID = max(s.lastID, event.ID)
+	s.failing = false
+}
+
+func (s *Store) fail(err error) {
+	if !s.failing {
+		log.Printf("Unable to write event history: %v", err)
+		s.failing = true
+	}
+}
+
+// current is the active segment, always the last.
+func (s *Store) current() *segment {
+	return &s.segments[len(s.segments)-1]
+}
+
+// openSegment starts a new segment at first. A running store never appends
+// to a segment it did not open, so a torn line from a crash stays the last
+// line of its file.
+func (s *Store) openSegment(first uint64) error {
+	path := filepath.Join(s.opts.Dir, segmentName(first))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if n := len(s.segments); n > 0 && s.segments[n-1].path == path {
+		s.segments = s.segments[:n-1]
+	}
+	s.segments = append(s.segments, segment{path: path, first: first, size: info.Size(), modified: time.Now()})
+	s.active = file
+	s.writer = bufio.NewWriter(file)
+	s.enforceRetention(time.Now())
+	return nil
+}
+
+func (s *Store) flush() {
+	if s.writer == nil {
+		return
+	}
+	if err := s.writer.Flush(); err != nil {
+		s.fail(err)
+	}
+}
+
+func (s *Store) closeSegment() {
+	if s.active == nil {
+		return
+	}
+	s.flush()
+	if err := s.active.Close(); err != nil {
+		s.fail(err)
+	}
+	s.active, s.writer = nil, nil
+}
+
+// enforceRetention deletes the oldest segments while the store is over
+// MaxBytes, and any segment last written before MaxAge ago. The active
+// segment is never deleted. The caller holds mu.
+func (s *Store) enforceRetention(now time.Time) {
+	var total int64
+	for _, seg := range s.segments {
+		total += seg.size
+	}
+
+	kept := s.segments[:0]
+	for i, seg := range s.segments {
+		isActive := s.active != nil && i == len(s.segments)-1
+		tooBig := s.opts.MaxBytes > 0 && total > s.opts.MaxBytes
+		tooOld := s.opts.MaxAge > 0 && now.Sub(seg.modified) > s.opts.MaxAge
+		if isActive || !(tooBig || tooOld) {
+			kept = append(kept, seg)
+			continue
+		}
+		if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Unable to delete event history segment %s: %v", seg.path, err)
+			kept = append(kept, seg)
+			continue
+		}
+		total -= seg.size
+	}
+	s.segments = kept
+}
+
+// snapshot flushes buffered events and returns the segments to read.
+func (s *Store) snapshot() []segment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flush()
+	return append([]segment(nil), s.segments...)
+}
+
+// readSegment calls fn with each event in the file at path, in order,
+// until fn returns false. Lines that do not decode, such as one torn by a
+// crash, are skipped, as is a segment retention has since deleted.
+func readSegment(path string, fn func(events.Event) bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var event events.Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			continue
+		}
+		if !fn(event) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
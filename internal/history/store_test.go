@@ -0,0 +1,136 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"load-balancer/internal/events"
+)
+
+func openStore(t *testing.T, dir string, es *events.EventSystem) *Store {
+	t.Helper()
+	store, err := Open(Options{Dir: dir, SegmentBytes: 2 << 10, MaxBytes: 8 << 10, MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	es.ContinueAfter(store.LastID())
+	es.OnEvent(store.Append)
+	store.Start()
+	t.Cleanup(store.Close)
+	return store
+}
+
+func publishPackets(es *events.EventSystem, n int) {
+	for i := 1; i <= n; i++ {
+		status, priority := "completed", "low"
+		if i%4 == 0 {
+			status = "failed"
+		}
+		if i%2 == 0 {
+			priority = "high"
+		}
+		es.Emit(events.Event{
+			Type:      events.PacketEvent,
+			ServerID:  fmt.Sprintf("server-%d", i%3),
+			RequestID: fmt.Sprintf("pkt-%d", i),
+			Message:   "packet",
+			Data:      events.Payload(map[string]string{"status": status, "priority": priority}),
+		})
+	}
+}
+
+func TestStore_RotatesRetainsAndPages(t *testing.T) {
+	dir := t.TempDir()
+	es := events.NewEventSystem(10)
+	store := openStore(t, dir, es)
+	publishPackets(es, 200)
+	store.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	var total int64
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatalf("stat: %v", err)
+		}
+		total += info.Size()
+	}
+	// Retention runs as segments start, so the active one may take the
+	// store past MaxBytes
+	if len(files) < 2 || total > 10<<10 {
+		t.Fatalf("want rotated segments within 10 KiB, got %d files of %d bytes", len(files), total)
+	}
+
+	all, err := store.All(Query{})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	oldest := all[0].ID
+	if oldest == 1 || all[len(all)-1].ID != 200 {
+		t.Fatalf("want the oldest events deleted and the newest kept, got IDs %d..%d", oldest, all[len(all)-1].ID)
+	}
+
+	// Page through failed high-priority packets on one server
+	q := Query{Status: "failed", Priority: "high", Filter: events.Filter{ServerID: "server-0"}, Limit: 3}
+	var got []uint64
+	for pages := 0; ; pages++ {
+		page, err := store.Query(q)
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		for _, event := range page.Events {
+			got = append(got, event.ID)
+		}
+		if page.Next == 0 {
+			break
+		}
+		if pages > 20 {
+			t.Fatal("pagination does not end")
+		}
+		q.After = page.Next
+	}
+	var want []uint64
+	for id := oldest; id <= 200; id++ {
+		if id%12 == 0 {
+			want = append(want, id)
+		}
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+
+	page, err := store.Query(Query{RequestID: "pkt-200"})
+	if err != nil || len(page.Events) != 1 || page.Events[0].ID != 200 {
+		t.Fatalf("want pkt-200 by request ID, got %+v (%v)", page.Events, err)
+	}
+}
+
+func TestStore_ContinuesIDsAfterRestartAndExpires(t *testing.T) {
+	dir := t.TempDir()
+	es := events.NewEventSystem(10)
+	store := openStore(t, dir, es)
+	publishPackets(es, 10)
+	store.Close()
+
+	es = events.NewEventSystem(10)
+	store = openStore(t, dir, es)
+	if store.LastID() != 10 {
+		t.Fatalf("want last stored ID 10, got %d", store.LastID())
+	}
+	publishPackets(es, 1)
+	store.Close()
+	all, err := store.All(Query{})
+	if err != nil || len(all) != 11 || all[10].ID != 11 {
+		t.Fatalf("want IDs to continue at 11, got %d events (%v)", len(all), err)
+	}
+
+	store.mu.Lock()
+	store.enforceRetention(time.Now().Add(2 * time.Hour))
+	store.mu.Unlock()
+	if all, _ := store.All(Query{}); len(all) != 0 {
+		t.Fatalf("want segments past MaxAge deleted, got %d events", len(all))
+	}
+}
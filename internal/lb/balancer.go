@@ -2,24 +2,43 @@
 package lb
 
 import (
-	"load-balancer/internal/server"
+	"fmt"
 	"net/http"
-	"strings"
 	"sync"
+	"time"
+
+	"load-balancer/internal/proxy"
+	"load-balancer/internal/server"
 )
 
 const BusyThreshold int64 = 5
 
+// Strategy names the fallback algorithm used once sticky sessions and IP hash
+// have had their say.
+type Strategy string
+
+const (
+	StrategyWeightedRoundRobin Strategy = "weighted-round-robin"
+	StrategyLeastConnections   Strategy = "least-connections"
+)
+
+// StrategyIPHash is the pool-level name for IP hash over weighted round robin.
+const StrategyIPHash = "ip-hash"
+
 // Balancer orchestrates the load-balancing process.
 type Balancer struct {
 	mu               sync.Mutex
 	ServerManager    *server.Manager
 	WRR              *WeightedRoundRobin
+	LeastConn        *LeastConnections
 	IPHasher         *IPHash
 	StickySessionMgr *StickySessions
 
+	Strategy          Strategy
 	UseStickySessions bool
 	UseIPHash         bool
+
+	slowStart SlowStart
 }
 
 // NewBalancer creates a new Balancer instance.
@@ -27,11 +46,89 @@ func NewBalancer(mgr *server.Manager, wrr *WeightedRoundRobin, ipHash *IPHash, s
 	return &Balancer{
 		ServerManager:    mgr,
 		WRR:              wrr,
+		LeastConn:        NewLeastConnections(mgr),
 		IPHasher:         ipHash,
 		StickySessionMgr: sticky,
+		Strategy:         StrategyWeightedRoundRobin,
 	}
 }
 
+// SetSlowStart applies the slow-start ramp to every strategy.
+func (b *Balancer) SetSlowStart(ss SlowStart) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.slowStart = ss
+	b.WRR.mu.Lock()
+	b.WRR.SlowStart = ss
+	b.WRR.mu.Unlock()
+	b.LeastConn.mu.Lock()
+	b.LeastConn.SlowStart = ss
+	b.LeastConn.mu.Unlock()
+	b.IPHasher.mu.Lock()
+	b.IPHasher.SlowStart = ss
+	b.IPHasher.mu.Unlock()
+}
+
+// SetStrategy switches to weighted-round-robin, least-connections or ip-hash.
+func (b *Balancer) SetStrategy(name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch name {
+	case string(StrategyWeightedRoundRobin):
+		b.Strategy, b.UseIPHash = StrategyWeightedRoundRobin, false
+	case string(StrategyLeastConnections):
+		b.Strategy, b.UseIPHash = StrategyLeastConnections, false
+	case StrategyIPHash:
+		b.Strategy, b.UseIPHash = StrategyWeightedRoundRobin, true
+	default:
+		return fmt.Errorf("unknown strategy %q", name)
+	}
+	return nil
+}
+
+// StrategyName reports the strategy as SetStrategy names it.
+func (b *Balancer) StrategyName() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.UseIPHash {
+		return StrategyIPHash
+	}
+	return string(b.Strategy)
+}
+
+// SetIPHash turns ip-hash on or off, keeping the underlying strategy.
+func (b *Balancer) SetIPHash(enabled bool) {
+	b.mu.Lock()
+	b.UseIPHash = enabled
+	b.mu.Unlock()
+}
+
+// SetStickySessions turns session affinity on or off.
+func (b *Balancer) SetStickySessions(enabled bool) {
+	b.mu.Lock()
+	b.UseStickySessions = enabled
+	b.mu.Unlock()
+}
+
+// StickySessions reports whether session affinity is on.
+func (b *Balancer) StickySessions() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.UseStickySessions
+}
+
+// RampFactor reports the share (0..1] of its normal weight the server gets now.
+func (b *Balancer) RampFactor(srv *server.Server) float64 {
+	b.mu.Lock()
+	ss := b.slowStart
+	b.mu.Unlock()
+
+	return ss.Factor(srv, time.Now())
+}
+
 // PickServer chooses which server should handle the request.
 func (b *Balancer) PickServer(r *http.Request) *server.Server {
 	return b.pickServerInternal(r, nil)
@@ -51,7 +148,7 @@ func (b *Balancer) pickServerInternal(r *http.Request, exclude map[string]bool)
 	if b.UseStickySessions && sessionID != "" {
 		if srv := b.StickySessionMgr.GetServerForSession(sessionID); srv != nil {
 			// If the sticky server is healthy (Closed), return it.
-			if srv.CircuitBreakerState == server.CBStateClosed {
+			if server.GetBreakerState(srv) == server.CBStateClosed {
 				if exclude == nil || !exclude[srv.ID] {
 					return srv
 				}
@@ -61,10 +158,10 @@ func (b *Balancer) pickServerInternal(r *http.Request, exclude map[string]bool)
 
 	// 2. If IP Hash is enabled, pick server based on IP.
 	if b.UseIPHash {
-		clientIP := extractClientIP(r)
+		clientIP := proxy.ClientIP(r)
 		if srv := b.IPHasher.GetServerForIP(clientIP); srv != nil {
 			// If the IP-hashed server is healthy, bind session (if using sticky)
-			if srv.CircuitBreakerState == server.CBStateClosed {
+			if server.GetBreakerState(srv) == server.CBStateClosed {
 				if exclude == nil || !exclude[srv.ID] {
 					if b.UseStickySessions && sessionID != "" {
 						b.StickySessionMgr.BindSessionToServer(sessionID, srv)
@@ -75,8 +172,13 @@ func (b *Balancer) pickServerInternal(r *http.Request, exclude map[string]bool)
 		}
 	}
 
-	// 3. Fallback to Weighted Round Robin
-	chosen := b.WRR.PickServer(exclude)
+	// 3. Fallback to the configured strategy (Weighted Round Robin by default)
+	var chosen *server.Server
+	if b.Strategy == StrategyLeastConnections {
+		chosen = b.LeastConn.PickServer(exclude)
+	} else {
+		chosen = b.WRR.PickServer(exclude)
+	}
 	if chosen == nil {
 		// All servers might be in Open state or no servers exist
 		return nil
@@ -98,16 +200,3 @@ func extractSessionID(r *http.Request) string {
 	}
 	return ""
 }
-
-// extractClientIP retrieves the client's IP address.
-func extractClientIP(r *http.Request) string {
-	ip := r.Header.Get("X-Forwarded-For")
-	if ip == "" {
-		// Fallback to r.RemoteAddr (which includes port)
-		ip = r.RemoteAddr
-		if idx := strings.LastIndex(ip, ":"); idx != -1 {
-			ip = ip[:idx]
-		}
-	}
-	return ip
-}
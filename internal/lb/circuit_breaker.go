@@ -2,6 +2,8 @@
 package lb
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"load-balancer/internal/server"
@@ -18,6 +20,9 @@ type CircuitBreakerSettings struct {
 type CircuitBreakerCoordinator struct {
 	Settings      CircuitBreakerSettings
 	ServerManager *server.Manager
+
+	listenersMu sync.Mutex
+	onTrip      []func(*server.Server)
 }
 
 // NewCircuitBreakerCoordinator creates a new CB coordinator.
@@ -28,51 +33,128 @@ func NewCircuitBreakerCoordinator(mgr *server.Manager, settings CircuitBreakerSe
 	}
 }
 
+// OnTrip registers a callback run whenever failures open a server's breaker.
+func (cbc *CircuitBreakerCoordinator) OnTrip(fn func(*server.Server)) {
+	cbc.listenersMu.Lock()
+	defer cbc.listenersMu.Unlock()
+
+	cbc.onTrip = append(cbc.onTrip, fn)
+}
+
 // RecordFailure increments failure count and potentially opens the breaker.
 func (cbc *CircuitBreakerCoordinator) RecordFailure(srv *server.Server) {
-	srv.FailureCount++
-	if srv.CircuitBreakerState == server.CBStateClosed &&
-		srv.FailureCount >= cbc.Settings.FailureThreshold {
-		srv.CircuitBreakerState = server.CBStateOpen
-		srv.OpenSince = time.Now()
-	} else if srv.CircuitBreakerState == server.CBStateHalfOpen {
-		// If in HalfOpen and a failure occurs, go back to Open
-		srv.CircuitBreakerState = server.CBStateOpen
-		srv.OpenSince = time.Now()
+	tripped := false
+	server.Update(srv, func(s *server.Server) {
+		s.FailureCount++
+		if s.CircuitBreakerState == server.CBStateClosed &&
+			s.FailureCount >= cbc.Settings.FailureThreshold {
+			s.CircuitBreakerState = server.CBStateOpen
+			s.OpenSince = time.Now()
+			tripped = true
+		} else if s.CircuitBreakerState == server.CBStateHalfOpen {
+			// If in HalfOpen and a failure occurs, go back to Open
+			s.CircuitBreakerState = server.CBStateOpen
+			s.OpenSince = time.Now()
+			tripped = true
+		}
+	})
+	if tripped {
+		cbc.notifyTrip(srv)
+	}
+}
+
+func (cbc *CircuitBreakerCoordinator) notifyTrip(srv *server.Server) {
+	cbc.listenersMu.Lock()
+	listeners := append([]func(*server.Server){}, cbc.onTrip...)
+	cbc.listenersMu.Unlock()
+
+	for _, fn := range listeners {
+		fn(srv)
 	}
 }
 
 // RecordSuccess resets the failure count. Also transitions from HalfOpen -> Closed
 // if enough success requests have been made.
 func (cbc *CircuitBreakerCoordinator) RecordSuccess(srv *server.Server) {
-	if srv.CircuitBreakerState == server.CBStateClosed {
-		srv.FailureCount = 0
-		return
-	}
+	server.Update(srv, func(s *server.Server) {
+		if s.CircuitBreakerState == server.CBStateClosed {
+			s.FailureCount = 0
+			return
+		}
 
-	if srv.CircuitBreakerState == server.CBStateHalfOpen {
-		srv.TrialSuccessCount++
-		if srv.TrialSuccessCount >= cbc.Settings.TrialRequests {
-			// Move to closed
-			srv.CircuitBreakerState = server.CBStateClosed
-			srv.FailureCount = 0
-			srv.TrialSuccessCount = 0
+		if s.CircuitBreakerState == server.CBStateHalfOpen {
+			s.TrialSuccessCount++
+			if s.TrialSuccessCount >= cbc.Settings.TrialRequests {
+				// Move to closed and warm up
+				s.CircuitBreakerState = server.CBStateClosed
+				s.FailureCount = 0
+				s.TrialSuccessCount = 0
+				s.SlowStartSince = time.Now()
+			}
 		}
-	}
+	})
+}
+
+// ForceOpen opens the server's closed breaker as of since, e.g. when a peer
+// has ejected it. Trip listeners are not called. It reports false if the
+// breaker was not closed.
+func (cbc *CircuitBreakerCoordinator) ForceOpen(srv *server.Server, since time.Time) bool {
+	opened := false
+	server.Update(srv, func(s *server.Server) {
+		if s.CircuitBreakerState != server.CBStateClosed {
+			return
+		}
+		s.CircuitBreakerState = server.CBStateOpen
+		s.OpenSince = since
+		s.FailureCount = 0
+		s.TrialSuccessCount = 0
+		opened = true
+	})
+	return opened
+}
+
+// Restore puts back breaker state saved by a snapshot, handoff or peer.
+func (cbc *CircuitBreakerCoordinator) Restore(srv *server.Server, saved server.Breaker) {
+	server.Update(srv, func(s *server.Server) {
+		s.CircuitBreakerState = saved.State
+		s.FailureCount = saved.Failures
+		s.TrialSuccessCount = saved.Trials
+		s.OpenSince = saved.OpenSince
+	})
+}
+
+// admitsTraffic reports whether a server's breaker lets requests through.
+// Half-open servers take trial requests so a success can close the breaker.
+func admitsTraffic(srv *server.Server) bool {
+	return server.GetBreakerState(srv) != server.CBStateOpen
 }
 
 // MonitorServers runs periodically to move servers from Open -> HalfOpen after cooldown.
 func (cbc *CircuitBreakerCoordinator) MonitorServers() {
+	cbc.Run(context.Background())
+}
+
+// Run is MonitorServers bound to a context; it returns once ctx is cancelled.
+func (cbc *CircuitBreakerCoordinator) Run(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
 	for {
 		servers := cbc.ServerManager.GetAllServers()
 		for _, srv := range servers {
-			if srv.CircuitBreakerState == server.CBStateOpen {
-				if time.Since(srv.OpenSince) >= cbc.Settings.CooldownPeriod {
-					srv.CircuitBreakerState = server.CBStateHalfOpen
-					srv.TrialSuccessCount = 0
+			server.Update(srv, func(s *server.Server) {
+				if s.CircuitBreakerState == server.CBStateOpen &&
+					time.Since(s.OpenSince) >= cbc.Settings.CooldownPeriod {
+					s.CircuitBreakerState = server.CBStateHalfOpen
+					s.TrialSuccessCount = 0
 				}
-			}
+			})
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
-		time.Sleep(1 * time.Second)
 	}
 }
@@ -0,0 +1,82 @@
+// internal/lb/drain.go
+package lb
+
+import (
+	"time"
+
+	"load-balancer/internal/server"
+)
+
+// drainPollInterval is how often a draining server's in-flight count is checked.
+const drainPollInterval = 100 * time.Millisecond
+
+// DrainResult describes how a drain ended.
+type DrainResult struct {
+	ServerID  string
+	Completed bool  // every in-flight request finished before the timeout
+	Cancelled bool  // the server was reactivated before the drain ended
+	InFlight  int64 // requests still running when the drain ended
+	Elapsed   time.Duration
+}
+
+// Drain stops sending new requests and sticky sessions to srv and re-homes
+// its sessions. In-flight requests are left to finish; once they have, or the
+// timeout expires, the server moves to maintenance and done is called.
+// It reports false if the server was not active.
+func (b *Balancer) Drain(srv *server.Server, timeout time.Duration, done func(DrainResult)) bool {
+	if !server.CompareAndSetAdminState(srv, server.AdminActive, server.AdminDraining) {
+		return false
+	}
+	started := time.Now()
+	server.Update(srv, func(s *server.Server) { s.DrainStarted = started })
+	b.StickySessionMgr.ForgetServer(srv)
+
+	go func() {
+		ticker := time.NewTicker(drainPollInterval)
+		defer ticker.Stop()
+
+		result := DrainResult{ServerID: srv.ID}
+		for {
+			inFlight := server.GetActiveRequests(srv)
+			expired := timeout > 0 && time.Since(started) >= timeout
+			if inFlight <= 0 || expired {
+				result.Completed = inFlight <= 0
+				result.InFlight = inFlight
+				if !server.CompareAndSetAdminState(srv, server.AdminDraining, server.AdminMaintenance) {
+					result.Cancelled = true
+				}
+				break
+			}
+			if server.GetAdminState(srv) != server.AdminDraining {
+				result.Cancelled = true
+				result.InFlight = inFlight
+				break
+			}
+			<-ticker.C
+		}
+
+		result.Elapsed = time.Since(started)
+		if done != nil {
+			done(result)
+		}
+	}()
+	return true
+}
+
+// EnterMaintenance takes srv out of rotation immediately without waiting for
+// in-flight requests, which still finish normally.
+func (b *Balancer) EnterMaintenance(srv *server.Server) {
+	server.SetAdminState(srv, server.AdminMaintenance)
+	b.StickySessionMgr.ForgetServer(srv)
+}
+
+// Activate returns a draining or maintenance server to rotation, warming it
+// up under slow start. It reports false if the server was already active.
+func (b *Balancer) Activate(srv *server.Server) bool {
+	if server.GetAdminState(srv) == server.AdminActive {
+		return false
+	}
+	server.SetAdminState(srv, server.AdminActive)
+	server.BeginSlowStart(srv)
+	return true
+}
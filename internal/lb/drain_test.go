@@ -0,0 +1,58 @@
+package lb
+
+import (
+	"testing"
+	"time"
+
+	"load-balancer/internal/server"
+)
+
+func TestBalancer_DrainWaitsForInFlightRequests(t *testing.T) {
+	mgr := newTestManagerWithWeights(0.5, 0.5)
+	servers := mgr.GetAllServers()
+	draining := servers[0]
+
+	sticky := NewStickySessions(mgr)
+	b := NewBalancer(mgr, NewWeightedRoundRobin(mgr), NewIPHash(mgr), sticky)
+	sticky.BindSessionToServer("session-1", draining)
+
+	server.BeginRequest(draining)
+	done := make(chan DrainResult, 1)
+	if !b.Drain(draining, time.Minute, func(result DrainResult) { done <- result }) {
+		t.Fatal("drain did not start")
+	}
+	if b.Drain(draining, time.Minute, nil) {
+		t.Fatal("second drain of the same server should be refused")
+	}
+
+	if got := sticky.GetServerForSession("session-1"); got != nil {
+		t.Fatalf("session still bound to %s while draining", got.ID)
+	}
+	for i := 0; i < 10; i++ {
+		if srv := b.WRR.PickServer(nil); srv == nil || srv.ID == draining.ID {
+			t.Fatalf("pick %d: got %v, want %s", i, srv, servers[1].ID)
+		}
+	}
+
+	select {
+	case <-done:
+		t.Fatal("drain completed while a request was still in flight")
+	case <-time.After(3 * drainPollInterval):
+	}
+
+	server.EndRequest(draining)
+	select {
+	case result := <-done:
+		if !result.Completed || result.InFlight != 0 {
+			t.Fatalf("result = %+v, want completed with nothing in flight", result)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("drain did not complete")
+	}
+	if state := server.GetAdminState(draining); state != server.AdminMaintenance {
+		t.Fatalf("state = %s, want maintenance", state)
+	}
+	if got := server.GetActiveRequests(draining); got != 0 {
+		t.Fatalf("ActiveRequests = %d, want 0", got)
+	}
+}
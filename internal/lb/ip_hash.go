@@ -4,6 +4,7 @@ package lb
 import (
 	"hash/crc32"
 	"sync"
+	"time"
 
 	"load-balancer/internal/server"
 )
@@ -15,6 +16,7 @@ import (
 type IPHash struct {
 	mu            sync.Mutex
 	ServerManager *server.Manager
+	SlowStart     SlowStart
 }
 
 // NewIPHash returns a new IPHash struct
@@ -43,8 +45,19 @@ func (ih *IPHash) GetServerForIP(ip string) *server.Server {
 	hashVal := crc32.ChecksumIEEE([]byte(ip)) // creating a hashvalue for a particular IP
 	index := int(hashVal) % len(servers) // selected a server index based on the hashvalue, since using modulus we wont go out of bounds
 	chosen := servers[index] // once we got the index we select the server and return it as chosen
-	if chosen.CircuitBreakerState != server.CBStateClosed {
+	if server.GetBreakerState(chosen) != server.CBStateClosed {
 		return nil // if the server is not closed, we return nil
 	}
+	if !server.IsAccepting(chosen) {
+		return nil // draining or in maintenance: let the strategy pick another
+	}
+	// While the server warms up only a growing, stable slice of its clients
+	// is sent to it; the rest fall through to the balancer's strategy.
+	if factor := ih.SlowStart.Factor(chosen, time.Now()); factor < 1 {
+		slice := float64(crc32.ChecksumIEEE([]byte("warmup|"+ip))%1000) / 1000
+		if slice >= factor {
+			return nil
+		}
+	}
 	return chosen
 }
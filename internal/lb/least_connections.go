@@ -0,0 +1,61 @@
+// internal/lb/least_connections.go
+package lb
+
+import (
+	"sync"
+	"time"
+
+	"load-balancer/internal/server"
+)
+
+// LeastConnections picks the healthy server with the fewest in-flight requests.
+// Ties are broken in rotation so equally idle servers share the load. Servers
+// in slow start count as busier, in proportion to how far they are from full.
+type LeastConnections struct {
+	mu            sync.Mutex
+	ServerManager *server.Manager
+	SlowStart     SlowStart
+	next          int
+}
+
+// NewLeastConnections creates a LeastConnections instance.
+func NewLeastConnections(mgr *server.Manager) *LeastConnections {
+	return &LeastConnections{ServerManager: mgr}
+}
+
+// PickServer returns the least busy eligible server, or nil if none remain.
+func (lc *LeastConnections) PickServer(exclude map[string]bool) *server.Server {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	servers := lc.ServerManager.GetAllServers()
+	if len(servers) == 0 {
+		return nil
+	}
+
+	var chosen *server.Server
+	var lowest float64
+	start := lc.next % len(servers)
+	now := time.Now()
+
+	for i := 0; i < len(servers); i++ {
+		srv := servers[(start+i)%len(servers)]
+		if exclude != nil && exclude[srv.ID] {
+			continue
+		}
+		if !server.IsUp(srv) || !admitsTraffic(srv) || !server.IsAccepting(srv) {
+			continue
+		}
+
+		// Score the load the server would carry with this request, scaled by
+		// its ramp so a warming server looks proportionally busier.
+		load := float64(server.GetActiveRequests(srv)+1) / lc.SlowStart.Factor(srv, now)
+		if chosen == nil || load < lowest {
+			chosen = srv
+			lowest = load
+		}
+	}
+
+	lc.next++
+	return chosen
+}
@@ -0,0 +1,58 @@
+// internal/lb/slow_start.go
+package lb
+
+import (
+	"math"
+	"time"
+
+	"load-balancer/internal/server"
+)
+
+// RampCurve selects how a warming server's share grows over the window.
+type RampCurve string
+
+const (
+	RampLinear      RampCurve = "linear"
+	RampExponential RampCurve = "exponential"
+)
+
+// defaultMinRampFactor is the share a server starts its window with.
+const defaultMinRampFactor = 0.1
+
+// SlowStart ramps a recovering or newly added server's effective weight from
+// MinFactor to full over Duration. A zero Duration disables the ramp.
+type SlowStart struct {
+	Duration  time.Duration
+	Curve     RampCurve
+	MinFactor float64
+}
+
+// Factor returns the multiplier (0..1] applied to the server's weight at now.
+func (ss SlowStart) Factor(srv *server.Server, now time.Time) float64 {
+	if ss.Duration <= 0 || srv == nil {
+		return 1
+	}
+	since := server.GetSlowStartSince(srv)
+	if since.IsZero() {
+		return 1
+	}
+	elapsed := now.Sub(since)
+	if elapsed >= ss.Duration {
+		return 1
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	min := ss.MinFactor
+	if min <= 0 || min > 1 {
+		min = defaultMinRampFactor
+	}
+	progress := float64(elapsed) / float64(ss.Duration)
+
+	if ss.Curve == RampExponential {
+		// Geometric growth: the share doubles at a steady rate, starting slowly.
+		return min * math.Pow(1/min, progress)
+	}
+	return min + (1-min)*progress
+}
@@ -0,0 +1,54 @@
+package lb
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"load-balancer/internal/server"
+)
+
+func TestSlowStart_Curves(t *testing.T) {
+	start := time.Now()
+	srv := &server.Server{SlowStartSince: start}
+
+	cases := []struct {
+		curve   RampCurve
+		elapsed time.Duration
+		want    float64
+	}{
+		{RampLinear, 0, 0.1},
+		{RampLinear, 5 * time.Second, 0.55},
+		{RampLinear, 10 * time.Second, 1},
+		{RampExponential, 0, 0.1},
+		{RampExponential, 5 * time.Second, math.Sqrt(0.1)},
+		{RampExponential, 20 * time.Second, 1},
+	}
+	for _, tc := range cases {
+		ss := SlowStart{Duration: 10 * time.Second, Curve: tc.curve, MinFactor: 0.1}
+		if got := ss.Factor(srv, start.Add(tc.elapsed)); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%s after %v: factor = %v, want %v", tc.curve, tc.elapsed, got, tc.want)
+		}
+	}
+
+	if got := (SlowStart{}).Factor(srv, start); got != 1 {
+		t.Errorf("disabled slow start: factor = %v, want 1", got)
+	}
+}
+
+func TestWeightedRoundRobin_HonoursSlowStart(t *testing.T) {
+	mgr := newTestManagerWithWeights(0.5, 0.5)
+	server.BeginSlowStart(mgr.GetAllServers()[1])
+
+	wrr := NewWeightedRoundRobin(mgr)
+	wrr.SlowStart = SlowStart{Duration: time.Hour, Curve: RampLinear, MinFactor: 0.25}
+
+	counts := map[string]int{}
+	for i := 0; i < 50; i++ {
+		counts[wrr.PickServer(nil).ID]++
+	}
+	// Effective weights 0.5 and ~0.125 split traffic 4:1.
+	if counts["srv-A"] != 40 || counts["srv-B"] != 10 {
+		t.Fatalf("counts = %v, want srv-A=40 srv-B=10", counts)
+	}
+}
@@ -2,7 +2,9 @@
 package lb
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	"load-balancer/internal/server"
 )
@@ -11,16 +13,32 @@ import (
 // This is a simple in-memory approach.
 type StickySessions struct {
 	mu            sync.Mutex // ensuring thread safe actions
-	sessionToSrv  map[string]*server.Server // creating a map for future mapping between session ids and servers
+	sessionToSrv  map[string]*binding // creating a map for future mapping between session ids and servers
 	ServerManager *server.Manager // a reference to the server manager
+	ttl           time.Duration   // bindings idle this long are dropped; 0 keeps them
+
+	listenersMu sync.Mutex
+	onBind      []func(sessionID string, srv *server.Server)
+	onUnbind    []func(sessionID string)
+}
+
+// binding is one session's server and when it was last used and announced
+// to OnBind listeners.
+type binding struct {
+	srv       *server.Server
+	used      time.Time
+	announced time.Time
 }
 
 // NewStickySessions creates a StickySessions instance.
 func NewStickySessions(mgr *server.Manager) *StickySessions { 
-	return &StickySessions{
-		sessionToSrv:  make(map[string]*server.Server), // empty session to server map
+	ss := &StickySessions{
+		sessionToSrv:  make(map[string]*binding), // empty session to server map
 		ServerManager: mgr, // Links the ServerManager to allow access to backend server details.
 	}
+	// Forget bindings to servers that leave the pool so they are re-homed.
+	mgr.OnRemove(ss.ForgetServer)
+	return ss
 }
 
 // GetServerForSession returns the server for a given session, if healthy.
@@ -29,24 +47,200 @@ func NewStickySessions(mgr *server.Manager) *StickySessions {
 // retrieves the server assigned to a particular session ID
 func (ss *StickySessions) GetServerForSession(sessionID string) *server.Server {
 	ss.mu.Lock()
-	defer ss.mu.Unlock() // to avoid deadlock
-	// defer occurs after the lock is removed to avoid race condition
-
-	srv, exists := ss.sessionToSrv[sessionID]
+	now := time.Now()
+	b, exists := ss.sessionToSrv[sessionID]
 	if !exists {
+		ss.mu.Unlock()
+		return nil
+	}
+	if ss.expiredLocked(b, now) {
+		delete(ss.sessionToSrv, sessionID)
+		ss.mu.Unlock()
+		return nil
+	}
+	// Draining servers keep no sessions; the caller re-homes this one
+	if !server.IsAccepting(b.srv) {
+		delete(ss.sessionToSrv, sessionID)
+		ss.mu.Unlock()
+		ss.notifyUnbind([]string{sessionID})
 		return nil
 	}
 	// Check if still healthy
-	if srv.CircuitBreakerState != server.CBStateClosed {
+	if server.GetBreakerState(b.srv) != server.CBStateClosed {
+		ss.mu.Unlock()
 		return nil
 	}
+	b.used = now
+	// Announce the binding again halfway through its TTL so listeners that
+	// keep a copy with the same TTL see it stay in use
+	renew := ss.ttl > 0 && now.Sub(b.announced) >= ss.ttl/2
+	if renew {
+		b.announced = now
+	}
+	srv := b.srv
+	ss.mu.Unlock()
+
+	if renew {
+		ss.notifyBind(sessionID, srv)
+	}
 	return srv
 }
 
+// SetTTL sets how long a binding may go unused before it is dropped; 0
+// keeps bindings until their server leaves.
+func (ss *StickySessions) SetTTL(ttl time.Duration) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.ttl = ttl
+}
+
+// TTL returns how long a binding may go unused.
+func (ss *StickySessions) TTL() time.Duration {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.ttl
+}
+
+func (ss *StickySessions) expiredLocked(b *binding, now time.Time) bool {
+	return ss.ttl > 0 && now.Sub(b.used) >= ss.ttl
+}
+
 // BindSessionToServer maps a session ID to a particular server.
 func (ss *StickySessions) BindSessionToServer(sessionID string, srv *server.Server) {
+	ss.mu.Lock()
+	if !server.IsAccepting(srv) {
+		ss.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	previous := ss.sessionToSrv[sessionID]
+	if previous != nil && previous.srv == srv {
+		previous.used = now
+		ss.mu.Unlock()
+		return
+	}
+	ss.sessionToSrv[sessionID] = &binding{srv: srv, used: now, announced: now}
+	ss.mu.Unlock()
+
+	ss.notifyBind(sessionID, srv)
+}
+
+// OnBind registers a callback run whenever a session is bound to a new
+// server, and again every half TTL while the binding is in use. Bindings
+// installed by RestoreSessions do not trigger it.
+func (ss *StickySessions) OnBind(fn func(sessionID string, srv *server.Server)) {
+	ss.listenersMu.Lock()
+	defer ss.listenersMu.Unlock()
+
+	ss.onBind = append(ss.onBind, fn)
+}
+
+// OnUnbind registers a callback run when bindings are dropped because their
+// server drains or leaves. Expiry and Unbind do not trigger it.
+func (ss *StickySessions) OnUnbind(fn func(sessionID string)) {
+	ss.listenersMu.Lock()
+	defer ss.listenersMu.Unlock()
+
+	ss.onUnbind = append(ss.onUnbind, fn)
+}
+
+func (ss *StickySessions) notifyBind(sessionID string, srv *server.Server) {
+	ss.listenersMu.Lock()
+	listeners := append([]func(string, *server.Server){}, ss.onBind...)
+	ss.listenersMu.Unlock()
+	for _, fn := range listeners {
+		fn(sessionID, srv)
+	}
+}
+
+func (ss *StickySessions) notifyUnbind(sessionIDs []string) {
+	if len(sessionIDs) == 0 {
+		return
+	}
+	ss.listenersMu.Lock()
+	listeners := append([]func(string){}, ss.onUnbind...)
+	ss.listenersMu.Unlock()
+	for _, sessionID := range sessionIDs {
+		for _, fn := range listeners {
+			fn(sessionID)
+		}
+	}
+}
+
+// Unbind drops a session's binding, e.g. one a peer has dropped.
+func (ss *StickySessions) Unbind(sessionID string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	delete(ss.sessionToSrv, sessionID)
+}
+
+// Run drops expired bindings until ctx is cancelled.
+func (ss *StickySessions) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			ss.mu.Lock()
+			for sessionID, b := range ss.sessionToSrv {
+				if ss.expiredLocked(b, now) {
+					delete(ss.sessionToSrv, sessionID)
+				}
+			}
+			ss.mu.Unlock()
+		}
+	}
+}
+
+// ForgetServer drops every session bound to the given server so the next
+// request from each session is re-homed.
+func (ss *StickySessions) ForgetServer(srv *server.Server) {
+	ss.mu.Lock()
+	var forgotten []string
+	for sessionID, b := range ss.sessionToSrv {
+		if b.srv.ID == srv.ID {
+			delete(ss.sessionToSrv, sessionID)
+			forgotten = append(forgotten, sessionID)
+		}
+	}
+	ss.mu.Unlock()
+	ss.notifyUnbind(forgotten)
+}
+
+// Sessions returns a copy of the session -> server ID bindings.
+func (ss *StickySessions) Sessions() map[string]string {
 	ss.mu.Lock()
 	defer ss.mu.Unlock()
 
-	ss.sessionToSrv[sessionID] = srv
+	sessions := make(map[string]string, len(ss.sessionToSrv))
+	for sessionID, b := range ss.sessionToSrv {
+		sessions[sessionID] = b.srv.ID
+	}
+	return sessions
+}
+
+// RestoreSessions re-binds sessions to the servers with the given IDs,
+// skipping servers that are no longer in the pool. It returns how many
+// sessions were restored.
+func (ss *StickySessions) RestoreSessions(sessions map[string]string) int {
+	byID := make(map[string]*server.Server)
+	for _, srv := range ss.ServerManager.GetAllServers() {
+		byID[srv.ID] = srv
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	now := time.Now()
+	restored := 0
+	for sessionID, serverID := range sessions {
+		if srv, ok := byID[serverID]; ok {
+			ss.sessionToSrv[sessionID] = &binding{srv: srv, used: now, announced: now}
+			restored++
+		}
+	}
+	return restored
 }
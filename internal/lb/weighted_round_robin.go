@@ -3,6 +3,7 @@ package lb
 
 import (
 	"sync"
+	"time"
 
 	"load-balancer/internal/server"
 )
@@ -13,6 +14,9 @@ type WeightedRoundRobin struct {
 	ServerManager  *server.Manager
 	currentWeights map[string]float64
 	fallbackIndex  int
+
+	// SlowStart scales down the weight of servers that are still warming up.
+	SlowStart SlowStart
 }
 
 // NewWeightedRoundRobin creates a WeightedRoundRobin instance.
@@ -43,6 +47,7 @@ func (w *WeightedRoundRobin) PickServer(exclude map[string]bool) *server.Server
 
 	// Track which servers currently exist so we can prune removed entries.
 	existing := make(map[string]struct{}, len(servers))
+	now := time.Now()
 
 	for _, srv := range servers {
 		existing[srv.ID] = struct{}{}
@@ -52,17 +57,17 @@ func (w *WeightedRoundRobin) PickServer(exclude map[string]bool) *server.Server
 			continue
 		}
 
-		if !srv.PingStatus {
+		if !server.IsUp(srv) {
 			delete(w.currentWeights, srv.ID)
 			continue
 		}
 
-		if srv.CircuitBreakerState != server.CBStateClosed {
+		if !admitsTraffic(srv) || !server.IsAccepting(srv) {
 			delete(w.currentWeights, srv.ID)
 			continue
 		}
 
-		weight := srv.CurrentWeight
+		weight := server.GetWeight(srv) * w.SlowStart.Factor(srv, now)
 		if weight < 0 {
 			weight = 0
 		}
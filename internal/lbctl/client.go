@@ -0,0 +1,184 @@
+// internal/lbctl/client.go
+package lbctl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	apiv1 "load-balancer/internal/api/v1"
+)
+
+// Client calls a balancer's admin API.
+type Client struct {
+	BaseURL string // e.g. http://localhost:8080
+	Token   string // sent as a bearer token when set
+	HTTP    *http.Client
+}
+
+// NewClient returns a client for the admin API at baseURL.
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Token:   token,
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// APIError is a non-2xx answer, decoded from problem+json when the endpoint
+// speaks it.
+type APIError struct {
+	Status  int
+	Problem *apiv1.Problem
+	Body    string
+}
+
+func (e *APIError) Error() string {
+	if e.Problem != nil {
+		if e.Problem.Detail != "" {
+			return fmt.Sprintf("%s (%d): %s", e.Problem.Title, e.Status, e.Problem.Detail)
+		}
+		return fmt.Sprintf("%s (%d)", e.Problem.Title, e.Status)
+	}
+	return fmt.Sprintf("%s (%d): %s", http.StatusText(e.Status), e.Status, strings.TrimSpace(e.Body))
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return req, nil
+}
+
+// Do sends a JSON request and decodes a JSON answer into out, if non-nil.
+func (c *Client) Do(ctx context.Context, method, path string, body, out interface{}) error {
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return readError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+func readError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	apiErr := &APIError{Status: resp.StatusCode, Body: string(data)}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), apiv1.ProblemContentType) {
+		var problem apiv1.Problem
+		if json.Unmarshal(data, &problem) == nil {
+			apiErr.Problem = &problem
+		}
+	}
+	return apiErr
+}
+
+// Message is one server-sent event.
+type Message struct {
+	ID    string // empty for events without one
+	Event string // "message" when unnamed
+	Data  []byte
+}
+
+// Stream opens a server-sent event stream, resuming after lastEventID when
+// set, and calls fn with each event until ctx ends, the stream closes or fn
+// returns an error.
+func (c *Client) Stream(ctx context.Context, path, lastEventID string, fn func(Message) error) error {
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	// The stream outlives the client's request timeout
+	client := *c.HTTP
+	client.Timeout = 0
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return readError(resp)
+	}
+
+	return readEvents(resp.Body, fn)
+}
+
+// readEvents splits an SSE body into events, joining multi-line data.
+func readEvents(r io.Reader, fn func(Message) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+
+	var msg Message
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, ":") {
+			continue // comment, e.g. a keepalive
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "":
+			if msg.Data != nil {
+				if msg.Event == "" {
+					msg.Event = "message"
+				}
+				if err := fn(msg); err != nil {
+					return err
+				}
+			}
+			msg = Message{}
+		case "data":
+			if msg.Data == nil {
+				msg.Data = []byte{}
+			} else {
+				msg.Data = append(msg.Data, '\n')
+			}
+			msg.Data = append(msg.Data, value...)
+		case "event":
+			msg.Event = value
+		case "id":
+			msg.ID = value
+		}
+	}
+	return scanner.Err()
+}
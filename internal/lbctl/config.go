@@ -0,0 +1,62 @@
+// internal/lbctl/config.go
+package lbctl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DefaultServer is the admin API used when neither a flag nor the config
+// file names one.
+const DefaultServer = "http://localhost:8080"
+
+// Settings is lbctl's config file, by default
+// $XDG_CONFIG_HOME/lbctl/config.json (~/.config/lbctl/config.json):
+//
+//	{"server": "https://lb.internal:8443", "token": "s3cret"}
+type Settings struct {
+	Server string `json:"server,omitempty"`
+	Token  string `json:"token,omitempty"`  // bearer token for an authenticating proxy in front of the API
+	Output string `json:"output,omitempty"` // table or json
+}
+
+// DefaultConfigPath is $LBCTL_CONFIG, or config.json in the user's config
+// directory.
+func DefaultConfigPath() string {
+	if path := os.Getenv("LBCTL_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "lbctl", "config.json")
+}
+
+// LoadSettings reads a config file. A missing file yields empty settings;
+// one readable by other users draws a warning on warn, as it holds a token.
+func LoadSettings(path string, warn io.Writer) (Settings, error) {
+	var s Settings
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if info, err := os.Stat(path); err == nil && s.Token != "" && info.Mode().Perm()&0o077 != 0 {
+		fmt.Fprintf(warn, "warning: %s holds a token but is readable by other users; chmod 600 it\n", path)
+	}
+	return s, nil
+}
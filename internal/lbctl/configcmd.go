@@ -0,0 +1,128 @@
+// internal/lbctl/configcmd.go
+package lbctl
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+
+	apiv1 "load-balancer/internal/api/v1"
+	"load-balancer/internal/config"
+)
+
+// livePool is the part of a GET /api/pools entry config export needs.
+type livePool struct {
+	Name    string                `json:"name"`
+	Config  config.PoolConfig     `json:"config"`
+	Servers []config.ServerConfig `json:"servers"`
+}
+
+func (c *cli) livePools() ([]livePool, error) {
+	var pools []livePool
+	err := c.client.Do(c.ctx, http.MethodGet, "/api/pools", nil, &pools)
+	return pools, err
+}
+
+func (c *cli) configExport(args []string) error {
+	var file string
+	if _, err := c.flags("config export", args, 0, func(fs *flag.FlagSet) {
+		fs.StringVar(&file, "f", "", "write to this file instead of stdout")
+	}); err != nil {
+		return err
+	}
+
+	pools, err := c.livePools()
+	if err != nil {
+		return err
+	}
+	var routing config.RoutingConfig
+	for _, pool := range pools {
+		cfg := pool.Config
+		cfg.Servers = pool.Servers
+		routing.Pools = append(routing.Pools, cfg)
+	}
+	if err := c.client.Do(c.ctx, http.MethodGet, "/api/routes", nil, &routing.Routes); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(routing, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if file == "" {
+		_, err = c.out.Write(data)
+		return err
+	}
+	return os.WriteFile(file, data, 0o644)
+}
+
+func (c *cli) configImport(args []string) error {
+	var file string
+	if _, err := c.flags("config import", args, 0, func(fs *flag.FlagSet) {
+		fs.StringVar(&file, "f", "", "routes file to import (required)")
+	}); err != nil {
+		return err
+	}
+	if file == "" {
+		return fmt.Errorf("config import needs -f file")
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var routing config.RoutingConfig
+	if err := json.Unmarshal(data, &routing); err != nil {
+		return fmt.Errorf("parsing %s: %w", file, err)
+	}
+
+	live, err := c.livePools()
+	if err != nil {
+		return err
+	}
+	existing := make(map[string][]string, len(live))
+	for _, pool := range live {
+		for _, srv := range pool.Servers {
+			existing[pool.Name] = append(existing[pool.Name], srv.ID)
+		}
+		if _, ok := existing[pool.Name]; !ok {
+			existing[pool.Name] = nil
+		}
+	}
+
+	var pools, servers int
+	for _, pool := range routing.Pools {
+		ids, ok := existing[pool.Name]
+		if !ok {
+			if err := c.client.Do(c.ctx, http.MethodPost, "/api/pools", pool, nil); err != nil {
+				return fmt.Errorf("creating pool %s: %w", pool.Name, err)
+			}
+			pools++
+			servers += len(pool.Servers)
+			continue
+		}
+		for _, srv := range pool.Servers {
+			if slices.Contains(ids, srv.ID) {
+				continue
+			}
+			spec := apiv1.ServerSpec{ID: srv.ID, Address: srv.Address, Port: srv.Port}
+			if err := c.client.Do(c.ctx, http.MethodPost, "/api/v1/pools/"+url.PathEscape(pool.Name)+"/servers", spec, nil); err != nil {
+				return fmt.Errorf("adding %s to pool %s: %w", srv.ID, pool.Name, err)
+			}
+			servers++
+		}
+	}
+	for _, route := range routing.Routes {
+		if err := c.client.Do(c.ctx, http.MethodPost, "/api/routes", route, nil); err != nil {
+			return fmt.Errorf("setting route %s: %w", route.Name, err)
+		}
+	}
+
+	fmt.Fprintf(c.out, "Imported %s: %d pool(s) created, %d server(s) added, %d route(s) set\n",
+		file, pools, servers, len(routing.Routes))
+	return nil
+}
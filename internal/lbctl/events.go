@@ -0,0 +1,179 @@
+// internal/lbctl/events.go
+package lbctl
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"load-balancer/internal/events"
+	"load-balancer/internal/metrics"
+)
+
+var (
+	errDone   = errors.New("done")   // -n events have been shown
+	errResume = errors.New("resume") // the stream closed after falling behind
+)
+
+func (c *cli) eventsTail(args []string) error {
+	var (
+		types, server, severity, match string
+		count                          int
+	)
+	if _, err := c.flags("events tail", args, 0, func(fs *flag.FlagSet) {
+		fs.StringVar(&types, "type", "", "comma-separated event types (info, success, warning, error, packet, breaker)")
+		fs.StringVar(&server, "server", "", "only events about this server")
+		fs.StringVar(&severity, "min-severity", "", "only events at least this severe (debug, info, warning, error)")
+		fs.StringVar(&match, "match", "", "only events whose message contains this text")
+		fs.IntVar(&count, "n", 0, "exit after this many events (0 follows forever)")
+	}); err != nil {
+		return err
+	}
+
+	// The balancer filters by type, server and severity; -match is ours
+	query := url.Values{}
+	for key, value := range map[string]string{"types": types, "server": server, "minSeverity": severity} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	path := "/api/events"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	// A stream that falls behind is closed with an overflow notice; pick it
+	// up again from the last event shown
+	shown, lastID := 0, ""
+	for {
+		err := c.client.Stream(c.ctx, path, lastID, func(msg Message) error {
+			switch msg.Event {
+			case "overflow":
+				fmt.Fprintf(c.errOut, "lbctl: fell behind the event stream; resuming after event %s\n", lastID)
+				return errResume
+			case "gap":
+				fmt.Fprintln(c.errOut, "lbctl: some events were lost while reconnecting")
+				return nil
+			}
+			if msg.ID != "" {
+				lastID = msg.ID
+			}
+
+			var ev events.Event
+			if err := json.Unmarshal(msg.Data, &ev); err != nil || !strings.Contains(ev.Message, match) {
+				return nil
+			}
+			if c.json {
+				fmt.Fprintf(c.out, "%s\n", msg.Data)
+			} else {
+				fmt.Fprintf(c.out, "%s  %-7s  %-7s  %s\n", ev.Timestamp.Local().Format(time.TimeOnly), ev.Severity, ev.Type, ev.Message)
+			}
+			if shown++; count > 0 && shown >= count {
+				return errDone
+			}
+			return nil
+		})
+		switch {
+		case errors.Is(err, errResume):
+			continue
+		case errors.Is(err, errDone) || c.ctx.Err() != nil:
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+// metricsReport is the part of /api/metrics lbctl shows, plus latency
+// percentiles over the recent response-time history.
+type metricsReport struct {
+	TotalRequests     int64                           `json:"totalRequests"`
+	ErrorRate         float64                         `json:"errorRate"`
+	AvgResponseTime   float64                         `json:"avgResponseTime"`
+	Latency           map[string]float64              `json:"latencyMs"`
+	RequestsPerServer map[string]int64                `json:"requestsPerServer"`
+	Variants          map[string]metrics.VariantStats `json:"variants,omitempty"`
+	Synthetic         *metrics.SyntheticStats         `json:"synthetic,omitempty"`
+}
+
+var reportPercentiles = []struct {
+	name string
+	p    float64
+}{{"p50", 50}, {"p90", 90}, {"p95", 95}, {"p99", 99}, {"max", 100}}
+
+func (c *cli) metrics(args []string) error {
+	if _, err := c.flags("metrics", args, 0, nil); err != nil {
+		return err
+	}
+	var raw struct {
+		LoadBalancer metrics.LBMetrics               `json:"loadBalancer"`
+		Variants     map[string]metrics.VariantStats `json:"variants"`
+		Synthetic    *metrics.SyntheticStats         `json:"synthetic"`
+	}
+	if err := c.client.Do(c.ctx, http.MethodGet, "/api/metrics", nil, &raw); err != nil {
+		return err
+	}
+
+	lb := raw.LoadBalancer
+	latencies := make([]float64, len(lb.ResponseTimeHistory))
+	for i, dp := range lb.ResponseTimeHistory {
+		latencies[i] = dp.Value
+	}
+	sort.Float64s(latencies)
+	report := metricsReport{
+		TotalRequests:     lb.TotalRequests,
+		ErrorRate:         lb.ErrorRate,
+		AvgResponseTime:   lb.AvgResponseTime,
+		Latency:           make(map[string]float64, len(reportPercentiles)),
+		RequestsPerServer: lb.RequestsPerServer,
+		Variants:          raw.Variants,
+		Synthetic:         raw.Synthetic,
+	}
+	for _, pc := range reportPercentiles {
+		report.Latency[pc.name] = metrics.Percentile(latencies, pc.p)
+	}
+
+	return c.print(report, func(tw *tabwriter.Writer) {
+		fmt.Fprintf(tw, "Requests:\t%d\n", report.TotalRequests)
+		fmt.Fprintf(tw, "Error rate:\t%.1f%%\n", report.ErrorRate*100)
+		fmt.Fprintf(tw, "Average:\t%.1f ms\n", report.AvgResponseTime)
+		fmt.Fprintf(tw, "Latency (last %d):\t", len(latencies))
+		for _, pc := range reportPercentiles {
+			fmt.Fprintf(tw, "%s %.1f ms  ", pc.name, report.Latency[pc.name])
+		}
+		fmt.Fprintln(tw)
+		if report.Synthetic != nil {
+			fmt.Fprintf(tw, "Synthetic:\t%d\n", report.Synthetic.Requests)
+		}
+
+		ids := make([]string, 0, len(report.RequestsPerServer))
+		for id := range report.RequestsPerServer {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		fmt.Fprintln(tw, "\nSERVER\tREQUESTS")
+		for _, id := range ids {
+			fmt.Fprintf(tw, "%s\t%d\n", id, report.RequestsPerServer[id])
+		}
+
+		if len(report.Variants) > 0 {
+			names := make([]string, 0, len(report.Variants))
+			for name := range report.Variants {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			fmt.Fprintln(tw, "\nVARIANT\tREQUESTS\tERRORS\tP50\tP95\tP99")
+			for _, name := range names {
+				v := report.Variants[name]
+				fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%.1f\t%.1f\n", name, v.Requests, v.Errors, v.P50, v.P95, v.P99)
+			}
+		}
+	})
+}
@@ -0,0 +1,157 @@
+// internal/lbctl/lbctl.go
+package lbctl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// command is one lbctl subcommand, named by one or two words.
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(c *cli, args []string) error
+}
+
+var commands = []command{
+	{"servers list", "[-pool name]", "list servers with state, weight and load", (*cli).serversList},
+	{"servers add", "<pool> <id> <host:port>", "add a server to a pool", (*cli).serversAdd},
+	{"servers remove", "<id>", "remove a server from its pool", (*cli).serversRemove},
+	{"servers drain", "[-timeout 45s] <id>", "stop new traffic and let in-flight requests finish", serverAction("drain")},
+	{"servers enable", "<id>", "mark a server up", serverAction("enable")},
+	{"servers disable", "<id>", "mark a server down", serverAction("disable")},
+	{"servers maintenance", "<id>", "take a server out of rotation now", serverAction("maintenance")},
+	{"servers activate", "<id>", "return a drained or maintenance server to rotation", serverAction("activate")},
+	{"servers reset-breaker", "<id>", "close a server's circuit breaker", serverAction("reset-breaker")},
+	{"pools list", "", "list pools", (*cli).poolsList},
+	{"pools set-strategy", "<pool> <strategy>", "switch to weighted-round-robin, least-connections or ip-hash", (*cli).poolsSetStrategy},
+	{"pools set-sticky", "<pool> on|off", "turn sticky sessions on or off", (*cli).poolsSetSticky},
+	{"events tail", "[-type t1,t2] [-server id] [-min-severity s] [-match text] [-n count]", "follow the event stream", (*cli).eventsTail},
+	{"metrics", "", "show request totals, error rate and latency percentiles", (*cli).metrics},
+	{"config export", "[-f file]", "write pools and routes in LB_ROUTES_FILE format", (*cli).configExport},
+	{"config import", "-f file", "create missing pools, servers and routes from a routes file", (*cli).configImport},
+}
+
+// cli is the state shared by every command.
+type cli struct {
+	ctx    context.Context
+	client *Client
+	out    io.Writer
+	errOut io.Writer
+	json   bool
+}
+
+// Run parses global flags, loads the config file and runs a subcommand.
+func Run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("lbctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var (
+		configPath = fs.String("config", DefaultConfigPath(), "config file with server, token and output")
+		server     = fs.String("server", "", "admin API base URL (default from config, else "+DefaultServer+")")
+		token      = fs.String("token", "", "bearer token (default from config)")
+		output     = fs.String("o", "", "output format: table or json (default from config, else table)")
+	)
+	fs.Usage = func() { usage(stderr, fs) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	settings, err := LoadSettings(*configPath, stderr)
+	if err != nil {
+		return err
+	}
+	if *server == "" {
+		*server = settings.Server
+	}
+	if *server == "" {
+		*server = DefaultServer
+	}
+	if *token == "" {
+		*token = settings.Token
+	}
+	if *output == "" {
+		*output = settings.Output
+	}
+	if *output != "" && *output != "table" && *output != "json" {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+
+	cmd, rest := findCommand(fs.Args())
+	if cmd == nil {
+		fs.Usage()
+		if len(fs.Args()) == 0 {
+			return flag.ErrHelp
+		}
+		return fmt.Errorf("unknown command %q", strings.Join(fs.Args(), " "))
+	}
+
+	c := &cli{
+		ctx:    ctx,
+		client: NewClient(*server, *token),
+		out:    stdout,
+		errOut: stderr,
+		json:   *output == "json",
+	}
+	return cmd.run(c, rest)
+}
+
+func findCommand(args []string) (*command, []string) {
+	for i := range commands {
+		words := strings.Fields(commands[i].name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == commands[i].name {
+			return &commands[i], args[len(words):]
+		}
+	}
+	return nil, nil
+}
+
+func usage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: lbctl [flags] <command> [args]\n\nCommands:\n")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s %s\t%s\n", cmd.name, cmd.args, cmd.summary)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\nFlags:\n")
+	fs.PrintDefaults()
+}
+
+// flags parses a command's own flags, requiring exactly n positional args.
+func (c *cli) flags(name string, args []string, n int, define func(fs *flag.FlagSet)) ([]string, error) {
+	fs := flag.NewFlagSet("lbctl "+name, flag.ContinueOnError)
+	fs.SetOutput(c.errOut)
+	if define != nil {
+		define(fs)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != n {
+		return nil, fmt.Errorf("%s takes %d argument(s), got %d", name, n, fs.NArg())
+	}
+	return fs.Args(), nil
+}
+
+// print writes v as JSON, or as the table table draws.
+func (c *cli) print(v interface{}, table func(tw *tabwriter.Writer)) error {
+	if c.json {
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	table(tw)
+	return tw.Flush()
+}
+
+// IsUsage reports whether err only means usage was printed.
+func IsUsage(err error) bool {
+	return errors.Is(err, flag.ErrHelp)
+}
@@ -0,0 +1,95 @@
+package lbctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	apiv1 "load-balancer/internal/api/v1"
+	"load-balancer/internal/config"
+	"load-balancer/internal/harness"
+)
+
+func run(t *testing.T, h *harness.Harness, args ...string) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	args = append([]string{"-config", "", "-server", h.URL}, args...)
+	if err := Run(ctx, args, &stdout, &stderr); err != nil {
+		t.Fatalf("lbctl %s: %v\n%s", strings.Join(args[4:], " "), err, stderr.String())
+	}
+	return stdout.String()
+}
+
+func TestLbctl_ServersAndConfig(t *testing.T) {
+	h := harness.Start(t, harness.Options{})
+
+	var list apiv1.ServerList
+	if err := json.Unmarshal([]byte(run(t, h, "-o", "json", "servers", "list")), &list); err != nil || len(list.Items) != 3 {
+		t.Fatalf("servers list -o json: %v, %d servers", err, len(list.Items))
+	}
+	if out := run(t, h, "servers", "list"); !strings.HasPrefix(out, "ID") || !strings.Contains(out, "server-2") {
+		t.Errorf("servers list table:\n%s", out)
+	}
+
+	var srv apiv1.Server
+	json.Unmarshal([]byte(run(t, h, "-o", "json", "servers", "drain", "-timeout", "1s", "server-1")), &srv)
+	if srv.AdminState != apiv1.AdminDraining {
+		t.Errorf("drain: admin state %q", srv.AdminState)
+	}
+
+	run(t, h, "pools", "set-strategy", "default", "least-connections")
+	file := filepath.Join(t.TempDir(), "routes.json")
+	run(t, h, "config", "export", "-f", file)
+	data, _ := os.ReadFile(file)
+	var routing config.RoutingConfig
+	if err := json.Unmarshal(data, &routing); err != nil {
+		t.Fatalf("exported config: %v\n%s", err, data)
+	}
+	if len(routing.Pools) != 1 || len(routing.Pools[0].Servers) != 3 ||
+		routing.Pools[0].Strategy != "least-connections" || len(routing.Routes) != 1 {
+		t.Errorf("exported config: %s", data)
+	}
+
+	routing.Pools[0].Servers = append(routing.Pools[0].Servers, config.ServerConfig{ID: "server-4", Address: "127.0.0.1", Port: 1})
+	data, _ = json.Marshal(routing)
+	os.WriteFile(file, data, 0o600)
+	if out := run(t, h, "config", "import", "-f", file); !strings.Contains(out, "1 server(s) added") {
+		t.Errorf("config import: %s", out)
+	}
+
+	if out := run(t, h, "events", "tail", "-type", "info", "-match", "Connected", "-n", "1"); !strings.Contains(out, "Connected to event stream") {
+		t.Errorf("events tail: %q", out)
+	}
+}
+
+func TestLoadSettings_WarnsOnReadableToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"server":"http://lb:9000","token":"s3cret"}`), 0o644)
+
+	var warn bytes.Buffer
+	s, err := LoadSettings(path, &warn)
+	if err != nil || s.Server != "http://lb:9000" || s.Token != "s3cret" {
+		t.Fatalf("LoadSettings: %+v, %v", s, err)
+	}
+	if !strings.Contains(warn.String(), "chmod 600") {
+		t.Errorf("no warning for a world-readable token: %q", warn.String())
+	}
+
+	os.Chmod(path, 0o600)
+	warn.Reset()
+	LoadSettings(path, &warn)
+	if warn.Len() != 0 {
+		t.Errorf("warning for a private config: %q", warn.String())
+	}
+
+	if s, err := LoadSettings(filepath.Join(t.TempDir(), "missing.json"), &warn); err != nil || s != (Settings{}) {
+		t.Errorf("missing config: %+v, %v", s, err)
+	}
+}
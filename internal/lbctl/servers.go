@@ -0,0 +1,161 @@
+// internal/lbctl/servers.go
+package lbctl
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"text/tabwriter"
+
+	apiv1 "load-balancer/internal/api/v1"
+)
+
+func (c *cli) serversList(args []string) error {
+	var pool string
+	if _, err := c.flags("servers list", args, 0, func(fs *flag.FlagSet) {
+		fs.StringVar(&pool, "pool", "", "only servers in this pool")
+	}); err != nil {
+		return err
+	}
+
+	path := "/api/v1/servers"
+	if pool != "" {
+		path += "?pool=" + url.QueryEscape(pool)
+	}
+	var list apiv1.ServerList
+	if err := c.client.Do(c.ctx, http.MethodGet, path, nil, &list); err != nil {
+		return err
+	}
+	return c.print(list, func(tw *tabwriter.Writer) { serverTable(tw, list.Items...) })
+}
+
+func serverTable(tw *tabwriter.Writer, servers ...apiv1.Server) {
+	fmt.Fprintln(tw, "ID\tPOOL\tADDRESS\tSTATE\tADMIN\tBREAKER\tWEIGHT\tACTIVE\tRESP MS\tERRORS")
+	for _, s := range servers {
+		fmt.Fprintf(tw, "%s\t%s\t%s:%d\t%s\t%s\t%s\t%.1f%%\t%d\t%.0f\t%.1f%%\n",
+			s.ID, s.Pool, s.Address, s.Port, s.State, s.AdminState, s.Breaker.State,
+			s.Weight*100, s.ActiveRequests, s.ResponseTimeMs, s.ErrorRate*100)
+	}
+}
+
+func (c *cli) serversAdd(args []string) error {
+	args, err := c.flags("servers add", args, 3, nil)
+	if err != nil {
+		return err
+	}
+	host, rawPort, err := net.SplitHostPort(args[2])
+	if err != nil {
+		return fmt.Errorf("address must be host:port: %w", err)
+	}
+	port, err := strconv.Atoi(rawPort)
+	if err != nil {
+		return fmt.Errorf("invalid port %q", rawPort)
+	}
+
+	var srv apiv1.Server
+	spec := apiv1.ServerSpec{ID: args[1], Address: host, Port: port}
+	if err := c.client.Do(c.ctx, http.MethodPost, "/api/v1/pools/"+url.PathEscape(args[0])+"/servers", spec, &srv); err != nil {
+		return err
+	}
+	return c.print(srv, func(tw *tabwriter.Writer) { serverTable(tw, srv) })
+}
+
+func (c *cli) serversRemove(args []string) error {
+	args, err := c.flags("servers remove", args, 1, nil)
+	if err != nil {
+		return err
+	}
+	var srv apiv1.Server
+	if err := c.client.Do(c.ctx, http.MethodGet, "/api/v1/servers/"+url.PathEscape(args[0]), nil, &srv); err != nil {
+		return err
+	}
+	path := "/api/v1/pools/" + url.PathEscape(srv.Pool) + "/servers/" + url.PathEscape(srv.ID)
+	if err := c.client.Do(c.ctx, http.MethodDelete, path, nil, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.errOut, "Removed %s from pool %s\n", srv.ID, srv.Pool)
+	return nil
+}
+
+// serverAction runs one of the /api/v1/servers/{id}/{action} state changes.
+func serverAction(action string) func(c *cli, args []string) error {
+	return func(c *cli, args []string) error {
+		var timeout string
+		args, err := c.flags("servers "+action, args, 1, func(fs *flag.FlagSet) {
+			if action == "drain" {
+				fs.StringVar(&timeout, "timeout", "", "how long to wait for in-flight requests (default: the balancer's DRAIN_TIMEOUT)")
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		path := "/api/v1/servers/" + url.PathEscape(args[0]) + "/" + action
+		if timeout != "" {
+			path += "?timeout=" + url.QueryEscape(timeout)
+		}
+		var srv apiv1.Server
+		if err := c.client.Do(c.ctx, http.MethodPost, path, nil, &srv); err != nil {
+			return err
+		}
+		return c.print(srv, func(tw *tabwriter.Writer) { serverTable(tw, srv) })
+	}
+}
+
+func (c *cli) poolsList(args []string) error {
+	if _, err := c.flags("pools list", args, 0, nil); err != nil {
+		return err
+	}
+	var list apiv1.PoolList
+	if err := c.client.Do(c.ctx, http.MethodGet, "/api/v1/pools", nil, &list); err != nil {
+		return err
+	}
+	return c.print(list, func(tw *tabwriter.Writer) { poolTable(tw, list.Items...) })
+}
+
+func poolTable(tw *tabwriter.Writer, pools ...apiv1.Pool) {
+	fmt.Fprintln(tw, "NAME\tSTRATEGY\tSTICKY\tHEALTH CHECK\tSERVERS")
+	for _, p := range pools {
+		check := "simulated"
+		if p.HealthCheckPath != "" {
+			check = p.HealthCheckPath
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%s every %s\t%d\n",
+			p.Name, p.Strategy, p.StickySessions, check, p.HealthCheckInterval, len(p.Servers))
+	}
+}
+
+func (c *cli) poolsSetStrategy(args []string) error {
+	args, err := c.flags("pools set-strategy", args, 2, nil)
+	if err != nil {
+		return err
+	}
+	return c.patchPool(args[0], apiv1.PoolPatch{Strategy: &args[1]})
+}
+
+func (c *cli) poolsSetSticky(args []string) error {
+	args, err := c.flags("pools set-sticky", args, 2, nil)
+	if err != nil {
+		return err
+	}
+	var sticky bool
+	switch args[1] {
+	case "on", "true":
+		sticky = true
+	case "off", "false":
+	default:
+		return fmt.Errorf("set-sticky takes on or off, not %q", args[1])
+	}
+	return c.patchPool(args[0], apiv1.PoolPatch{StickySessions: &sticky})
+}
+
+func (c *cli) patchPool(name string, patch apiv1.PoolPatch) error {
+	var pool apiv1.Pool
+	if err := c.client.Do(c.ctx, http.MethodPatch, "/api/v1/pools/"+url.PathEscape(name), patch, &pool); err != nil {
+		return err
+	}
+	return c.print(pool, func(tw *tabwriter.Writer) { poolTable(tw, pool) })
+}
@@ -0,0 +1,214 @@
+// internal/lifecycle/lifecycle.go
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// idlePollInterval is how often WaitIdle re-checks the in-flight count.
+const idlePollInterval = 50 * time.Millisecond
+
+// Phase is the balancer's position in its lifecycle.
+type Phase string
+
+const (
+	PhaseStarting Phase = "starting"
+	PhaseServing  Phase = "serving"
+	PhaseStopping Phase = "stopping" // not ready, still serving during the pre-stop delay
+	PhaseDraining Phase = "draining" // listener closed, waiting for in-flight requests
+	PhaseStopped  Phase = "stopped"
+)
+
+// Lifecycle tracks the balancer's own readiness and its in-flight proxied
+// requests so shutdown can fail readiness first and then drain.
+type Lifecycle struct {
+	mu        sync.Mutex
+	phase     Phase
+	started   time.Time
+	draining  chan struct{}
+	drainOnce sync.Once
+	checks    []readinessCheck
+
+	inFlight atomic.Int64
+	served   atomic.Int64
+}
+
+// New returns a lifecycle in the starting phase.
+func New() *Lifecycle {
+	return &Lifecycle{
+		phase:    PhaseStarting,
+		started:  time.Now(),
+		draining: make(chan struct{}),
+	}
+}
+
+// Phase returns the current phase.
+func (l *Lifecycle) Phase() Phase {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.phase
+}
+
+func (l *Lifecycle) setPhase(phase Phase) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.phase = phase
+}
+
+// MarkReady reports the balancer ready once it is listening.
+func (l *Lifecycle) MarkReady() {
+	l.setPhase(PhaseServing)
+}
+
+type readinessCheck struct {
+	name  string
+	ready func() bool
+}
+
+// AddReadinessCheck makes readiness also depend on check, reported by name
+// in /readyz while it fails.
+func (l *Lifecycle) AddReadinessCheck(name string, check func() bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.checks = append(l.checks, readinessCheck{name: name, ready: check})
+}
+
+// Ready reports whether the balancer should receive new traffic.
+func (l *Lifecycle) Ready() bool {
+	return l.Phase() == PhaseServing && len(l.blockedBy()) == 0
+}
+
+// blockedBy returns the readiness checks that currently fail.
+func (l *Lifecycle) blockedBy() []string {
+	l.mu.Lock()
+	checks := l.checks
+	l.mu.Unlock()
+
+	var blocked []string
+	for _, check := range checks {
+		if !check.ready() {
+			blocked = append(blocked, check.name)
+		}
+	}
+	return blocked
+}
+
+// BeginShutdown fails readiness while requests keep being served, giving
+// upstream orchestrators time to stop routing here.
+func (l *Lifecycle) BeginShutdown() {
+	l.setPhase(PhaseStopping)
+}
+
+// BeginDrain marks the listener closed; long-lived streams should end.
+func (l *Lifecycle) BeginDrain() {
+	l.setPhase(PhaseDraining)
+	l.drainOnce.Do(func() { close(l.draining) })
+}
+
+// Draining is closed once BeginDrain has been called.
+func (l *Lifecycle) Draining() <-chan struct{} {
+	return l.draining
+}
+
+// MarkStopped records the end of shutdown.
+func (l *Lifecycle) MarkStopped() {
+	l.setPhase(PhaseStopped)
+}
+
+// InFlight returns the number of proxied requests currently running.
+func (l *Lifecycle) InFlight() int64 {
+	return l.inFlight.Load()
+}
+
+// Served returns the number of proxied requests completed so far.
+func (l *Lifecycle) Served() int64 {
+	return l.served.Load()
+}
+
+// Track counts requests through next as in flight.
+func (l *Lifecycle) Track(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l.inFlight.Add(1)
+		defer func() {
+			l.inFlight.Add(-1)
+			l.served.Add(1)
+		}()
+		next(w, r)
+	}
+}
+
+// WaitIdle blocks until no tracked request is in flight or ctx ends, and
+// returns how many requests were still running.
+func (l *Lifecycle) WaitIdle(ctx context.Context) int64 {
+	ticker := time.NewTicker(idlePollInterval)
+	defer ticker.Stop()
+
+	for {
+		n := l.InFlight()
+		if n <= 0 {
+			return 0
+		}
+		select {
+		case <-ctx.Done():
+			return l.InFlight()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status is the body of /healthz and /readyz.
+type Status struct {
+	Status    string   `json:"status"`
+	Phase     Phase    `json:"phase"`
+	InFlight  int64    `json:"inFlight"`
+	Uptime    float64  `json:"uptimeSeconds"`
+	BlockedBy []string `json:"blockedBy,omitempty"`
+}
+
+func (l *Lifecycle) status(ok bool) Status {
+	l.mu.Lock()
+	phase, started := l.phase, l.started
+	l.mu.Unlock()
+
+	status := "ok"
+	if !ok {
+		status = "unavailable"
+	}
+	return Status{
+		Status:    status,
+		Phase:     phase,
+		InFlight:  l.InFlight(),
+		Uptime:    time.Since(started).Seconds(),
+		BlockedBy: l.blockedBy(),
+	}
+}
+
+// HealthzHandler reports liveness: the process is up until it has stopped.
+func (l *Lifecycle) HealthzHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ok := l.Phase() != PhaseStopped
+		writeStatus(w, ok, l.status(ok))
+	}
+}
+
+// ReadyzHandler reports readiness: only while serving normally.
+func (l *Lifecycle) ReadyzHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ok := l.Ready()
+		writeStatus(w, ok, l.status(ok))
+	}
+}
+
+func writeStatus(w http.ResponseWriter, ok bool, status Status) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,79 @@
+package lifecycle
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestShutdown_FailsReadinessThenDrains(t *testing.T) {
+	lc := New()
+	release := make(chan struct{})
+	entered := make(chan struct{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/readyz", lc.ReadyzHandler())
+	mux.HandleFunc("/slow", lc.Track(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		io.WriteString(w, "done")
+	}))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(listener)
+	lc.MarkReady()
+	base := "http://" + listener.Addr().String()
+
+	slowResult := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(base + "/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+		slowResult <- err
+	}()
+	<-entered
+
+	done := make(chan Summary, 1)
+	go func() {
+		done <- lc.Shutdown(context.Background(), srv, ShutdownOptions{
+			PreStopDelay:  200 * time.Millisecond,
+			DrainDeadline: 5 * time.Second,
+		}, nil)
+	}()
+
+	// During the pre-stop delay the listener is open but readiness fails.
+	time.Sleep(50 * time.Millisecond)
+	rec := httptest.NewRecorder()
+	lc.ReadyzHandler()(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("readyz during shutdown = %d, want 503", rec.Code)
+	}
+	resp, err := http.Get(base + "/readyz")
+	if err != nil {
+		t.Fatalf("listener closed during pre-stop delay: %v", err)
+	}
+	resp.Body.Close()
+
+	time.Sleep(250 * time.Millisecond)
+	close(release)
+
+	summary := <-done
+	if err := <-slowResult; err != nil {
+		t.Fatalf("in-flight request failed: %v", err)
+	}
+	if summary.InFlight != 1 || summary.Abandoned != 0 || summary.Served != 1 {
+		t.Fatalf("summary = %s, want one request drained", summary)
+	}
+	if lc.Phase() != PhaseStopped {
+		t.Fatalf("phase = %s, want stopped", lc.Phase())
+	}
+}
@@ -0,0 +1,83 @@
+// internal/lifecycle/shutdown.go
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ShutdownOptions controls the shutdown sequence.
+type ShutdownOptions struct {
+	PreStopDelay  time.Duration // readiness fails this long before the listener closes
+	DrainDeadline time.Duration // how long in-flight requests get to finish
+}
+
+// Summary describes how a shutdown went.
+type Summary struct {
+	Served        int64 // proxied requests completed over the process lifetime
+	InFlight      int64 // proxied requests running when draining began
+	Abandoned     int64 // proxied requests still running at the drain deadline
+	PreStopDelay  time.Duration
+	DrainDuration time.Duration
+	Total         time.Duration
+	ServerErr     error
+}
+
+func (s Summary) String() string {
+	text := fmt.Sprintf("served=%d in-flight at drain=%d drained=%d abandoned=%d pre-stop=%v drain=%v total=%v",
+		s.Served, s.InFlight, s.InFlight-s.Abandoned, s.Abandoned,
+		s.PreStopDelay, s.DrainDuration.Round(time.Millisecond), s.Total.Round(time.Millisecond))
+	if s.ServerErr != nil {
+		text += fmt.Sprintf(" server error=%v", s.ServerErr)
+	}
+	return text
+}
+
+// Shutdown fails readiness, waits out the pre-stop delay, closes the listener
+// and drains in-flight requests until the deadline, then calls stopLoops to
+// cancel the background goroutines. A cancelled ctx skips the remaining waits.
+func (l *Lifecycle) Shutdown(ctx context.Context, srv *http.Server, opts ShutdownOptions, stopLoops func()) Summary {
+	start := time.Now()
+	summary := Summary{PreStopDelay: opts.PreStopDelay}
+
+	l.BeginShutdown()
+	if opts.PreStopDelay > 0 {
+		select {
+		case <-time.After(opts.PreStopDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	drainStart := time.Now()
+	summary.InFlight = l.InFlight()
+	l.BeginDrain()
+
+	drainCtx := ctx
+	if opts.DrainDeadline > 0 {
+		var cancel context.CancelFunc
+		drainCtx, cancel = context.WithTimeout(ctx, opts.DrainDeadline)
+		defer cancel()
+	}
+
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- srv.Shutdown(drainCtx) }()
+
+	summary.Abandoned = l.WaitIdle(drainCtx)
+	if err := <-shutdownErr; err != nil {
+		// Deadline reached with connections still open: cut them off.
+		summary.ServerErr = err
+		srv.Close()
+	}
+	summary.DrainDuration = time.Since(drainStart)
+
+	if stopLoops != nil {
+		stopLoops()
+	}
+	l.MarkStopped()
+
+	summary.Served = l.Served()
+	summary.Total = time.Since(start)
+	return summary
+}
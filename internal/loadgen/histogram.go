@@ -0,0 +1,189 @@
+// internal/loadgen/histogram.go
+package loadgen
+
+import (
+	"math"
+	"math/bits"
+)
+
+// Histogram is an HDR (high dynamic range) histogram of integer values. It
+// keeps a fixed number of significant digits at every magnitude, so a
+// 1µs..1min latency range costs a few thousand counters and any recorded
+// value is reproduced to within 0.1% at 3 significant digits.
+type Histogram struct {
+	lowest  int64
+	highest int64
+
+	unitMagnitude        uint
+	subBucketHalfCountMg uint
+	subBucketCount       int64
+	subBucketHalfCount   int64
+	subBucketMask        int64
+
+	counts []int64
+	total  int64
+	min    int64
+	max    int64
+	sum    float64
+}
+
+// NewHistogram tracks values from lowest (at least 1) to highest with
+// sigFigs (1-5) significant decimal digits. Larger values are clamped.
+func NewHistogram(lowest, highest int64, sigFigs int) *Histogram {
+	if lowest < 1 {
+		lowest = 1
+	}
+	if highest < 2*lowest {
+		highest = 2 * lowest
+	}
+	if sigFigs < 1 {
+		sigFigs = 1
+	} else if sigFigs > 5 {
+		sigFigs = 5
+	}
+
+	largestSingleUnit := 2 * int64(math.Pow10(sigFigs))
+	subBucketCountMg := uint(math.Ceil(math.Log2(float64(largestSingleUnit))))
+	unitMagnitude := uint(bits.Len64(uint64(lowest)) - 1)
+
+	h := &Histogram{
+		lowest:               lowest,
+		highest:              highest,
+		unitMagnitude:        unitMagnitude,
+		subBucketHalfCountMg: subBucketCountMg - 1,
+		subBucketCount:       1 << subBucketCountMg,
+		subBucketHalfCount:   1 << (subBucketCountMg - 1),
+		min:                  math.MaxInt64,
+	}
+	h.subBucketMask = (h.subBucketCount - 1) << unitMagnitude
+
+	// Each bucket doubles the range of the one before it
+	buckets := 1
+	for smallestUntracked := h.subBucketCount << unitMagnitude; smallestUntracked <= highest; smallestUntracked <<= 1 {
+		buckets++
+		if smallestUntracked > math.MaxInt64/2 {
+			break
+		}
+	}
+	h.counts = make([]int64, (buckets+1)*int(h.subBucketHalfCount))
+	return h
+}
+
+// Record adds one value.
+func (h *Histogram) Record(v int64) {
+	if v < 0 {
+		v = 0
+	}
+	if v > h.highest {
+		v = h.highest
+	}
+	h.counts[h.countsIndex(v)]++
+	h.total++
+	h.sum += float64(v)
+	if v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+}
+
+// Merge adds every value recorded in o, which must share h's layout.
+func (h *Histogram) Merge(o *Histogram) {
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+	h.total += o.total
+	h.sum += o.sum
+	if o.total > 0 {
+		if o.min < h.min {
+			h.min = o.min
+		}
+		if o.max > h.max {
+			h.max = o.max
+		}
+	}
+}
+
+// Count is the number of recorded values.
+func (h *Histogram) Count() int64 { return h.total }
+
+// Min is the smallest recorded value, or 0.
+func (h *Histogram) Min() int64 {
+	if h.total == 0 {
+		return 0
+	}
+	return h.min
+}
+
+// Max is the largest recorded value, or 0.
+func (h *Histogram) Max() int64 { return h.max }
+
+// Mean is the average recorded value, or 0.
+func (h *Histogram) Mean() float64 {
+	if h.total == 0 {
+		return 0
+	}
+	return h.sum / float64(h.total)
+}
+
+// ValueAt returns the value below which percentile (0-100) of the recorded
+// values fall, reported as the highest value equivalent to its bucket.
+func (h *Histogram) ValueAt(percentile float64) int64 {
+	if h.total == 0 {
+		return 0
+	}
+	if percentile > 100 {
+		percentile = 100
+	}
+	target := int64(percentile/100*float64(h.total) + 0.5)
+	if target < 1 {
+		target = 1
+	}
+
+	var seen int64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= target {
+			v := h.highestEquivalent(h.valueFromIndex(i))
+			if v > h.max {
+				v = h.max
+			}
+			return v
+		}
+	}
+	return h.max
+}
+
+func (h *Histogram) bucketIndex(v int64) int {
+	pow2Ceiling := 64 - bits.LeadingZeros64(uint64(v|h.subBucketMask))
+	return pow2Ceiling - int(h.unitMagnitude) - int(h.subBucketHalfCountMg+1)
+}
+
+func (h *Histogram) countsIndex(v int64) int {
+	bucket := h.bucketIndex(v)
+	sub := v >> (uint(bucket) + h.unitMagnitude)
+	return int(int64(bucket+1)<<h.subBucketHalfCountMg + sub - h.subBucketHalfCount)
+}
+
+func (h *Histogram) valueFromIndex(i int) int64 {
+	bucket := i>>h.subBucketHalfCountMg - 1
+	sub := int64(i)&(h.subBucketHalfCount-1) + h.subBucketHalfCount
+	if bucket < 0 {
+		sub -= h.subBucketHalfCount
+		bucket = 0
+	}
+	return sub << (uint(bucket) + h.unitMagnitude)
+}
+
+// highestEquivalent is the largest value that shares v's counter.
+func (h *Histogram) highestEquivalent(v int64) int64 {
+	bucket := h.bucketIndex(v)
+	shift := uint(bucket) + h.unitMagnitude
+	sub := v >> shift
+	lowest := sub << shift
+	if sub >= h.subBucketCount {
+		shift++
+	}
+	return lowest + int64(1)<<shift - 1
+}
@@ -0,0 +1,60 @@
+package loadgen
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHistogram_PercentilesWithinPrecision(t *testing.T) {
+	h := NewHistogram(1, 3600_000_000, 3)
+	for v := int64(1); v <= 100_000; v++ {
+		h.Record(v)
+	}
+
+	for _, tc := range []struct {
+		p    float64
+		want float64
+	}{{50, 50_000}, {90, 90_000}, {99, 99_000}, {99.9, 99_900}, {100, 100_000}} {
+		got := float64(h.ValueAt(tc.p))
+		if math.Abs(got-tc.want)/tc.want > 0.001 {
+			t.Errorf("p%g = %v, want %v within 0.1%%", tc.p, got, tc.want)
+		}
+	}
+	if h.Min() != 1 || h.Max() != 100_000 || h.Count() != 100_000 {
+		t.Errorf("min/max/count = %d/%d/%d", h.Min(), h.Max(), h.Count())
+	}
+	if mean := h.Mean(); math.Abs(mean-50_000.5) > 0.01 {
+		t.Errorf("mean = %v", mean)
+	}
+}
+
+func TestHistogram_SmallValuesAreExact(t *testing.T) {
+	h := NewHistogram(1, 1_000_000, 3)
+	for _, v := range []int64{3, 7, 7, 1500} {
+		h.Record(v)
+	}
+	if got := h.ValueAt(25); got != 3 {
+		t.Errorf("p25 = %d, want 3", got)
+	}
+	if got := h.ValueAt(50); got != 7 {
+		t.Errorf("p50 = %d, want 7", got)
+	}
+	if got := h.ValueAt(100); got != 1500 {
+		t.Errorf("p100 = %d, want 1500", got)
+	}
+}
+
+func TestHistogram_MergeAndClamp(t *testing.T) {
+	a := NewHistogram(1, 1000, 2)
+	b := NewHistogram(1, 1000, 2)
+	a.Record(10)
+	b.Record(5000) // beyond highest, clamped
+	a.Merge(b)
+
+	if a.Count() != 2 || a.Min() != 10 || a.Max() != 1000 {
+		t.Errorf("merged count/min/max = %d/%d/%d", a.Count(), a.Min(), a.Max())
+	}
+	if got := NewHistogram(1, 1000, 2).ValueAt(50); got != 0 {
+		t.Errorf("empty p50 = %d", got)
+	}
+}
@@ -0,0 +1,281 @@
+// internal/loadgen/loadgen.go
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Options describe a load run.
+type Options struct {
+	Target      string             // balancer base URL, e.g. http://localhost:8080
+	Path        string             // request path; default /lb/
+	Method      string             // default GET
+	Body        []byte             // request body, sent with every request
+	ContentType string             // Content-Type for Body
+	RPS         float64            // starting request rate
+	RampTo      float64            // rate reached at the end of Duration; 0 keeps RPS
+	Duration    time.Duration      // how long to send for
+	Priorities  map[string]float64 // X-Task-Priority -> relative weight; none sends no header
+	Sessions    int                // distinct session_id cookies to spread over; 0 sends none
+	ClientIPs   int                // distinct X-Forwarded-For addresses to spread over; 0 sends none
+	Timeout     time.Duration      // per-request timeout; default 10s
+	MaxInFlight int                // requests in flight before sends are dropped; default 1000
+	Seed        int64              // seed for the session/IP/priority draw; 0 uses the clock
+}
+
+func (o *Options) validate() error {
+	if o.Target == "" {
+		return fmt.Errorf("target is required")
+	}
+	if o.RPS <= 0 {
+		return fmt.Errorf("rps must be positive")
+	}
+	if o.RampTo < 0 {
+		return fmt.Errorf("ramp target must not be negative")
+	}
+	if o.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	for priority, weight := range o.Priorities {
+		if weight < 0 {
+			return fmt.Errorf("negative weight for priority %s", priority)
+		}
+	}
+	if o.Sessions < 0 || o.ClientIPs < 0 {
+		return fmt.Errorf("session and client IP populations must not be negative")
+	}
+	if o.ClientIPs > 1<<24 {
+		return fmt.Errorf("at most %d client IPs", 1<<24)
+	}
+
+	o.Target = strings.TrimSuffix(o.Target, "/")
+	if o.Path == "" {
+		o.Path = "/lb/"
+	}
+	if o.Method == "" {
+		o.Method = http.MethodGet
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	if o.MaxInFlight <= 0 {
+		o.MaxInFlight = 1000
+	}
+	if o.Seed == 0 {
+		o.Seed = time.Now().UnixNano()
+	}
+	return nil
+}
+
+// rateAt is the target request rate elapsed into the run.
+func (o *Options) rateAt(elapsed time.Duration) float64 {
+	if o.RampTo == 0 {
+		return o.RPS
+	}
+	frac := float64(elapsed) / float64(o.Duration)
+	if frac > 1 {
+		frac = 1
+	}
+	return o.RPS + (o.RampTo-o.RPS)*frac
+}
+
+// ParseMix parses a priority mix such as "high=1,normal=3".
+func ParseMix(s string) (map[string]float64, error) {
+	mix := make(map[string]float64)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, raw, ok := strings.Cut(part, "=")
+		if !ok {
+			raw = "1"
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight for priority %q", name)
+		}
+		mix[strings.TrimSpace(name)] = weight
+	}
+	return mix, nil
+}
+
+// result is the outcome of one request.
+type result struct {
+	server  string
+	status  int
+	latency time.Duration
+	err     error
+}
+
+// Run sends open-loop traffic: requests leave on schedule whether or not
+// earlier ones have answered, and latency is measured from each request's
+// scheduled send time so a stalled balancer can't hide its queueing delay.
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = opts.MaxInFlight
+	client := &http.Client{Timeout: opts.Timeout, Transport: transport}
+	defer transport.CloseIdleConnections()
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	priorities := newMix(opts.Priorities)
+	rec := newRecorder()
+
+	var wg sync.WaitGroup
+	inFlight := make(chan struct{}, opts.MaxInFlight)
+	start := time.Now()
+	next := start
+
+	for next.Sub(start) < opts.Duration {
+		if wait := time.Until(next); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				wg.Wait()
+				return rec.report(opts, time.Since(start)), ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		req, err := opts.newRequest(ctx, rng, priorities.next())
+		if err != nil {
+			return nil, err
+		}
+
+		select {
+		case inFlight <- struct{}{}:
+			wg.Add(1)
+			go func(scheduled time.Time) {
+				defer wg.Done()
+				defer func() { <-inFlight }()
+				rec.record(send(client, req, scheduled))
+			}(next)
+		default:
+			rec.drop()
+		}
+
+		next = next.Add(time.Duration(float64(time.Second) / opts.rateAt(next.Sub(start))))
+	}
+
+	wg.Wait()
+	return rec.report(opts, time.Since(start)), nil
+}
+
+func (o *Options) newRequest(ctx context.Context, rng *rand.Rand, priority string) (*http.Request, error) {
+	var body io.Reader
+	if o.Body != nil {
+		body = bytes.NewReader(o.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, o.Method, o.Target+o.Path, body)
+	if err != nil {
+		return nil, err
+	}
+	if o.Body != nil && o.ContentType != "" {
+		req.Header.Set("Content-Type", o.ContentType)
+	}
+	if priority != "" {
+		req.Header.Set("X-Task-Priority", priority)
+	}
+	if o.Sessions > 0 {
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: fmt.Sprintf("loadgen-%d", rng.Intn(o.Sessions))})
+	}
+	if o.ClientIPs > 0 {
+		// The balancer only honours this from a trusted proxy address
+		n := rng.Intn(o.ClientIPs)
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("10.%d.%d.%d", n>>16&0xff, n>>8&0xff, n&0xff))
+	}
+	return req, nil
+}
+
+func send(client *http.Client, req *http.Request, scheduled time.Time) result {
+	resp, err := client.Do(req)
+	if err != nil {
+		return result{latency: time.Since(scheduled), err: err}
+	}
+	_, err = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return result{
+		server:  resp.Header.Get("X-Served-By"),
+		status:  resp.StatusCode,
+		latency: time.Since(scheduled),
+		err:     err,
+	}
+}
+
+// errorKind buckets a transport error for the error breakdown.
+func errorKind(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	case errors.Is(err, os.ErrDeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection refused"
+	case errors.Is(err, syscall.ECONNRESET):
+		return "connection reset"
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return "eof"
+	default:
+		return "other"
+	}
+}
+
+// mix picks priorities in proportion to their weights, interleaved by smooth
+// weighted round robin.
+type mix struct {
+	names   []string
+	weights []float64
+	current []float64
+	total   float64
+}
+
+func newMix(weights map[string]float64) *mix {
+	m := &mix{}
+	for name := range weights {
+		if weights[name] > 0 {
+			m.names = append(m.names, name)
+		}
+	}
+	sort.Strings(m.names)
+	for _, name := range m.names {
+		m.weights = append(m.weights, weights[name])
+		m.total += weights[name]
+	}
+	m.current = make([]float64, len(m.names))
+	return m
+}
+
+func (m *mix) next() string {
+	if len(m.names) == 0 {
+		return ""
+	}
+	best := 0
+	for i := range m.names {
+		m.current[i] += m.weights[i]
+		if m.current[i] > m.current[best] {
+			best = i
+		}
+	}
+	m.current[best] -= m.total
+	return m.names[best]
+}
@@ -0,0 +1,108 @@
+package loadgen
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRun_SendsMixAndPopulations(t *testing.T) {
+	var mu sync.Mutex
+	priorities := make(map[string]int)
+	sessions := make(map[string]bool)
+	ips := make(map[string]bool)
+	served := 0
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		priorities[r.Header.Get("X-Task-Priority")]++
+		if c, err := r.Cookie("session_id"); err == nil {
+			sessions[c.Value] = true
+		}
+		ips[r.Header.Get("X-Forwarded-For")] = true
+		served++
+		id := "server-1"
+		if served%4 == 0 {
+			id = "server-2"
+			w.Header().Set("X-Served-By", id)
+			http.Error(w, "boom", http.StatusServiceUnavailable)
+			mu.Unlock()
+			return
+		}
+		mu.Unlock()
+		w.Header().Set("X-Served-By", id)
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+
+	report, err := Run(context.Background(), Options{
+		Target:     backend.URL,
+		RPS:        200,
+		Duration:   500 * time.Millisecond,
+		Priorities: map[string]float64{"high": 1, "normal": 3},
+		Sessions:   5,
+		ClientIPs:  3,
+		Seed:       1,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if report.Requests < 90 || report.Requests > 110 {
+		t.Errorf("requests = %d, want ~100 at 200 rps for 500ms", report.Requests)
+	}
+	if report.StatusCodes[503] != report.Servers["server-2"].Errors || report.Errors != report.StatusCodes[503] {
+		t.Errorf("errors = %d, codes = %v, servers = %+v", report.Errors, report.StatusCodes, report.Servers)
+	}
+	if share := report.Servers["server-1"].Share; share < 0.7 || share > 0.8 {
+		t.Errorf("server-1 share = %.2f, want ~0.75", share)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if ratio := float64(priorities["normal"]) / float64(priorities["high"]); ratio < 2.5 || ratio > 3.5 {
+		t.Errorf("priority mix = %v, want normal:high ~3:1", priorities)
+	}
+	if len(sessions) != 5 || len(ips) != 3 {
+		t.Errorf("saw %d sessions and %d client IPs, want 5 and 3", len(sessions), len(ips))
+	}
+}
+
+func TestRun_CountsTransportErrors(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer backend.Close()
+
+	report, err := Run(context.Background(), Options{
+		Target:   backend.URL,
+		RPS:      50,
+		Duration: 100 * time.Millisecond,
+		Timeout:  50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if report.Errors != report.Requests || report.ErrorKinds["timeout"] != report.Requests {
+		t.Errorf("errors = %d of %d, kinds = %v", report.Errors, report.Requests, report.ErrorKinds)
+	}
+
+	var out strings.Builder
+	if err := report.WriteText(&out); err != nil || !strings.Contains(out.String(), "timeout") {
+		t.Errorf("text report = %q, %v", out.String(), err)
+	}
+}
+
+func TestParseMix(t *testing.T) {
+	mix, err := ParseMix("high=1, normal=3,low")
+	if err != nil || mix["high"] != 1 || mix["normal"] != 3 || mix["low"] != 1 {
+		t.Errorf("ParseMix = %v, %v", mix, err)
+	}
+	if _, err := ParseMix("high=-1"); err == nil {
+		t.Errorf("negative weight accepted")
+	}
+}
@@ -0,0 +1,243 @@
+// internal/loadgen/report.go
+package loadgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Latencies are recorded in microseconds from 1µs to 10 minutes.
+const (
+	histLowest  = 1
+	histHighest = int64(10 * time.Minute / time.Microsecond)
+	histSigFigs = 3
+)
+
+// percentiles reported in the latency distribution.
+var percentiles = []float64{50, 75, 90, 95, 99, 99.9, 99.99, 100}
+
+// Latency summarises response times in milliseconds.
+type Latency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	P999 float64 `json:"p999"`
+	Max  float64 `json:"max"`
+}
+
+// Quantile is one point of the latency distribution.
+type Quantile struct {
+	Percentile float64 `json:"percentile"`
+	Ms         float64 `json:"ms"`
+}
+
+// ServerReport is the traffic one backend answered.
+type ServerReport struct {
+	Requests int     `json:"requests"`
+	Errors   int     `json:"errors"` // 4xx/5xx responses
+	Share    float64 `json:"share"`  // fraction of all answered requests
+	P50      float64 `json:"p50"`
+	P99      float64 `json:"p99"`
+}
+
+// Report is the result of a run.
+type Report struct {
+	Target       string                  `json:"target"`
+	Path         string                  `json:"path"`
+	Duration     string                  `json:"duration"`
+	TargetRPS    float64                 `json:"targetRps"`
+	RampToRPS    float64                 `json:"rampToRps,omitempty"`
+	AchievedRPS  float64                 `json:"achievedRps"`
+	Requests     int                     `json:"requests"`
+	Dropped      int                     `json:"dropped"` // scheduled sends skipped at MaxInFlight
+	Errors       int                     `json:"errors"`  // transport errors and 4xx/5xx responses
+	ErrorRate    float64                 `json:"errorRate"`
+	StatusCodes  map[int]int             `json:"statusCodes"`
+	ErrorKinds   map[string]int          `json:"errorKinds"` // transport errors by kind
+	Latency      Latency                 `json:"latency"`
+	Distribution []Quantile              `json:"distribution"`
+	Servers      map[string]ServerReport `json:"servers"`
+}
+
+// recorder accumulates results while requests are in flight.
+type recorder struct {
+	mu      sync.Mutex
+	all     *Histogram
+	servers map[string]*serverStats
+	codes   map[int]int
+	kinds   map[string]int
+	errors  int
+	dropped int
+}
+
+type serverStats struct {
+	hist   *Histogram
+	errors int
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		all:     NewHistogram(histLowest, histHighest, histSigFigs),
+		servers: make(map[string]*serverStats),
+		codes:   make(map[int]int),
+		kinds:   make(map[string]int),
+	}
+}
+
+func (r *recorder) record(res result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	us := res.latency.Microseconds()
+	r.all.Record(us)
+	switch {
+	case res.status == 0:
+		r.errors++
+		r.kinds[errorKind(res.err)]++
+		return
+	case res.status >= 400:
+		r.errors++
+	}
+	r.codes[res.status]++
+
+	id := res.server
+	if id == "" {
+		id = "(no backend)" // the balancer answered itself
+	}
+	stats := r.servers[id]
+	if stats == nil {
+		stats = &serverStats{hist: NewHistogram(histLowest, histHighest, histSigFigs)}
+		r.servers[id] = stats
+	}
+	stats.hist.Record(us)
+	if res.status >= 400 {
+		stats.errors++
+	}
+}
+
+func (r *recorder) drop() {
+	r.mu.Lock()
+	r.dropped++
+	r.mu.Unlock()
+}
+
+func (r *recorder) report(opts Options, elapsed time.Duration) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rep := &Report{
+		Target:      opts.Target,
+		Path:        opts.Path,
+		Duration:    elapsed.Round(time.Millisecond).String(),
+		TargetRPS:   opts.RPS,
+		RampToRPS:   opts.RampTo,
+		Requests:    int(r.all.Count()),
+		Dropped:     r.dropped,
+		Errors:      r.errors,
+		StatusCodes: r.codes,
+		ErrorKinds:  r.kinds,
+		Latency: Latency{
+			Min:  ms(r.all.Min()),
+			Mean: r.all.Mean() / 1000,
+			P50:  ms(r.all.ValueAt(50)),
+			P90:  ms(r.all.ValueAt(90)),
+			P95:  ms(r.all.ValueAt(95)),
+			P99:  ms(r.all.ValueAt(99)),
+			P999: ms(r.all.ValueAt(99.9)),
+			Max:  ms(r.all.Max()),
+		},
+		Servers: make(map[string]ServerReport, len(r.servers)),
+	}
+	if elapsed > 0 {
+		rep.AchievedRPS = float64(rep.Requests) / elapsed.Seconds()
+	}
+	if rep.Requests > 0 {
+		rep.ErrorRate = float64(rep.Errors) / float64(rep.Requests)
+	}
+	for _, p := range percentiles {
+		rep.Distribution = append(rep.Distribution, Quantile{Percentile: p, Ms: ms(r.all.ValueAt(p))})
+	}
+
+	var answered int64
+	for _, stats := range r.servers {
+		answered += stats.hist.Count()
+	}
+	for id, stats := range r.servers {
+		rep.Servers[id] = ServerReport{
+			Requests: int(stats.hist.Count()),
+			Errors:   stats.errors,
+			Share:    float64(stats.hist.Count()) / float64(answered),
+			P50:      ms(stats.hist.ValueAt(50)),
+			P99:      ms(stats.hist.ValueAt(99)),
+		}
+	}
+	return rep
+}
+
+func ms(us int64) float64 {
+	return float64(us) / 1000
+}
+
+// WriteJSON writes the report as indented JSON.
+func (rep *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rep)
+}
+
+// WriteText writes the report as human-readable tables.
+func (rep *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	rate := fmt.Sprintf("%.0f rps", rep.TargetRPS)
+	if rep.RampToRPS > 0 {
+		rate = fmt.Sprintf("%.0f -> %.0f rps", rep.TargetRPS, rep.RampToRPS)
+	}
+	fmt.Fprintf(tw, "Target:\t%s%s (%s for %s)\n", rep.Target, rep.Path, rate, rep.Duration)
+	fmt.Fprintf(tw, "Requests:\t%d (%.1f rps achieved, %d dropped)\n", rep.Requests, rep.AchievedRPS, rep.Dropped)
+	fmt.Fprintf(tw, "Errors:\t%d (%.2f%%)\n", rep.Errors, rep.ErrorRate*100)
+
+	fmt.Fprintf(tw, "\nLatency\tms\n")
+	fmt.Fprintf(tw, "  min\t%.2f\n", rep.Latency.Min)
+	fmt.Fprintf(tw, "  mean\t%.2f\n", rep.Latency.Mean)
+	for _, q := range rep.Distribution {
+		fmt.Fprintf(tw, "  p%g\t%.2f\n", q.Percentile, q.Ms)
+	}
+
+	fmt.Fprintf(tw, "\nServer\tRequests\tShare\tErrors\tp50 ms\tp99 ms\n")
+	for _, id := range sortedKeys(rep.Servers) {
+		s := rep.Servers[id]
+		fmt.Fprintf(tw, "%s\t%d\t%.1f%%\t%d\t%.2f\t%.2f\n", id, s.Requests, s.Share*100, s.Errors, s.P50, s.P99)
+	}
+
+	fmt.Fprintf(tw, "\nStatus\tCount\n")
+	codes := make([]int, 0, len(rep.StatusCodes))
+	for code := range rep.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		fmt.Fprintf(tw, "%d\t%d\n", code, rep.StatusCodes[code])
+	}
+	for _, kind := range sortedKeys(rep.ErrorKinds) {
+		fmt.Fprintf(tw, "%s\t%d\n", kind, rep.ErrorKinds[kind])
+	}
+	return tw.Flush()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
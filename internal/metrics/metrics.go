@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"load-balancer/internal/events"
+	"load-balancer/internal/proxy"
 	"load-balancer/internal/server"
 )
 
@@ -21,7 +22,6 @@ type LBMetrics struct {
 	ResponseTimeHistory []ResponseTimeDataPoint `json:"responseTimeHistory"`
 	ErrorRate           float64                 `json:"errorRate"`
 	LastErrors          []ErrorEvent            `json:"lastErrors"`
-	mutex               sync.RWMutex
 }
 
 // ResponseTimeDataPoint represents a data point for response time tracking
@@ -41,6 +41,7 @@ type ErrorEvent struct {
 type MetricsManager struct {
 	Metrics       LBMetrics
 	ServerManager *server.Manager
+	Upstreams     *proxy.Registry
 	mutex         sync.RWMutex
 
 	// Circular buffer settings for response time history
@@ -50,6 +51,15 @@ type MetricsManager struct {
 	packetHistory    []PacketEvent
 	maxPacketHistory int
 	packetCounter    uint64
+
+	// Per-variant traffic for split routes
+	variants variantRegistry
+
+	// Shadow traffic sent to mirror pools
+	mirrors mirrorRegistry
+
+	// Synthetic /api/test probes, kept out of the real request metrics
+	synthetic syntheticRegistry
 }
 
 // NewMetricsManager creates a new metrics manager
@@ -127,6 +137,9 @@ type PacketEvent struct {
 	RequestID      string    `json:"requestId"`
 	Attempt        int       `json:"attempt"`
 	Priority       string    `json:"priority"`
+	ClientIP       string    `json:"clientIp,omitempty"`
+	Route          string    `json:"route,omitempty"`
+	Variant        string    `json:"variant,omitempty"`
 	ServerID       string    `json:"serverId"`
 	ServerAddress  string    `json:"serverAddress"`
 	Status         string    `json:"status"`
@@ -134,6 +147,7 @@ type PacketEvent struct {
 	Timestamp      time.Time `json:"timestamp"`
 	ResponseTime   float64   `json:"responseTime,omitempty"`
 	ActiveRequests int64     `json:"activeRequests"`
+	Synthetic      bool      `json:"synthetic,omitempty"` // a /api/test probe, not client traffic
 }
 
 // GeneratePacketID returns a unique identifier for a routed request.
@@ -162,11 +176,24 @@ func (mm *MetricsManager) RecordAndBroadcastPacketEvent(es *events.EventSystem,
 		return
 	}
 
-	payload, err := json.Marshal(evt)
-	if err != nil {
-		return
+	severity := events.SeverityDebug
+	if evt.Status == "failed" {
+		severity = events.SeverityWarning
 	}
-	es.Publish(events.PacketEvent, string(payload))
+	message := fmt.Sprintf("%s attempt %d %s on %s", evt.RequestID, evt.Attempt, evt.Status, evt.ServerID)
+	if evt.Reason != "" {
+		message += ": " + evt.Reason
+	}
+	es.Emit(events.Event{
+		Type:      events.PacketEvent,
+		Severity:  severity,
+		Source:    "dispatch",
+		ServerID:  evt.ServerID,
+		RequestID: evt.RequestID,
+		Message:   message,
+		Data:      events.Payload(evt),
+		Timestamp: evt.Timestamp,
+	})
 }
 
 // GetPacketHistory returns the most recent packet events up to the requested limit.
@@ -199,13 +226,26 @@ func (mm *MetricsManager) Handler() http.HandlerFunc {
 		// Combine server metrics with load balancer metrics
 		servers := mm.ServerManager.GetAllServers()
 
+		var pools map[string]proxy.PoolStats
+		if mm.Upstreams != nil {
+			pools = mm.Upstreams.Stats()
+		}
+
 		// Create combined response
 		response := struct {
-			LoadBalancer LBMetrics        `json:"loadBalancer"`
-			Servers      []*server.Server `json:"servers"`
+			LoadBalancer    *LBMetrics                 `json:"loadBalancer"`
+			Servers         []*server.Server           `json:"servers"`
+			ConnectionPools map[string]proxy.PoolStats `json:"connectionPools,omitempty"`
+			Variants        map[string]VariantStats    `json:"variants,omitempty"`
+			Mirrors         map[string]MirrorStats     `json:"mirrors,omitempty"`
+			Synthetic       *SyntheticStats            `json:"synthetic,omitempty"`
 		}{
-			LoadBalancer: mm.Metrics,
-			Servers:      servers,
+			LoadBalancer:    &mm.Metrics,
+			Servers:         servers,
+			ConnectionPools: pools,
+			Variants:        mm.GetVariantStats(),
+			Mirrors:         mm.GetMirrorStats(),
+			Synthetic:       mm.GetSyntheticStats(),
 		}
 
 		// Encode and send
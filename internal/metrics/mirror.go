@@ -0,0 +1,102 @@
+// internal/metrics/mirror.go
+package metrics
+
+import "sync"
+
+// MirrorStats summarises shadow traffic sent from a route to a mirror pool.
+// Diff fields only cover requests that were compared with the primary response.
+type MirrorStats struct {
+	Route           string  `json:"route"`
+	Pool            string  `json:"pool"`
+	Requests        int64   `json:"requests"`
+	Errors          int64   `json:"errors"`
+	Dropped         int64   `json:"dropped"`
+	ErrorRate       float64 `json:"errorRate"`
+	AvgResponseTime float64 `json:"avgResponseTime"`
+
+	Compared         int64   `json:"compared"`
+	StatusMismatches int64   `json:"statusMismatches"`
+	MismatchRate     float64 `json:"mismatchRate"`
+	AvgLatencyDelta  float64 `json:"avgLatencyDelta"` // shadow minus primary, in ms
+}
+
+type mirrorTracker struct {
+	stats        MirrorStats
+	totalMs      float64
+	totalDeltaMs float64
+}
+
+// mirrorRegistry holds per route/pool mirror trackers.
+type mirrorRegistry struct {
+	mu       sync.Mutex
+	trackers map[string]*mirrorTracker
+}
+
+func (reg *mirrorRegistry) tracker(route, pool string) *mirrorTracker {
+	if reg.trackers == nil {
+		reg.trackers = make(map[string]*mirrorTracker)
+	}
+	key := route + "/" + pool
+	t, ok := reg.trackers[key]
+	if !ok {
+		t = &mirrorTracker{stats: MirrorStats{Route: route, Pool: pool}}
+		reg.trackers[key] = t
+	}
+	return t
+}
+
+// RecordMirrorRequest records one shadow request. It is kept apart from the
+// primary request metrics so mirrors never skew them.
+func (mm *MetricsManager) RecordMirrorRequest(route, pool string, responseTime float64, isError bool) {
+	mm.mirrors.mu.Lock()
+	defer mm.mirrors.mu.Unlock()
+
+	t := mm.mirrors.tracker(route, pool)
+	t.stats.Requests++
+	t.totalMs += responseTime
+	if isError {
+		t.stats.Errors++
+	}
+}
+
+// RecordMirrorDropped counts a shadow request skipped because too many were in flight.
+func (mm *MetricsManager) RecordMirrorDropped(route, pool string) {
+	mm.mirrors.mu.Lock()
+	defer mm.mirrors.mu.Unlock()
+
+	mm.mirrors.tracker(route, pool).stats.Dropped++
+}
+
+// RecordMirrorDiff records how a shadow response compared with the primary one.
+func (mm *MetricsManager) RecordMirrorDiff(route, pool string, primaryStatus, shadowStatus int, latencyDelta float64) {
+	mm.mirrors.mu.Lock()
+	defer mm.mirrors.mu.Unlock()
+
+	t := mm.mirrors.tracker(route, pool)
+	t.stats.Compared++
+	t.totalDeltaMs += latencyDelta
+	if primaryStatus != shadowStatus {
+		t.stats.StatusMismatches++
+	}
+}
+
+// GetMirrorStats returns mirror statistics keyed by "route/pool".
+func (mm *MetricsManager) GetMirrorStats() map[string]MirrorStats {
+	mm.mirrors.mu.Lock()
+	defer mm.mirrors.mu.Unlock()
+
+	result := make(map[string]MirrorStats, len(mm.mirrors.trackers))
+	for key, t := range mm.mirrors.trackers {
+		stats := t.stats
+		if stats.Requests > 0 {
+			stats.ErrorRate = float64(stats.Errors) / float64(stats.Requests)
+			stats.AvgResponseTime = t.totalMs / float64(stats.Requests)
+		}
+		if stats.Compared > 0 {
+			stats.MismatchRate = float64(stats.StatusMismatches) / float64(stats.Compared)
+			stats.AvgLatencyDelta = t.totalDeltaMs / float64(stats.Compared)
+		}
+		result[key] = stats
+	}
+	return result
+}
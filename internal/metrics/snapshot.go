@@ -0,0 +1,55 @@
+// internal/metrics/snapshot.go
+package metrics
+
+import "sync/atomic"
+
+// Counters is the part of the metrics that is worth keeping across a restart.
+type Counters struct {
+	LoadBalancer  LBMetrics `json:"loadBalancer"`
+	PacketCounter uint64    `json:"packetCounter"`
+}
+
+// ExportCounters returns a copy of the load balancer counters.
+func (mm *MetricsManager) ExportCounters() Counters {
+	mm.mutex.RLock()
+	defer mm.mutex.RUnlock()
+
+	lb := mm.Metrics
+	lb.RequestsPerServer = make(map[string]int64, len(mm.Metrics.RequestsPerServer))
+	for id, n := range mm.Metrics.RequestsPerServer {
+		lb.RequestsPerServer[id] = n
+	}
+	lb.ResponseTimeHistory = append([]ResponseTimeDataPoint(nil), mm.Metrics.ResponseTimeHistory...)
+	lb.LastErrors = append([]ErrorEvent(nil), mm.Metrics.LastErrors...)
+
+	return Counters{
+		LoadBalancer:  lb,
+		PacketCounter: atomic.LoadUint64(&mm.packetCounter),
+	}
+}
+
+// RestoreCounters replaces the load balancer counters with saved ones. The
+// packet counter only moves forward so new packet IDs never repeat old ones.
+func (mm *MetricsManager) RestoreCounters(c Counters) {
+	mm.mutex.Lock()
+	defer mm.mutex.Unlock()
+
+	lb := c.LoadBalancer
+	if lb.RequestsPerServer == nil {
+		lb.RequestsPerServer = make(map[string]int64)
+	}
+	if n := len(lb.ResponseTimeHistory); n > mm.maxHistoryPoints {
+		lb.ResponseTimeHistory = lb.ResponseTimeHistory[n-mm.maxHistoryPoints:]
+	}
+	if n := len(lb.LastErrors); n > 10 {
+		lb.LastErrors = lb.LastErrors[n-10:]
+	}
+	mm.Metrics = lb
+
+	for {
+		current := atomic.LoadUint64(&mm.packetCounter)
+		if c.PacketCounter <= current || atomic.CompareAndSwapUint64(&mm.packetCounter, current, c.PacketCounter) {
+			break
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// internal/metrics/synthetic.go
+package metrics
+
+import (
+	"context"
+	"sync"
+)
+
+type syntheticKey struct{}
+
+// WithSynthetic marks a request context as a synthetic probe. Probes travel
+// the normal dispatch path but are recorded apart from real traffic.
+func WithSynthetic(ctx context.Context) context.Context {
+	return context.WithValue(ctx, syntheticKey{}, true)
+}
+
+// IsSynthetic reports whether ctx belongs to a synthetic probe.
+func IsSynthetic(ctx context.Context) bool {
+	synthetic, _ := ctx.Value(syntheticKey{}).(bool)
+	return synthetic
+}
+
+// SyntheticStats summarises synthetic probe attempts, overall and per server.
+type SyntheticStats struct {
+	Requests        int64                           `json:"requests"`
+	Errors          int64                           `json:"errors"`
+	ErrorRate       float64                         `json:"errorRate"`
+	AvgResponseTime float64                         `json:"avgResponseTime"`
+	Servers         map[string]SyntheticServerStats `json:"servers"`
+}
+
+// SyntheticServerStats is one server's share of the synthetic probes.
+type SyntheticServerStats struct {
+	Requests        int64   `json:"requests"`
+	Errors          int64   `json:"errors"`
+	AvgResponseTime float64 `json:"avgResponseTime"`
+}
+
+type syntheticTracker struct {
+	stats   SyntheticServerStats
+	totalMs float64
+}
+
+// syntheticRegistry holds per-server synthetic probe trackers.
+type syntheticRegistry struct {
+	mu       sync.Mutex
+	trackers map[string]*syntheticTracker
+}
+
+// RecordSyntheticRequest records one synthetic probe attempt. It never
+// touches the real request metrics.
+func (mm *MetricsManager) RecordSyntheticRequest(serverID string, responseTime float64, isError bool) {
+	mm.synthetic.mu.Lock()
+	defer mm.synthetic.mu.Unlock()
+
+	if mm.synthetic.trackers == nil {
+		mm.synthetic.trackers = make(map[string]*syntheticTracker)
+	}
+	t, ok := mm.synthetic.trackers[serverID]
+	if !ok {
+		t = &syntheticTracker{}
+		mm.synthetic.trackers[serverID] = t
+	}
+	t.stats.Requests++
+	t.totalMs += responseTime
+	if isError {
+		t.stats.Errors++
+	}
+}
+
+// GetSyntheticStats returns the synthetic probe totals, or nil if no probe
+// has run.
+func (mm *MetricsManager) GetSyntheticStats() *SyntheticStats {
+	mm.synthetic.mu.Lock()
+	defer mm.synthetic.mu.Unlock()
+
+	if len(mm.synthetic.trackers) == 0 {
+		return nil
+	}
+	stats := &SyntheticStats{Servers: make(map[string]SyntheticServerStats, len(mm.synthetic.trackers))}
+	var totalMs float64
+	for id, t := range mm.synthetic.trackers {
+		s := t.stats
+		if s.Requests > 0 {
+			s.AvgResponseTime = t.totalMs / float64(s.Requests)
+		}
+		stats.Servers[id] = s
+		stats.Requests += s.Requests
+		stats.Errors += s.Errors
+		totalMs += t.totalMs
+	}
+	if stats.Requests > 0 {
+		stats.ErrorRate = float64(stats.Errors) / float64(stats.Requests)
+		stats.AvgResponseTime = totalMs / float64(stats.Requests)
+	}
+	return stats
+}
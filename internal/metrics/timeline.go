@@ -0,0 +1,92 @@
+// internal/metrics/timeline.go
+package metrics
+
+import (
+	"time"
+
+	"load-balancer/internal/events"
+)
+
+// Attempt is one try at a backend within a request's timeline.
+type Attempt struct {
+	Attempt       int           `json:"attempt"`
+	ServerID      string        `json:"serverId"`
+	ServerAddress string        `json:"serverAddress"`
+	Status        string        `json:"status"` // the last step: completed, failed, rerouted, or dispatch while in flight
+	Reason        string        `json:"reason,omitempty"`
+	ResponseTime  float64       `json:"responseTime,omitempty"`
+	Steps         []PacketEvent `json:"steps"`
+}
+
+// Timeline is the recorded history of one request, grouped by attempt.
+type Timeline struct {
+	RequestID  string         `json:"requestId"`
+	Priority   string         `json:"priority,omitempty"`
+	ClientIP   string         `json:"clientIp,omitempty"`
+	Route      string         `json:"route,omitempty"`
+	Outcome    string         `json:"outcome"` // completed, failed or in-flight
+	Started    time.Time      `json:"started"`
+	DurationMs float64        `json:"durationMs"`
+	Attempts   []Attempt      `json:"attempts"`
+	Events     []events.Event `json:"events,omitempty"` // non-packet events about the request, e.g. why it failed
+}
+
+// BuildTimeline groups a request's packet events, in the order they were
+// recorded, into attempts. notes are the request's other events. A request
+// ID seen again after a restart without a snapshot starts a new timeline;
+// only the latest is kept.
+func BuildTimeline(requestID string, packets []PacketEvent, notes []events.Event) Timeline {
+	timeline := Timeline{RequestID: requestID, Attempts: []Attempt{}}
+	start := 0
+	for i, p := range packets {
+		if p.Attempt == 1 && p.Status == "dispatch" {
+			start = i
+		}
+	}
+	packets = packets[start:]
+	if len(packets) == 0 {
+		return timeline
+	}
+
+	first, last := packets[0], packets[len(packets)-1]
+	timeline.Priority = first.Priority
+	timeline.ClientIP = first.ClientIP
+	timeline.Route = first.Route
+	timeline.Started = first.Timestamp
+	timeline.DurationMs = float64(last.Timestamp.Sub(first.Timestamp).Microseconds()) / 1000
+
+	for _, p := range packets {
+		n := len(timeline.Attempts)
+		if n == 0 || timeline.Attempts[n-1].Attempt != p.Attempt {
+			timeline.Attempts = append(timeline.Attempts, Attempt{
+				Attempt:       p.Attempt,
+				ServerID:      p.ServerID,
+				ServerAddress: p.ServerAddress,
+			})
+			n++
+		}
+		attempt := &timeline.Attempts[n-1]
+		attempt.Status = p.Status
+		attempt.Reason = p.Reason
+		attempt.ResponseTime = p.ResponseTime
+		attempt.Steps = append(attempt.Steps, p)
+	}
+
+	// A failed or rerouted attempt is followed at once by the next one, so
+	// a request whose last step is one of those has given up
+	switch last.Status {
+	case "completed":
+		timeline.Outcome = "completed"
+	case "dispatch":
+		timeline.Outcome = "in-flight"
+	default:
+		timeline.Outcome = "failed"
+	}
+
+	for _, note := range notes {
+		if !note.Timestamp.Before(first.Timestamp) {
+			timeline.Events = append(timeline.Events, note)
+		}
+	}
+	return timeline
+}
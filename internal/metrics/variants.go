@@ -0,0 +1,169 @@
+// internal/metrics/variants.go
+package metrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxVariantSamples bounds the latency samples retained per variant.
+const maxVariantSamples = 2000
+
+// VariantStats summarises the traffic served by one variant of a split route.
+type VariantStats struct {
+	Route           string  `json:"route"`
+	Variant         string  `json:"variant"`
+	Requests        int64   `json:"requests"`
+	Errors          int64   `json:"errors"`
+	ErrorRate       float64 `json:"errorRate"`
+	AvgResponseTime float64 `json:"avgResponseTime"`
+	P50             float64 `json:"p50"`
+	P95             float64 `json:"p95"`
+	P99             float64 `json:"p99"`
+}
+
+type variantSample struct {
+	at      time.Time
+	ms      float64
+	isError bool
+}
+
+// variantTracker keeps cumulative counters plus a bounded window of samples.
+type variantTracker struct {
+	requests int64
+	errors   int64
+	totalMs  float64
+	samples  []variantSample
+}
+
+// variantRegistry holds per-variant trackers keyed by route and variant.
+type variantRegistry struct {
+	mu       sync.RWMutex
+	trackers map[string]*variantTracker
+}
+
+func variantKey(route, variant string) string {
+	return route + "/" + variant
+}
+
+// RecordVariantRequest records one backend attempt served by a split variant.
+func (mm *MetricsManager) RecordVariantRequest(route, variant string, responseTime float64, isError bool) {
+	mm.variants.mu.Lock()
+	defer mm.variants.mu.Unlock()
+
+	if mm.variants.trackers == nil {
+		mm.variants.trackers = make(map[string]*variantTracker)
+	}
+	key := variantKey(route, variant)
+	tracker, ok := mm.variants.trackers[key]
+	if !ok {
+		tracker = &variantTracker{}
+		mm.variants.trackers[key] = tracker
+	}
+
+	tracker.requests++
+	tracker.totalMs += responseTime
+	if isError {
+		tracker.errors++
+	}
+
+	sample := variantSample{at: time.Now(), ms: responseTime, isError: isError}
+	if len(tracker.samples) >= maxVariantSamples {
+		tracker.samples = append(tracker.samples[1:], sample)
+	} else {
+		tracker.samples = append(tracker.samples, sample)
+	}
+}
+
+// GetVariantStats returns cumulative counters for every variant seen so far.
+// Percentiles are computed over the most recent samples.
+func (mm *MetricsManager) GetVariantStats() map[string]VariantStats {
+	mm.variants.mu.RLock()
+	defer mm.variants.mu.RUnlock()
+
+	result := make(map[string]VariantStats, len(mm.variants.trackers))
+	for key, tracker := range mm.variants.trackers {
+		route, variant := splitVariantKey(key)
+		stats := summarise(route, variant, tracker.samples)
+		stats.Requests = tracker.requests
+		stats.Errors = tracker.errors
+		stats.ErrorRate = 0
+		stats.AvgResponseTime = 0
+		if tracker.requests > 0 {
+			stats.ErrorRate = float64(tracker.errors) / float64(tracker.requests)
+			stats.AvgResponseTime = tracker.totalMs / float64(tracker.requests)
+		}
+		result[key] = stats
+	}
+	return result
+}
+
+// VariantWindow summarises only the samples recorded since the given time.
+func (mm *MetricsManager) VariantWindow(route, variant string, since time.Time) VariantStats {
+	mm.variants.mu.RLock()
+	defer mm.variants.mu.RUnlock()
+
+	tracker, ok := mm.variants.trackers[variantKey(route, variant)]
+	if !ok {
+		return VariantStats{Route: route, Variant: variant}
+	}
+
+	start := sort.Search(len(tracker.samples), func(i int) bool {
+		return !tracker.samples[i].at.Before(since)
+	})
+	return summarise(route, variant, tracker.samples[start:])
+}
+
+func splitVariantKey(key string) (string, string) {
+	for i := len(key) - 1; i >= 0; i-- {
+		if key[i] == '/' {
+			return key[:i], key[i+1:]
+		}
+	}
+	return key, ""
+}
+
+// summarise computes counts, error rate and latency percentiles for samples.
+func summarise(route, variant string, samples []variantSample) VariantStats {
+	stats := VariantStats{Route: route, Variant: variant}
+	if len(samples) == 0 {
+		return stats
+	}
+
+	latencies := make([]float64, 0, len(samples))
+	total := 0.0
+	for _, s := range samples {
+		stats.Requests++
+		if s.isError {
+			stats.Errors++
+		}
+		latencies = append(latencies, s.ms)
+		total += s.ms
+	}
+	sort.Float64s(latencies)
+
+	stats.ErrorRate = float64(stats.Errors) / float64(stats.Requests)
+	stats.AvgResponseTime = total / float64(stats.Requests)
+	stats.P50 = Percentile(latencies, 50)
+	stats.P95 = Percentile(latencies, 95)
+	stats.P99 = Percentile(latencies, 99)
+	return stats
+}
+
+// Percentile returns the p-th percentile (0-100) of an ascending slice using
+// the nearest-rank method.
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
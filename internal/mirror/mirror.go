@@ -0,0 +1,120 @@
+// internal/mirror/mirror.go
+package mirror
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/metrics"
+	"load-balancer/internal/proxy"
+	"load-balancer/internal/router"
+)
+
+// defaultTimeout bounds a shadow request when the route sets none.
+const defaultTimeout = 5 * time.Second
+
+// Sender copies requests to shadow pools. Shadow requests use their own
+// metrics and never touch the circuit breakers or in-flight counters, so a
+// failing mirror cannot affect the primary pool.
+type Sender struct {
+	Upstreams *proxy.Registry
+	Metrics   *metrics.MetricsManager
+
+	slots chan struct{}
+}
+
+// NewSender creates a sender allowing at most maxInFlight concurrent shadow
+// requests; extra requests are dropped rather than queued.
+func NewSender(upstreams *proxy.Registry, mm *metrics.MetricsManager, maxInFlight int) *Sender {
+	if maxInFlight <= 0 {
+		maxInFlight = 64
+	}
+	return &Sender{
+		Upstreams: upstreams,
+		Metrics:   mm,
+		slots:     make(chan struct{}, maxInFlight),
+	}
+}
+
+// Shadow is an in-flight mirrored request.
+type Shadow struct {
+	route  string
+	pool   string
+	diff   bool
+	result chan outcome
+	mm     *metrics.MetricsManager
+}
+
+type outcome struct {
+	status int // 0 if the shadow request failed outright
+	ms     float64
+}
+
+// Send starts shadowing r, with its buffered body, to the pool. It returns
+// immediately; the shadow response is measured and discarded. It returns nil
+// if the request was not sent.
+func (s *Sender) Send(routeName string, cfg config.MirrorConfig, pool *router.Pool, r *http.Request, body []byte) *Shadow {
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		s.Metrics.RecordMirrorDropped(routeName, pool.Name)
+		return nil
+	}
+
+	timeout := cfg.Timeout.Duration
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	// Keep request values such as the resolved client IP, but not the
+	// client's cancellation: the shadow outlives the primary response.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), timeout)
+	shadowReq := r.Clone(ctx)
+
+	shadow := &Shadow{
+		route:  routeName,
+		pool:   pool.Name,
+		diff:   cfg.Diff,
+		result: make(chan outcome, 1),
+		mm:     s.Metrics,
+	}
+
+	go func() {
+		defer func() { <-s.slots }()
+		defer cancel()
+
+		srv := pool.Balancer.PickServer(shadowReq)
+		if srv == nil {
+			s.Metrics.RecordMirrorRequest(routeName, pool.Name, 0, true)
+			shadow.result <- outcome{}
+			return
+		}
+
+		start := time.Now()
+		result, err := proxy.Forward(s.Upstreams.Get(srv), srv, shadowReq, body)
+		ms := float64(time.Since(start).Milliseconds())
+
+		if err != nil {
+			s.Metrics.RecordMirrorRequest(routeName, pool.Name, ms, true)
+			shadow.result <- outcome{ms: ms}
+			return
+		}
+		s.Metrics.RecordMirrorRequest(routeName, pool.Name, ms, result.StatusCode >= http.StatusInternalServerError)
+		shadow.result <- outcome{status: result.StatusCode, ms: ms}
+	}()
+	return shadow
+}
+
+// Compare records a diff between the shadow and the response the client
+// received, once the shadow completes. It does nothing unless the route asked
+// for diffs; it never blocks the caller.
+func (sh *Shadow) Compare(primaryStatus int, primaryMs float64) {
+	if sh == nil || !sh.diff {
+		return
+	}
+	go func() {
+		out := <-sh.result
+		sh.mm.RecordMirrorDiff(sh.route, sh.pool, primaryStatus, out.status, out.ms-primaryMs)
+	}()
+}
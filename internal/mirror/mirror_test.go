@@ -0,0 +1,78 @@
+package mirror
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/metrics"
+	"load-balancer/internal/proxy"
+	"load-balancer/internal/router"
+	"load-balancer/internal/server"
+)
+
+func TestSender_ShadowsWithoutTouchingPrimaryState(t *testing.T) {
+	received := make(chan string, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.URL.Path
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	host, portStr, _ := net.SplitHostPort(ts.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	pool, err := router.NewPool(config.PoolConfig{
+		Name:    "shadow",
+		Servers: []config.ServerConfig{{ID: "shadow-1", Address: host, Port: port}},
+	})
+	if err != nil {
+		t.Fatalf("new pool: %v", err)
+	}
+
+	upstreams := proxy.NewRegistry(proxy.PoolSettings{})
+	defer upstreams.CloseAll()
+	mm := metrics.NewMetricsManager(pool.Manager)
+	sender := NewSender(upstreams, mm, 4)
+
+	mirrorCfg := config.MirrorConfig{Pool: "shadow", Percentage: 100, Diff: true}
+	const requests = 3
+	for i := 0; i < requests; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		shadow := sender.Send("web", mirrorCfg, pool, req, nil)
+		if shadow == nil {
+			t.Fatalf("request %d was dropped", i)
+		}
+		shadow.Compare(http.StatusOK, 1)
+		if path := <-received; path != "/orders" {
+			t.Fatalf("shadow path = %s, want /orders", path)
+		}
+	}
+
+	var stats metrics.MirrorStats
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		stats = mm.GetMirrorStats()["web/shadow"]
+		if stats.Compared == requests {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if stats.Requests != requests || stats.Errors != requests || stats.StatusMismatches != requests {
+		t.Fatalf("stats = %+v, want %d failed and mismatched requests", stats, requests)
+	}
+
+	srv := pool.Manager.GetAllServers()[0]
+	if active := server.GetActiveRequests(srv); active != 0 {
+		t.Errorf("ActiveRequests = %d, want 0", active)
+	}
+	if srv.CircuitBreakerState != server.CBStateClosed {
+		t.Errorf("breaker state = %v, want closed", srv.CircuitBreakerState)
+	}
+	if total := mm.Metrics.TotalRequests; total != 0 {
+		t.Errorf("primary TotalRequests = %d, want 0", total)
+	}
+}
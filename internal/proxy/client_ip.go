@@ -0,0 +1,163 @@
+// internal/proxy/client_ip.go
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIPResolver works out the real client address of a request. Forwarding
+// headers are only honoured when they were added by a trusted proxy.
+type ClientIPResolver struct {
+	trusted []*net.IPNet
+}
+
+// ClientInfo is the resolved origin of a request, stored in its context.
+type ClientInfo struct {
+	ClientIP    string // best guess at the originating client
+	PeerIP      string // the address that actually connected to us
+	PeerTrusted bool   // whether PeerIP is a trusted proxy
+}
+
+type clientInfoKey struct{}
+
+// NewClientIPResolver builds a resolver from a list of CIDRs. Bare IPs are
+// accepted and treated as single-host networks.
+func NewClientIPResolver(cidrs []string) (*ClientIPResolver, error) {
+	resolver := &ClientIPResolver{}
+	for _, raw := range cidrs {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if !strings.Contains(raw, "/") {
+			ip := net.ParseIP(raw)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", raw)
+			}
+			if ip.To4() != nil {
+				raw += "/32"
+			} else {
+				raw += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", raw, err)
+		}
+		resolver.trusted = append(resolver.trusted, network)
+	}
+	return resolver, nil
+}
+
+// IsTrusted reports whether ip belongs to one of the trusted proxy networks.
+func (c *ClientIPResolver) IsTrusted(ip string) bool {
+	if c == nil {
+		return false
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range c.trusted {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the client information for r. The forwarding chain is
+// walked from the right, skipping trusted proxies; the first untrusted hop is
+// the client. Requests from untrusted peers never consult the headers.
+func (c *ClientIPResolver) Resolve(r *http.Request) ClientInfo {
+	peer := hostOnly(r.RemoteAddr)
+	info := ClientInfo{ClientIP: peer, PeerIP: peer}
+	if !c.IsTrusted(peer) {
+		return info
+	}
+	info.PeerTrusted = true
+
+	chain := forwardedForChain(r.Header)
+	for i := len(chain) - 1; i >= 0; i-- {
+		hop := chain[i]
+		if net.ParseIP(hop) == nil {
+			// Obfuscated or garbled entry; nothing beyond it can be trusted.
+			break
+		}
+		info.ClientIP = hop
+		if !c.IsTrusted(hop) {
+			break
+		}
+	}
+	return info
+}
+
+// Middleware resolves the client IP once and stores it in the request context.
+func (c *ClientIPResolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := c.Resolve(r)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientInfoKey{}, info)))
+	})
+}
+
+// ClientInfoFrom returns the resolved client information for r. Requests that
+// did not pass through the middleware fall back to the connecting peer.
+func ClientInfoFrom(r *http.Request) ClientInfo {
+	if info, ok := r.Context().Value(clientInfoKey{}).(ClientInfo); ok {
+		return info
+	}
+	peer := hostOnly(r.RemoteAddr)
+	return ClientInfo{ClientIP: peer, PeerIP: peer}
+}
+
+// ClientIP is shorthand for ClientInfoFrom(r).ClientIP.
+func ClientIP(r *http.Request) string {
+	return ClientInfoFrom(r).ClientIP
+}
+
+// forwardedForChain returns the hops listed in X-Forwarded-For, falling back to
+// the for= parameters of RFC 7239 Forwarded. Multiple header lines are joined
+// in order.
+func forwardedForChain(h http.Header) []string {
+	var chain []string
+	for _, line := range h.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(line, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				chain = append(chain, hostOnly(hop))
+			}
+		}
+	}
+	if len(chain) > 0 {
+		return chain
+	}
+
+	for _, line := range h.Values("Forwarded") {
+		for _, element := range strings.Split(line, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || !strings.EqualFold(key, "for") {
+					continue
+				}
+				chain = append(chain, hostOnly(strings.Trim(value, `"`)))
+			}
+		}
+	}
+	return chain
+}
+
+// hostOnly strips the port, IPv6 brackets and zone from an address.
+func hostOnly(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	addr = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+	if idx := strings.IndexByte(addr, '%'); idx != -1 {
+		addr = addr[:idx]
+	}
+	return addr
+}
@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIPResolver_Resolve(t *testing.T) {
+	resolver, err := NewClientIPResolver([]string{"10.0.0.0/8", "::1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		forwarded  string
+		want       string
+	}{
+		{"untrusted peer ignores header", "203.0.113.7:5000", []string{"1.1.1.1"}, "", "203.0.113.7"},
+		{"trusted peer uses header", "10.1.2.3:5000", []string{"198.51.100.4"}, "", "198.51.100.4"},
+		{"skips trusted hops from the right", "10.1.2.3:5000", []string{"198.51.100.4, 10.9.9.9"}, "", "198.51.100.4"},
+		{"spoofed left entries are ignored", "10.1.2.3:5000", []string{"6.6.6.6", "198.51.100.4"}, "", "198.51.100.4"},
+		{"ipv6 remote addr", "[::1]:443", []string{"2001:db8::1"}, "", "2001:db8::1"},
+		{"ipv6 untrusted remote addr", "[2001:db8::5]:443", nil, "", "2001:db8::5"},
+		{"forwarded fallback", "10.1.2.3:5000", nil, `for="[2001:db8::9]:80";proto=https`, "2001:db8::9"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tc.remoteAddr
+			for _, v := range tc.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if tc.forwarded != "" {
+				r.Header.Set("Forwarded", tc.forwarded)
+			}
+			if got := resolver.Resolve(r).ClientIP; got != tc.want {
+				t.Fatalf("expected client IP %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestForwardingHeaders(t *testing.T) {
+	in := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	in.Header.Add("Accept", "text/html")
+	in.Header.Add("Accept", "application/json")
+	in.Header.Set("Connection", "keep-alive, X-Secret")
+	in.Header.Set("X-Secret", "drop-me")
+	in.Header.Set("X-Forwarded-For", "6.6.6.6")
+
+	out := http.Header{}
+	copyHeaders(out, in.Header)
+	removeHopHeaders(out)
+	setForwardingHeaders(out, in, ClientInfo{ClientIP: "203.0.113.7", PeerIP: "203.0.113.7"})
+
+	if got := out.Values("Accept"); len(got) != 2 {
+		t.Fatalf("expected multi-valued Accept to survive, got %v", got)
+	}
+	if out.Get("Connection") != "" || out.Get("X-Secret") != "" {
+		t.Fatalf("expected hop-by-hop headers to be removed, got %v", out)
+	}
+	if got := out.Get("X-Forwarded-For"); got != "203.0.113.7" {
+		t.Fatalf("expected untrusted X-Forwarded-For to be replaced, got %q", got)
+	}
+	if got := out.Get("X-Forwarded-Host"); got != "example.com" {
+		t.Fatalf("unexpected X-Forwarded-Host %q", got)
+	}
+	if got := out.Get("Forwarded"); got != "for=203.0.113.7;host=example.com;proto=http" {
+		t.Fatalf("unexpected Forwarded header %q", got)
+	}
+}
@@ -0,0 +1,76 @@
+// internal/proxy/forward.go
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"load-balancer/internal/server"
+)
+
+// Result holds a fully buffered backend response.
+type Result struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Forward sends the original request (with its buffered body) to the backend
+// through the backend's pool and buffers the response. Hop-by-hop headers are
+// dropped and forwarding headers are added for the resolved client.
+func Forward(pool *Pool, srv *server.Server, original *http.Request, body []byte) (*Result, error) {
+	url := fmt.Sprintf("http://%s:%d%s", srv.Address, srv.Port, original.URL.Path)
+	if raw := original.URL.RawQuery; raw != "" {
+		url = url + "?" + raw
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(original.Context(), original.Method, url, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	copyHeaders(req.Header, original.Header)
+	removeHopHeaders(req.Header)
+	setForwardingHeaders(req.Header, original, ClientInfoFrom(original))
+
+	resp, err := pool.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	header := resp.Header.Clone()
+	removeHopHeaders(header)
+
+	return &Result{
+		StatusCode: resp.StatusCode,
+		Header:     header,
+		Body:       respBody,
+	}, nil
+}
+
+// WriteResponse copies a buffered backend response to the client.
+func WriteResponse(w http.ResponseWriter, result *Result) {
+	copyHeaders(w.Header(), result.Header)
+
+	w.WriteHeader(result.StatusCode)
+	if len(result.Body) == 0 {
+		return
+	}
+	if _, err := w.Write(result.Body); err != nil {
+		log.Printf("Error writing response body: %v", err)
+	}
+}
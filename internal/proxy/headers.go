@@ -0,0 +1,110 @@
+// internal/proxy/headers.go
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// hopHeaders are connection-specific and must not be forwarded (RFC 7230 §6.1).
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// copyHeaders copies every value of every header from src to dst.
+func copyHeaders(dst, src http.Header) {
+	for k, vv := range src {
+		for _, v := range vv {
+			dst.Add(k, v)
+		}
+	}
+}
+
+// removeHopHeaders strips hop-by-hop headers, including any named in Connection.
+func removeHopHeaders(h http.Header) {
+	for _, line := range h.Values("Connection") {
+		for _, name := range strings.Split(line, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				h.Del(name)
+			}
+		}
+	}
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+}
+
+// setForwardingHeaders adds X-Forwarded-For/Host/Proto and RFC 7239 Forwarded
+// to an outgoing request. Values supplied by the client are only kept when the
+// connecting peer is a trusted proxy; otherwise they are replaced.
+func setForwardingHeaders(out http.Header, in *http.Request, info ClientInfo) {
+	proto := "http"
+	if in.TLS != nil {
+		proto = "https"
+	}
+
+	if info.PeerTrusted {
+		if prior := strings.Join(in.Header.Values("X-Forwarded-For"), ", "); prior != "" {
+			out.Set("X-Forwarded-For", prior+", "+info.PeerIP)
+		} else {
+			out.Set("X-Forwarded-For", info.PeerIP)
+		}
+		if host := in.Header.Get("X-Forwarded-Host"); host != "" {
+			out.Set("X-Forwarded-Host", host)
+		} else {
+			out.Set("X-Forwarded-Host", in.Host)
+		}
+		if p := in.Header.Get("X-Forwarded-Proto"); p != "" {
+			out.Set("X-Forwarded-Proto", p)
+		} else {
+			out.Set("X-Forwarded-Proto", proto)
+		}
+	} else {
+		out.Set("X-Forwarded-For", info.PeerIP)
+		out.Set("X-Forwarded-Host", in.Host)
+		out.Set("X-Forwarded-Proto", proto)
+	}
+
+	element := "for=" + forwardedNode(info.PeerIP)
+	if in.Host != "" {
+		element += ";host=" + quoteIfNeeded(in.Host)
+	}
+	element += ";proto=" + proto
+
+	if prior := strings.Join(in.Header.Values("Forwarded"), ", "); info.PeerTrusted && prior != "" {
+		out.Set("Forwarded", prior+", "+element)
+	} else {
+		out.Set("Forwarded", element)
+	}
+}
+
+// forwardedNode formats an IP as an RFC 7239 node; IPv6 must be bracketed and quoted.
+func forwardedNode(ip string) string {
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+		return `"[` + ip + `]"`
+	}
+	if ip == "" {
+		return "unknown"
+	}
+	return ip
+}
+
+// quoteIfNeeded quotes values that are not valid RFC 7230 tokens (e.g. host:port).
+func quoteIfNeeded(v string) string {
+	for _, c := range v {
+		if !(c == '-' || c == '.' || c == '_' || c == '~' ||
+			(c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')) {
+			return `"` + strings.ReplaceAll(v, `"`, `\"`) + `"`
+		}
+	}
+	return v
+}
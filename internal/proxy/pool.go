@@ -0,0 +1,236 @@
+// internal/proxy/pool.go
+package proxy
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"load-balancer/internal/server"
+)
+
+// PoolSettings controls the shared upstream transport kept for each backend.
+type PoolSettings struct {
+	MaxIdleConns          int           // idle keep-alive connections kept per backend
+	MaxConns              int           // total connections per backend (0 = unlimited)
+	IdleConnTimeout       time.Duration // how long an idle connection is kept open
+	KeepAlive             time.Duration // TCP keep-alive probe interval
+	DisableKeepAlives     bool          // open a fresh connection for every request
+	DialTimeout           time.Duration // TCP connect timeout
+	TLSHandshakeTimeout   time.Duration // TLS handshake timeout
+	ResponseHeaderTimeout time.Duration // wait for response headers after the request is written
+	RequestTimeout        time.Duration // overall limit for a single upstream request
+}
+
+// PoolStats reports connection usage for a single backend.
+type PoolStats struct {
+	Open    int64 `json:"open"`
+	Idle    int64 `json:"idle"`
+	Active  int64 `json:"active"`
+	Waiting int64 `json:"waiting"`
+}
+
+// Pool owns the HTTP transport used to reach a single backend server.
+type Pool struct {
+	ServerID  string
+	Client    *http.Client
+	transport *http.Transport
+
+	open    int64
+	active  int64
+	waiting int64
+}
+
+// NewPool builds a pool for the given server using the provided settings.
+func NewPool(serverID string, settings PoolSettings) *Pool {
+	p := &Pool{ServerID: serverID}
+
+	dialer := &net.Dialer{
+		Timeout:   settings.DialTimeout,
+		KeepAlive: settings.KeepAlive,
+	}
+
+	p.transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			atomic.AddInt64(&p.open, 1)
+			return &trackedConn{Conn: conn, pool: p}, nil
+		},
+		MaxIdleConns:          settings.MaxIdleConns,
+		MaxIdleConnsPerHost:   settings.MaxIdleConns,
+		MaxConnsPerHost:       settings.MaxConns,
+		IdleConnTimeout:       settings.IdleConnTimeout,
+		TLSHandshakeTimeout:   settings.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: settings.ResponseHeaderTimeout,
+		DisableKeepAlives:     settings.DisableKeepAlives,
+	}
+
+	p.Client = &http.Client{
+		Transport: p.transport,
+		Timeout:   settings.RequestTimeout,
+	}
+	return p
+}
+
+// Do sends the request through the pool, tracking connection usage.
+func (p *Pool) Do(req *http.Request) (*http.Response, error) {
+	var waiting int32
+	var held atomic.Pointer[trackedConn]
+
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) {
+			if atomic.CompareAndSwapInt32(&waiting, 0, 1) {
+				atomic.AddInt64(&p.waiting, 1)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if atomic.CompareAndSwapInt32(&waiting, 1, 0) {
+				atomic.AddInt64(&p.waiting, -1)
+			}
+			if tc, ok := info.Conn.(*trackedConn); ok {
+				tc.acquire()
+				held.Store(tc)
+			}
+		},
+		PutIdleConn: func(error) {
+			if tc := held.Load(); tc != nil {
+				tc.release()
+			}
+		},
+	}
+
+	resp, err := p.Client.Do(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if atomic.CompareAndSwapInt32(&waiting, 1, 0) {
+		atomic.AddInt64(&p.waiting, -1)
+	}
+	return resp, err
+}
+
+// Stats returns a snapshot of the pool's connection counters.
+func (p *Pool) Stats() PoolStats {
+	open := atomic.LoadInt64(&p.open)
+	active := atomic.LoadInt64(&p.active)
+	idle := open - active
+	if idle < 0 {
+		idle = 0
+	}
+	return PoolStats{
+		Open:    open,
+		Idle:    idle,
+		Active:  active,
+		Waiting: atomic.LoadInt64(&p.waiting),
+	}
+}
+
+// Close drops all idle connections held by the pool. Connections still in use
+// are closed by the transport once their response bodies are released.
+func (p *Pool) Close() {
+	p.transport.CloseIdleConnections()
+}
+
+// trackedConn wraps an upstream connection so the pool can count open and
+// in-use connections.
+type trackedConn struct {
+	net.Conn
+	pool   *Pool
+	inUse  int32
+	closed int32
+}
+
+func (c *trackedConn) acquire() {
+	if atomic.CompareAndSwapInt32(&c.inUse, 0, 1) {
+		atomic.AddInt64(&c.pool.active, 1)
+	}
+}
+
+func (c *trackedConn) release() {
+	if atomic.CompareAndSwapInt32(&c.inUse, 1, 0) {
+		atomic.AddInt64(&c.pool.active, -1)
+	}
+}
+
+func (c *trackedConn) Close() error {
+	if atomic.CompareAndSwapInt32(&c.closed, 0, 1) {
+		c.release()
+		atomic.AddInt64(&c.pool.open, -1)
+	}
+	return c.Conn.Close()
+}
+
+// Registry keeps one Pool per backend server, keyed by server ID.
+type Registry struct {
+	mu       sync.Mutex
+	settings PoolSettings
+	pools    map[string]*Pool
+}
+
+// NewRegistry creates an empty registry that builds pools with the given settings.
+func NewRegistry(settings PoolSettings) *Registry {
+	return &Registry{
+		settings: settings,
+		pools:    make(map[string]*Pool),
+	}
+}
+
+// Get returns the pool for a server, creating it on first use.
+func (r *Registry) Get(srv *server.Server) *Pool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pool, ok := r.pools[srv.ID]
+	if !ok {
+		pool = NewPool(srv.ID, r.settings)
+		r.pools[srv.ID] = pool
+	}
+	return pool
+}
+
+// Remove tears down the pool for a server that has left the manager.
+func (r *Registry) Remove(serverID string) {
+	r.mu.Lock()
+	pool, ok := r.pools[serverID]
+	delete(r.pools, serverID)
+	r.mu.Unlock()
+
+	if ok {
+		pool.Close()
+	}
+}
+
+// Watch removes pools whenever their server is removed from the manager.
+func (r *Registry) Watch(mgr *server.Manager) {
+	mgr.OnRemove(func(srv *server.Server) {
+		r.Remove(srv.ID)
+	})
+}
+
+// Stats returns connection counters for every known pool.
+func (r *Registry) Stats() map[string]PoolStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make(map[string]PoolStats, len(r.pools))
+	for id, pool := range r.pools {
+		stats[id] = pool.Stats()
+	}
+	return stats
+}
+
+// CloseAll tears down every pool in the registry.
+func (r *Registry) CloseAll() {
+	r.mu.Lock()
+	pools := r.pools
+	r.pools = make(map[string]*Pool)
+	r.mu.Unlock()
+
+	for _, pool := range pools {
+		pool.Close()
+	}
+}
@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"load-balancer/internal/server"
+)
+
+func newBackend(t *testing.T) (*httptest.Server, *server.Server) {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(ts.Close)
+
+	host, portStr, err := net.SplitHostPort(ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("split host port: %v", err)
+	}
+	port, _ := strconv.Atoi(portStr)
+	return ts, &server.Server{ID: "srv-A", Address: host, Port: port}
+}
+
+func testSettings() PoolSettings {
+	return PoolSettings{
+		MaxIdleConns:    4,
+		IdleConnTimeout: time.Minute,
+		DialTimeout:     time.Second,
+		RequestTimeout:  2 * time.Second,
+	}
+}
+
+func TestPool_ReusesConnections(t *testing.T) {
+	_, srv := newBackend(t)
+	reg := NewRegistry(testSettings())
+	defer reg.CloseAll()
+
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	for i := 0; i < 5; i++ {
+		result, err := Forward(reg.Get(srv), srv, req, nil)
+		if err != nil {
+			t.Fatalf("forward %d failed: %v", i, err)
+		}
+		if result.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200, got %d", result.StatusCode)
+		}
+	}
+
+	stats := reg.Stats()[srv.ID]
+	if stats.Open != 1 {
+		t.Fatalf("expected a single reused connection, got %+v", stats)
+	}
+	if stats.Idle != 1 || stats.Active != 0 || stats.Waiting != 0 {
+		t.Fatalf("expected the connection to be idle after use, got %+v", stats)
+	}
+}
+
+func TestRegistry_RemovesPoolWithServer(t *testing.T) {
+	_, srv := newBackend(t)
+	mgr := server.NewManager([]*server.Server{srv})
+	reg := NewRegistry(testSettings())
+	reg.Watch(mgr)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if _, err := Forward(reg.Get(srv), srv, req, nil); err != nil {
+		t.Fatalf("forward failed: %v", err)
+	}
+
+	mgr.RemoveServer(srv.ID)
+
+	if _, ok := reg.Stats()[srv.ID]; ok {
+		t.Fatalf("expected pool for %s to be torn down after removal", srv.ID)
+	}
+}
@@ -0,0 +1,28 @@
+// internal/router/mirror.go
+package router
+
+import (
+	"fmt"
+	"math/rand"
+
+	"load-balancer/internal/config"
+)
+
+// validateMirror checks a mirror policy against the pools known to the table.
+func validateMirror(mirror *config.MirrorConfig, pools map[string]*Pool) error {
+	if mirror.Percentage < 0 || mirror.Percentage > 100 {
+		return fmt.Errorf("mirror percentage must be between 0 and 100")
+	}
+	if _, ok := pools[mirror.Pool]; !ok {
+		return fmt.Errorf("mirror pool %s not found", mirror.Pool)
+	}
+	return nil
+}
+
+// sampleMirror decides whether one request is shadowed.
+func sampleMirror(percentage float64) bool {
+	if percentage <= 0 {
+		return false
+	}
+	return percentage >= 100 || rand.Float64()*100 < percentage
+}
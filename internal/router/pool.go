@@ -0,0 +1,185 @@
+// internal/router/pool.go
+package router
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/health"
+	"load-balancer/internal/lb"
+	"load-balancer/internal/server"
+)
+
+// Pool is a named group of backend servers with its own balancer, health
+// checker and circuit breaker.
+type Pool struct {
+	Name     string
+	Config   config.PoolConfig
+	Manager  *server.Manager
+	Balancer *lb.Balancer
+	Breaker  *lb.CircuitBreakerCoordinator
+	Checker  *health.Checker
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+
+	// updates serializes Update calls
+	updates sync.Mutex
+}
+
+// NewPool builds a pool from a fully resolved pool configuration
+// (see config.Config.ResolvePool). The pool is idle until Start is called.
+func NewPool(cfg config.PoolConfig) (*Pool, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("pool name is required")
+	}
+
+	var servers []*server.Server
+	for _, s := range cfg.Servers {
+		if s.ID == "" {
+			return nil, fmt.Errorf("pool %s: server ID is required", cfg.Name)
+		}
+		servers = append(servers, NewServer(s))
+	}
+	mgr := server.NewManager(servers)
+
+	wrr := lb.NewWeightedRoundRobin(mgr)
+	ipHash := lb.NewIPHash(mgr)
+	sticky := lb.NewStickySessions(mgr)
+	sticky.SetTTL(cfg.StickySessionTTL.Duration)
+	balancer := lb.NewBalancer(mgr, wrr, ipHash, sticky)
+
+	if cfg.Strategy != "" {
+		if err := balancer.SetStrategy(cfg.Strategy); err != nil {
+			return nil, fmt.Errorf("pool %s: %w", cfg.Name, err)
+		}
+	}
+	if cfg.UseStickySessions != nil {
+		balancer.UseStickySessions = *cfg.UseStickySessions
+	}
+
+	cbSettings := lb.CircuitBreakerSettings{}
+	if cfg.CircuitBreaker != nil {
+		cbSettings = lb.CircuitBreakerSettings{
+			FailureThreshold: cfg.CircuitBreaker.FailureThreshold,
+			CooldownPeriod:   cfg.CircuitBreaker.CooldownPeriod.Duration,
+			TrialRequests:    cfg.CircuitBreaker.TrialRequests,
+		}
+	}
+
+	if ss := cfg.SlowStart; ss != nil {
+		curve := lb.RampCurve(ss.Curve)
+		switch curve {
+		case "":
+			curve = lb.RampLinear
+		case lb.RampLinear, lb.RampExponential:
+		default:
+			return nil, fmt.Errorf("pool %s: unknown slow start curve %q", cfg.Name, ss.Curve)
+		}
+		balancer.SetSlowStart(lb.SlowStart{
+			Duration:  ss.Duration.Duration,
+			Curve:     curve,
+			MinFactor: ss.MinFactor,
+		})
+	}
+
+	checker := health.NewChecker(cfg.HealthCheckInterval.Duration, mgr)
+	checker.ProbePath = cfg.HealthCheckPath
+
+	return &Pool{
+		Name:     cfg.Name,
+		Config:   cfg,
+		Manager:  mgr,
+		Balancer: balancer,
+		Breaker:  lb.NewCircuitBreakerCoordinator(mgr, cbSettings),
+		Checker:  checker,
+	}, nil
+}
+
+// NewServer creates a backend server entry with the same initial metric
+// placeholders used for statically configured servers.
+func NewServer(s config.ServerConfig) *server.Server {
+	return &server.Server{
+		ID:                  s.ID,
+		Address:             s.Address,
+		Port:                s.Port,
+		CircuitBreakerState: server.CBStateClosed,
+		// Initial placeholders for metrics:
+		CPUUsage:     0.1,
+		MemUsage:     0.1,
+		ResponseTime: 0.1,
+		ErrorRate:    0.0,
+		PingStatus:   true,
+	}
+}
+
+// Settings returns the pool's configuration with its current strategy and
+// sticky-session setting, which the API may have changed since creation.
+func (p *Pool) Settings() config.PoolConfig {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.Config
+}
+
+// Reconfigure switches the pool's strategy and/or sticky sessions; nil
+// leaves a setting unchanged.
+func (p *Pool) Reconfigure(strategy *string, sticky *bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if strategy != nil {
+		if err := p.Balancer.SetStrategy(*strategy); err != nil {
+			return err
+		}
+		p.Config.Strategy = *strategy
+	}
+	if sticky != nil {
+		p.Balancer.SetStickySessions(*sticky)
+		enabled := *sticky
+		p.Config.UseStickySessions = &enabled
+	}
+	return nil
+}
+
+// Update runs fn if matches reports true, holding the pool's update lock
+// across both so no other Update changes the pool or its servers in
+// between. The admin API uses it to make If-Match checks atomic with the
+// change. It reports whether fn ran.
+func (p *Pool) Update(matches func() bool, fn func()) bool {
+	p.updates.Lock()
+	defer p.updates.Unlock()
+
+	if !matches() {
+		return false
+	}
+	fn()
+	return true
+}
+
+// Start launches the pool's health checker, circuit breaker monitor and
+// sticky-session expiry.
+func (p *Pool) Start(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cancel != nil {
+		return
+	}
+	ctx, p.cancel = context.WithCancel(ctx)
+	p.Checker.Start(ctx)
+	go p.Breaker.Run(ctx)
+	go p.Balancer.StickySessionMgr.Run(ctx)
+}
+
+// Stop halts the pool's background loops.
+func (p *Pool) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+}
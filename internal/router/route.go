@@ -0,0 +1,165 @@
+// internal/router/route.go
+package router
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"load-balancer/internal/config"
+)
+
+// Route is a compiled routing rule.
+type Route struct {
+	Config    config.RouteConfig
+	pathRegex *regexp.Regexp
+	rewrite   *regexp.Regexp
+}
+
+// CompileRoute validates a route configuration and compiles its expressions.
+func CompileRoute(cfg config.RouteConfig) (*Route, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("route name is required")
+	}
+	if cfg.Pool == "" {
+		return nil, fmt.Errorf("route %s: pool is required", cfg.Name)
+	}
+
+	route := &Route{Config: cfg}
+	if cfg.PathRegex != "" {
+		re, err := regexp.Compile(cfg.PathRegex)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: invalid pathRegex: %w", cfg.Name, err)
+		}
+		route.pathRegex = re
+	}
+	if cfg.Rewrite != nil {
+		re, err := regexp.Compile(cfg.Rewrite.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: invalid rewrite pattern: %w", cfg.Name, err)
+		}
+		route.rewrite = re
+	}
+	if len(cfg.Methods) > 0 {
+		route.Config.Methods = make([]string, len(cfg.Methods))
+		for i, m := range cfg.Methods {
+			route.Config.Methods[i] = strings.ToUpper(m)
+		}
+	}
+	return route, nil
+}
+
+// Matches reports whether every matcher configured on the route accepts r.
+func (rt *Route) Matches(r *http.Request) bool {
+	cfg := rt.Config
+
+	if cfg.Host != "" && !matchHost(cfg.Host, r.Host) {
+		return false
+	}
+	if cfg.PathPrefix != "" && !strings.HasPrefix(r.URL.Path, cfg.PathPrefix) {
+		return false
+	}
+	if rt.pathRegex != nil && !rt.pathRegex.MatchString(r.URL.Path) {
+		return false
+	}
+	if len(cfg.Methods) > 0 {
+		found := false
+		for _, m := range cfg.Methods {
+			if m == r.Method {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for name, want := range cfg.Headers {
+		values := r.Header.Values(name)
+		if len(values) == 0 {
+			return false
+		}
+		if want != "" && values[0] != want {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply strips the configured prefix and applies the rewrite rule to r's path.
+func (rt *Route) Apply(r *http.Request) {
+	path := r.URL.Path
+	if prefix := rt.Config.StripPrefix; prefix != "" {
+		path = strings.TrimPrefix(path, prefix)
+	}
+	if rt.rewrite != nil {
+		path = rt.rewrite.ReplaceAllString(path, rt.Config.Rewrite.Replacement)
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	r.URL.Path = path
+	r.URL.RawPath = ""
+}
+
+// usesPool reports whether the route, its mirror or any of its split variants
+// targets pool.
+func (rt *Route) usesPool(pool string) bool {
+	if rt.Config.Pool == pool {
+		return true
+	}
+	if rt.Config.Mirror != nil && rt.Config.Mirror.Pool == pool {
+		return true
+	}
+	if rt.Config.Split != nil {
+		for _, v := range rt.Config.Split.Variants {
+			if v.Pool == pool {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// precedes reports whether rt should be evaluated before other.
+func (rt *Route) precedes(other *Route) bool {
+	if rt.Config.Priority != other.Config.Priority {
+		return rt.Config.Priority > other.Config.Priority
+	}
+	if len(rt.Config.PathPrefix) != len(other.Config.PathPrefix) {
+		return len(rt.Config.PathPrefix) > len(other.Config.PathPrefix)
+	}
+	return rt.matcherCount() > other.matcherCount()
+}
+
+// matcherCount counts the non-prefix matchers configured on the route.
+func (rt *Route) matcherCount() int {
+	n := len(rt.Config.Headers)
+	if rt.Config.Host != "" {
+		n++
+	}
+	if rt.pathRegex != nil {
+		n++
+	}
+	if len(rt.Config.Methods) > 0 {
+		n++
+	}
+	return n
+}
+
+// matchHost compares a host pattern against the request host (port ignored).
+// A leading "*." matches any subdomain.
+func matchHost(pattern, host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	pattern = strings.ToLower(pattern)
+
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(host, pattern[1:])
+	}
+	return host == pattern
+}
@@ -0,0 +1,154 @@
+// internal/router/split.go
+package router
+
+import (
+	"fmt"
+	"hash/crc32"
+	"math"
+	"net/http"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/proxy"
+)
+
+const (
+	defaultOverrideHeader = "X-LB-Variant"
+	defaultOverrideCookie = "lb_variant"
+)
+
+// Target is the outcome of routing a request: the route that matched, the
+// pool to use and, for split routes, the chosen variant and its server subset.
+type Target struct {
+	Route   *Route
+	Pool    *Pool
+	Variant string
+	Mirror  *Pool           // shadow pool for this request, nil unless sampled
+	servers map[string]bool // nil means every server in the pool
+}
+
+// Exclude returns an exclusion map containing every server of the target pool
+// that lies outside the variant's server subset. It is safe to add to.
+func (t *Target) Exclude() map[string]bool {
+	exclude := make(map[string]bool)
+	if t.servers == nil {
+		return exclude
+	}
+	for _, srv := range t.Pool.Manager.GetAllServers() {
+		if !t.servers[srv.ID] {
+			exclude[srv.ID] = true
+		}
+	}
+	return exclude
+}
+
+// validateSplit checks a split against the pools known to the table.
+func validateSplit(split *config.SplitConfig, routePool string, pools map[string]*Pool) error {
+	if len(split.Variants) == 0 {
+		return fmt.Errorf("split needs at least one variant")
+	}
+
+	total := 0.0
+	seen := make(map[string]bool, len(split.Variants))
+	for _, v := range split.Variants {
+		if v.Name == "" {
+			return fmt.Errorf("split variant name is required")
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("duplicate split variant %s", v.Name)
+		}
+		seen[v.Name] = true
+
+		if v.Weight < 0 || v.Weight > 100 {
+			return fmt.Errorf("variant %s: weight must be between 0 and 100", v.Name)
+		}
+		total += v.Weight
+
+		poolName := v.Pool
+		if poolName == "" {
+			poolName = routePool
+		}
+		if _, ok := pools[poolName]; !ok {
+			return fmt.Errorf("variant %s: pool %s not found", v.Name, poolName)
+		}
+	}
+	if math.Abs(total-100) > 0.001 {
+		return fmt.Errorf("split weights must add up to 100, got %.2f", total)
+	}
+	return nil
+}
+
+// pickVariant chooses the variant for r: an explicit override header or cookie
+// wins, otherwise the client's stable hash bucket selects by percentage.
+func (rt *Route) pickVariant(r *http.Request) *config.VariantConfig {
+	split := rt.Config.Split
+
+	header := split.OverrideHeader
+	if header == "" {
+		header = defaultOverrideHeader
+	}
+	forced := r.Header.Get(header)
+	if forced == "" {
+		cookieName := split.OverrideCookie
+		if cookieName == "" {
+			cookieName = defaultOverrideCookie
+		}
+		if c, err := r.Cookie(cookieName); err == nil {
+			forced = c.Value
+		}
+	}
+	if forced != "" {
+		for i := range split.Variants {
+			if split.Variants[i].Name == forced {
+				return &split.Variants[i]
+			}
+		}
+	}
+
+	// Sticky assignment: the same client always lands in the same bucket.
+	key := proxy.ClientIP(r)
+	if c, err := r.Cookie("session_id"); err == nil && c.Value != "" {
+		key = c.Value
+	}
+	bucket := float64(crc32.ChecksumIEEE([]byte(rt.Config.Name+"|"+key))%10000) / 100.0
+
+	cumulative := 0.0
+	for i := range split.Variants {
+		cumulative += split.Variants[i].Weight
+		if bucket < cumulative {
+			return &split.Variants[i]
+		}
+	}
+	// Rounding can leave the top of the range uncovered; use the last weighted variant.
+	for i := len(split.Variants) - 1; i >= 0; i-- {
+		if split.Variants[i].Weight > 0 {
+			return &split.Variants[i]
+		}
+	}
+	return &split.Variants[len(split.Variants)-1]
+}
+
+// withWeights returns a copy of the split config with new variant weights.
+func withWeights(split *config.SplitConfig, weights map[string]float64) (*config.SplitConfig, error) {
+	updated := *split
+	updated.Variants = make([]config.VariantConfig, len(split.Variants))
+	copy(updated.Variants, split.Variants)
+
+	for name := range weights {
+		found := false
+		for _, v := range updated.Variants {
+			if v.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown variant %s", name)
+		}
+	}
+	for i := range updated.Variants {
+		if w, ok := weights[updated.Variants[i].Name]; ok {
+			updated.Variants[i].Weight = w
+		}
+	}
+	return &updated, nil
+}
@@ -0,0 +1,332 @@
+// internal/router/table.go
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/server"
+)
+
+// Table is the routing table: an ordered list of routes and the named pools
+// they send traffic to. The first matching route wins; see config.RouteConfig
+// for the ordering rules.
+type Table struct {
+	mu     sync.RWMutex
+	ctx    context.Context
+	routes []*Route
+	pools  map[string]*Pool
+
+	// Defaults fills unset settings on pools created at runtime.
+	Defaults *config.Config
+
+	onPoolAdded []func(*Pool)
+}
+
+// NewTable creates an empty routing table. Pools added to the table run
+// until ctx is cancelled or they are removed.
+func NewTable(ctx context.Context, defaults *config.Config) *Table {
+	return &Table{
+		ctx:      ctx,
+		pools:    make(map[string]*Pool),
+		Defaults: defaults,
+	}
+}
+
+// OnPoolAdded registers a callback invoked for every pool added afterwards.
+func (t *Table) OnPoolAdded(fn func(*Pool)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.onPoolAdded = append(t.onPoolAdded, fn)
+}
+
+// AddPoolConfig resolves, builds, starts and registers a pool.
+func (t *Table) AddPoolConfig(cfg config.PoolConfig) (*Pool, error) {
+	if t.Defaults != nil {
+		cfg = t.Defaults.ResolvePool(cfg)
+	}
+	pool, err := NewPool(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.AddPool(pool); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+// AddPool registers and starts a pool. Pool names and server IDs must be
+// unique across the table.
+func (t *Table) AddPool(pool *Pool) error {
+	t.mu.Lock()
+	if _, exists := t.pools[pool.Name]; exists {
+		t.mu.Unlock()
+		return fmt.Errorf("pool %s already exists", pool.Name)
+	}
+	for _, srv := range pool.Manager.GetAllServers() {
+		if owner := t.findServerLocked(srv.ID); owner != nil {
+			t.mu.Unlock()
+			return fmt.Errorf("server %s already belongs to pool %s", srv.ID, owner.Name)
+		}
+	}
+	t.pools[pool.Name] = pool
+	callbacks := append([]func(*Pool){}, t.onPoolAdded...)
+	t.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(pool)
+	}
+	pool.Start(t.ctx)
+	return nil
+}
+
+// AddServer adds srv to pool, which must be in the table. Server IDs must be
+// unique across the table.
+func (t *Table) AddServer(pool *Pool, srv *server.Server) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pools[pool.Name] != pool {
+		return fmt.Errorf("pool %s not found", pool.Name)
+	}
+	if owner := t.findServerLocked(srv.ID); owner != nil {
+		return fmt.Errorf("server %s already belongs to pool %s", srv.ID, owner.Name)
+	}
+	pool.Manager.AddServer(srv)
+	return nil
+}
+
+// RemovePool stops and unregisters a pool that no route references.
+func (t *Table) RemovePool(name string) error {
+	t.mu.Lock()
+	pool, ok := t.pools[name]
+	if !ok {
+		t.mu.Unlock()
+		return fmt.Errorf("pool %s not found", name)
+	}
+	for _, route := range t.routes {
+		if route.usesPool(name) {
+			t.mu.Unlock()
+			return fmt.Errorf("pool %s is used by route %s", name, route.Config.Name)
+		}
+	}
+	delete(t.pools, name)
+	t.mu.Unlock()
+
+	pool.Stop()
+	// Removing the servers lets OnRemove listeners (e.g. upstream pools) clean up.
+	pool.Manager.UpdateServers(nil)
+	return nil
+}
+
+// Pool returns the named pool, or nil.
+func (t *Table) Pool(name string) *Pool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.pools[name]
+}
+
+// Pools returns all pools sorted by name.
+func (t *Table) Pools() []*Pool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	pools := make([]*Pool, 0, len(t.pools))
+	for _, p := range t.pools {
+		pools = append(pools, p)
+	}
+	sort.Slice(pools, func(i, j int) bool { return pools[i].Name < pools[j].Name })
+	return pools
+}
+
+// FindServer returns the pool that owns the given server ID, or nil.
+func (t *Table) FindServer(serverID string) *Pool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.findServerLocked(serverID)
+}
+
+func (t *Table) findServerLocked(serverID string) *Pool {
+	for _, pool := range t.pools {
+		for _, srv := range pool.Manager.GetAllServers() {
+			if srv.ID == serverID {
+				return pool
+			}
+		}
+	}
+	return nil
+}
+
+// SetRoute adds a route, or replaces the route with the same name in place.
+func (t *Table) SetRoute(cfg config.RouteConfig) error {
+	route, err := CompileRoute(cfg)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.pools[cfg.Pool]; !ok {
+		return fmt.Errorf("route %s: pool %s not found", cfg.Name, cfg.Pool)
+	}
+	if cfg.Split != nil {
+		if err := validateSplit(cfg.Split, cfg.Pool, t.pools); err != nil {
+			return fmt.Errorf("route %s: %w", cfg.Name, err)
+		}
+	}
+	if cfg.Mirror != nil {
+		if err := validateMirror(cfg.Mirror, t.pools); err != nil {
+			return fmt.Errorf("route %s: %w", cfg.Name, err)
+		}
+	}
+	t.putRouteLocked(route)
+	return nil
+}
+
+// putRouteLocked inserts or replaces a compiled route and re-sorts the table.
+func (t *Table) putRouteLocked(route *Route) {
+	replaced := false
+	for i, existing := range t.routes {
+		if existing.Config.Name == route.Config.Name {
+			t.routes[i] = route
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		t.routes = append(t.routes, route)
+	}
+	sort.SliceStable(t.routes, func(i, j int) bool {
+		return t.routes[i].precedes(t.routes[j])
+	})
+}
+
+// SetSplitWeights atomically replaces the variant percentages of a split
+// route, e.g. {"blue": 0, "green": 100} for a blue/green cutover. Variants
+// not mentioned keep their weight; the result must still add up to 100.
+func (t *Table) SetSplitWeights(routeName string, weights map[string]float64) (*config.SplitConfig, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var current *Route
+	for _, route := range t.routes {
+		if route.Config.Name == routeName {
+			current = route
+			break
+		}
+	}
+	if current == nil {
+		return nil, fmt.Errorf("route %s not found", routeName)
+	}
+	if current.Config.Split == nil {
+		return nil, fmt.Errorf("route %s has no traffic split", routeName)
+	}
+
+	split, err := withWeights(current.Config.Split, weights)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSplit(split, current.Config.Pool, t.pools); err != nil {
+		return nil, err
+	}
+
+	// Routes are treated as immutable once published, so swap in a copy.
+	updated := *current
+	updated.Config.Split = split
+	t.putRouteLocked(&updated)
+	return split, nil
+}
+
+// RemoveRoute deletes the named route.
+func (t *Table) RemoveRoute(name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, route := range t.routes {
+		if route.Config.Name == name {
+			t.routes = append(t.routes[:i:i], t.routes[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("route %s not found", name)
+}
+
+// Route returns the named route, or nil.
+func (t *Table) Route(name string) *Route {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, route := range t.routes {
+		if route.Config.Name == name {
+			return route
+		}
+	}
+	return nil
+}
+
+// Routes returns the configuration of every route in match order.
+func (t *Table) Routes() []config.RouteConfig {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	routes := make([]config.RouteConfig, len(t.routes))
+	for i, route := range t.routes {
+		routes[i] = route.Config
+	}
+	return routes
+}
+
+// Match returns the target for the first route accepting r, resolving any
+// traffic split to a variant and sampling the route's mirror. It returns nil
+// if no route matches.
+func (t *Table) Match(r *http.Request) *Target {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, route := range t.routes {
+		if !route.Matches(r) {
+			continue
+		}
+		pool, ok := t.pools[route.Config.Pool]
+		if !ok {
+			continue
+		}
+
+		target := &Target{Route: route, Pool: pool}
+		if m := route.Config.Mirror; m != nil && sampleMirror(m.Percentage) {
+			target.Mirror = t.pools[m.Pool]
+		}
+		if route.Config.Split != nil {
+			variant := route.pickVariant(r)
+			target.Variant = variant.Name
+			if variant.Pool != "" {
+				if p, ok := t.pools[variant.Pool]; ok {
+					target.Pool = p
+				}
+			}
+			if len(variant.Servers) > 0 {
+				target.servers = make(map[string]bool, len(variant.Servers))
+				for _, id := range variant.Servers {
+					target.servers[id] = true
+				}
+			}
+		}
+		return target
+	}
+	return nil
+}
+
+// Stop halts every pool's background loops.
+func (t *Table) Stop() {
+	for _, pool := range t.Pools() {
+		pool.Stop()
+	}
+}
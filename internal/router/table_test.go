@@ -0,0 +1,197 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"load-balancer/internal/config"
+)
+
+func newTestTable(t *testing.T) *Table {
+	t.Helper()
+	defaults := &config.Config{
+		HealthCheckInterval: time.Hour,
+		UseStickySessions:   true,
+		CircuitBreaker: config.CircuitBreakerConfig{
+			FailureThreshold: 3,
+			CooldownPeriod:   time.Second,
+			TrialRequests:    1,
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	table := NewTable(ctx, defaults)
+	for _, name := range []string{"web", "api", "admin"} {
+		_, err := table.AddPoolConfig(config.PoolConfig{
+			Name:    name,
+			Servers: []config.ServerConfig{{ID: name + "-1", Address: "localhost", Port: 1}},
+		})
+		if err != nil {
+			t.Fatalf("add pool %s: %v", name, err)
+		}
+	}
+	return table
+}
+
+func TestTable_MatchOrderAndRewrite(t *testing.T) {
+	table := newTestTable(t)
+	routes := []config.RouteConfig{
+		{Name: "admin", Host: "*.internal.example", PathPrefix: "/lb/", StripPrefix: "/lb", Pool: "admin"},
+		{Name: "api-v1", PathPrefix: "/lb/api/", Methods: []string{"get", "post"}, StripPrefix: "/lb/api",
+			Rewrite: &config.RewriteConfig{Pattern: `^/v1/(.*)$`, Replacement: "/v2/$1"}, Pool: "api"},
+		{Name: "beta", PathPrefix: "/lb/", Headers: map[string]string{"X-Beta": ""}, StripPrefix: "/lb", Pool: "api"},
+		{Name: "default", PathPrefix: "/lb/", StripPrefix: "/lb", Pool: "web"},
+	}
+	for _, route := range routes {
+		if err := table.SetRoute(route); err != nil {
+			t.Fatalf("set route %s: %v", route.Name, err)
+		}
+	}
+
+	cases := []struct {
+		name     string
+		method   string
+		target   string
+		headers  map[string]string
+		wantPool string
+		wantPath string
+	}{
+		{"host wildcard", http.MethodGet, "http://ops.internal.example/lb/status", nil, "admin", "/status"},
+		{"prefix with rewrite", http.MethodGet, "http://example.com/lb/api/v1/users", nil, "api", "/v2/users"},
+		{"method mismatch falls through", http.MethodDelete, "http://example.com/lb/api/v1/users", nil, "web", "/api/v1/users"},
+		{"header presence", http.MethodGet, "http://example.com/lb/home", map[string]string{"X-Beta": "1"}, "api", "/home"},
+		{"catch-all", http.MethodGet, "http://example.com/lb/", nil, "web", "/"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, tc.target, nil)
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+			target := table.Match(r)
+			if target == nil {
+				t.Fatalf("expected a route to match")
+			}
+			if target.Pool.Name != tc.wantPool {
+				t.Fatalf("expected pool %s, got %s (route %s)", tc.wantPool, target.Pool.Name, target.Route.Config.Name)
+			}
+			target.Route.Apply(r)
+			if r.URL.Path != tc.wantPath {
+				t.Fatalf("expected path %s, got %s", tc.wantPath, r.URL.Path)
+			}
+		})
+	}
+}
+
+func TestTable_PoolInUseCannotBeRemoved(t *testing.T) {
+	table := newTestTable(t)
+	if err := table.SetRoute(config.RouteConfig{Name: "r", PathPrefix: "/lb/", Pool: "web"}); err != nil {
+		t.Fatalf("set route: %v", err)
+	}
+	if err := table.RemovePool("web"); err == nil {
+		t.Fatalf("expected removing a referenced pool to fail")
+	}
+	if err := table.RemovePool("admin"); err != nil {
+		t.Fatalf("expected unreferenced pool removal to succeed: %v", err)
+	}
+	if _, err := table.AddPoolConfig(config.PoolConfig{
+		Name:    "dup",
+		Servers: []config.ServerConfig{{ID: "web-1"}},
+	}); err == nil {
+		t.Fatalf("expected duplicate server IDs across pools to be rejected")
+	}
+}
+
+func TestTable_AddServerKeepsIDsUniqueUnderConcurrency(t *testing.T) {
+	table := newTestTable(t)
+	web, api := table.Pool("web"), table.Pool("api")
+
+	var wg sync.WaitGroup
+	var added atomic.Int32
+	for i := 0; i < 20; i++ {
+		pool := web
+		if i%2 == 1 {
+			pool = api
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if table.AddServer(pool, NewServer(config.ServerConfig{ID: "shared", Address: "localhost", Port: 2})) == nil {
+				added.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := added.Load(); n != 1 {
+		t.Fatalf("added %d servers with the same ID, want 1", n)
+	}
+	if err := table.AddServer(table.Pool("admin"), NewServer(config.ServerConfig{ID: "web-1"})); err == nil {
+		t.Fatalf("expected a server ID taken by another pool to be rejected")
+	}
+}
+
+func TestTable_TrafficSplit(t *testing.T) {
+	table := newTestTable(t)
+	err := table.SetRoute(config.RouteConfig{
+		Name:       "release",
+		PathPrefix: "/lb/",
+		Pool:       "web",
+		Split: &config.SplitConfig{Variants: []config.VariantConfig{
+			{Name: "blue", Weight: 90},
+			{Name: "green", Pool: "api", Weight: 10},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("set route: %v", err)
+	}
+
+	request := func(client string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "http://example.com/lb/", nil)
+		r.RemoteAddr = client + ":1234"
+		return r
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		client := "10.0." + strconv.Itoa(i/250) + "." + strconv.Itoa(i%250)
+		first := table.Match(request(client))
+		again := table.Match(request(client))
+		if first.Variant != again.Variant {
+			t.Fatalf("expected sticky assignment for %s, got %s then %s", client, first.Variant, again.Variant)
+		}
+		counts[first.Variant]++
+		if first.Variant == "green" && first.Pool.Name != "api" {
+			t.Fatalf("expected green variant to use pool api, got %s", first.Pool.Name)
+		}
+	}
+	if counts["green"] < 120 || counts["green"] > 280 {
+		t.Fatalf("expected roughly 10%% green traffic, got %v", counts)
+	}
+
+	forced := request("10.0.0.1")
+	forced.Header.Set("X-LB-Variant", "green")
+	if got := table.Match(forced).Variant; got != "green" {
+		t.Fatalf("expected override header to force green, got %s", got)
+	}
+
+	if _, err := table.SetSplitWeights("release", map[string]float64{"blue": 50}); err == nil {
+		t.Fatalf("expected weights not adding up to 100 to be rejected")
+	}
+	if _, err := table.SetSplitWeights("release", map[string]float64{"blue": 0, "green": 100}); err != nil {
+		t.Fatalf("cutover failed: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		if got := table.Match(request("10.1.0." + strconv.Itoa(i))).Variant; got != "green" {
+			t.Fatalf("expected all traffic on green after cutover, got %s", got)
+		}
+	}
+}
@@ -0,0 +1,519 @@
+// internal/scenario/engine.go
+package scenario
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"load-balancer/internal/events"
+)
+
+// Run states.
+const (
+	StateRunning   = "running"
+	StateCompleted = "completed"
+	StateFailed    = "failed"
+	StateCancelled = "cancelled"
+)
+
+// ErrBusy is returned when a scenario is started while another is running.
+var ErrBusy = errors.New("a scenario is already running")
+
+// ServerState is a backend as a step's server selectors see it.
+type ServerState struct {
+	ID     string
+	Online bool // enabled and taking traffic
+}
+
+// Fleet applies server actions on behalf of the engine.
+type Fleet interface {
+	Servers() []ServerState
+	Apply(serverID string, action Action) error
+}
+
+// FaultInjector makes backends misbehave on demand.
+type FaultInjector interface {
+	InjectFault(ctx context.Context, serverID string, fault Fault) error
+	ClearFaults(ctx context.Context, serverID string) error
+}
+
+// Status reports a run.
+type Status struct {
+	ID         string    `json:"id"`
+	Scenario   Scenario  `json:"scenario"`
+	State      string    `json:"state"`
+	Step       int       `json:"step"` // 1-based step in progress, or the last one run
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Report     *Report   `json:"report,omitempty"` // set once the run has finished
+}
+
+// Progress is published to subscribers while a run is going.
+type Progress struct {
+	Run      string    `json:"run"`
+	Type     string    `json:"type"` // "step" when a step starts, "progress" every second
+	Step     int       `json:"step"`
+	StepName string    `json:"stepName,omitempty"`
+	Requests int       `json:"requests"` // completed so far in the whole run
+	Errors   int       `json:"errors"`
+	Time     time.Time `json:"time"`
+}
+
+// Bounds on concurrency and on how many finished runs are remembered.
+const (
+	maxInFlight = 256
+	maxRuns     = 20
+)
+
+// Engine runs scenarios one at a time against the balancer.
+type Engine struct {
+	Sender Sender
+	Fleet  Fleet
+	Events *events.EventSystem
+
+	// Faults, when set, lets steps inject backend faults
+	Faults FaultInjector
+
+	ctx   context.Context
+	mu    sync.Mutex
+	seq   int
+	runs  map[string]*run
+	order []string // run IDs, oldest first
+}
+
+type run struct {
+	mu     sync.Mutex
+	status Status
+	steps  []StepReport
+	subs   map[chan Progress]struct{}
+	cancel context.CancelFunc
+}
+
+// NewEngine creates an engine whose runs stop when ctx is cancelled.
+func NewEngine(ctx context.Context, sender Sender, fleet Fleet, es *events.EventSystem) *Engine {
+	return &Engine{
+		Sender: sender,
+		Fleet:  fleet,
+		Events: es,
+		ctx:    ctx,
+		runs:   make(map[string]*run),
+	}
+}
+
+// Catalog returns the built-in scenarios.
+func (e *Engine) Catalog() []Scenario {
+	return Builtin()
+}
+
+// Lookup finds a built-in scenario by name.
+func (e *Engine) Lookup(name string) (Scenario, bool) {
+	for _, s := range e.Catalog() {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Scenario{}, false
+}
+
+// Start validates a scenario and runs it in the background.
+func (e *Engine) Start(s Scenario) (Status, error) {
+	if err := s.Validate(); err != nil {
+		return Status{}, err
+	}
+
+	e.mu.Lock()
+	for _, r := range e.runs {
+		if r.state() == StateRunning {
+			e.mu.Unlock()
+			return Status{}, ErrBusy
+		}
+	}
+	e.seq++
+	ctx, cancel := context.WithCancel(e.ctx)
+	r := &run{
+		status: Status{ID: fmt.Sprintf("run-%d", e.seq), Scenario: s, State: StateRunning, StartedAt: time.Now()},
+		subs:   make(map[chan Progress]struct{}),
+		cancel: cancel,
+	}
+	e.runs[r.status.ID] = r
+	e.order = append(e.order, r.status.ID)
+	for len(e.order) > maxRuns {
+		delete(e.runs, e.order[0])
+		e.order = e.order[1:]
+	}
+	e.mu.Unlock()
+
+	e.publish(events.InfoEvent, fmt.Sprintf("Scenario %s started (%s)", s.Name, r.status.ID))
+	go e.execute(ctx, r)
+	return r.snapshot(), nil
+}
+
+// Cancel stops a running scenario. Requests in flight are abandoned and the
+// report covers what completed.
+func (e *Engine) Cancel(id string) (Status, error) {
+	e.mu.Lock()
+	r, ok := e.runs[id]
+	e.mu.Unlock()
+	if !ok {
+		return Status{}, fmt.Errorf("no scenario run %s", id)
+	}
+	r.cancel()
+	return r.snapshot(), nil
+}
+
+// Status returns a run.
+func (e *Engine) Status(id string) (Status, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	r, ok := e.runs[id]
+	if !ok {
+		return Status{}, false
+	}
+	return r.snapshot(), true
+}
+
+// List returns the remembered runs, newest first.
+func (e *Engine) List() []Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	result := make([]Status, 0, len(e.order))
+	for i := len(e.order) - 1; i >= 0; i-- {
+		result = append(result, e.runs[e.order[i]].snapshot())
+	}
+	return result
+}
+
+// Subscribe streams a run's progress. The channel is closed when the run
+// finishes (immediately if it already has); call cancel to stop early.
+func (e *Engine) Subscribe(id string) (<-chan Progress, func(), bool) {
+	e.mu.Lock()
+	r, ok := e.runs[id]
+	e.mu.Unlock()
+	if !ok {
+		return nil, nil, false
+	}
+
+	ch := make(chan Progress, 64)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status.State != StateRunning {
+		close(ch)
+		return ch, func() {}, true
+	}
+	r.subs[ch] = struct{}{}
+	return ch, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if _, ok := r.subs[ch]; ok {
+			delete(r.subs, ch)
+			close(ch)
+		}
+	}, true
+}
+
+func (e *Engine) execute(ctx context.Context, r *run) {
+	defer r.cancel()
+
+	s := r.status.Scenario
+	total := newCollector()
+	faulted := make(map[string]bool)
+	var runErr error
+
+	for i, step := range s.Steps {
+		if ctx.Err() != nil {
+			break
+		}
+		r.beginStep(i+1, step.Name, total)
+
+		if runErr = e.applyServers(step); runErr != nil {
+			break
+		}
+		if runErr = e.injectFaults(ctx, step, faulted); runErr != nil {
+			break
+		}
+
+		stepTotals := newCollector()
+		e.traffic(ctx, r, i+1, step, total, stepTotals)
+		r.endStep(StepReport{Name: step.Name, Report: stepTotals.report()})
+	}
+
+	// Faults never outlive the run that injected them
+	clearCtx, cancelClear := context.WithTimeout(context.Background(), 5*time.Second)
+	for id := range faulted {
+		if err := e.Faults.ClearFaults(clearCtx, id); err != nil {
+			e.Events.Emit(events.Event{
+				Type:     events.WarningEvent,
+				Source:   "scenario",
+				ServerID: id,
+				Message:  fmt.Sprintf("Scenario %s: clearing faults on %s failed: %v", s.Name, id, err),
+			})
+		}
+	}
+	cancelClear()
+
+	state := StateCompleted
+	switch {
+	case runErr != nil:
+		state = StateFailed
+	case ctx.Err() != nil:
+		state = StateCancelled
+	}
+	report := r.finish(state, runErr, total)
+
+	switch state {
+	case StateCompleted:
+		e.publish(events.SuccessEvent, fmt.Sprintf("Scenario %s completed: %d requests, %d errors, p95 %.0fms",
+			s.Name, report.Requests, report.Errors, report.Latency.P95))
+	case StateCancelled:
+		e.publish(events.WarningEvent, fmt.Sprintf("Scenario %s cancelled after %d requests", s.Name, report.Requests))
+	default:
+		e.publish(events.ErrorEvent, fmt.Sprintf("Scenario %s failed: %v", s.Name, runErr))
+	}
+}
+
+// applyServers performs a step's server actions in order.
+func (e *Engine) applyServers(step Step) error {
+	for _, sa := range step.Servers {
+		ids, err := e.resolve(sa.Server)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := e.Fleet.Apply(id, sa.Action); err != nil {
+				return fmt.Errorf("%s %s: %w", sa.Action, id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// injectFaults performs a step's fault injections, noting the servers
+// touched so the faults can be cleared afterwards.
+func (e *Engine) injectFaults(ctx context.Context, step Step, faulted map[string]bool) error {
+	if len(step.Faults) > 0 && e.Faults == nil {
+		return fmt.Errorf("fault injection is not available")
+	}
+	for _, fa := range step.Faults {
+		ids, err := e.resolve(fa.Server)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := e.Faults.InjectFault(ctx, id, fa.Fault); err != nil {
+				return fmt.Errorf("inject %s fault into %s: %w", fa.Fault.Type, id, err)
+			}
+			faulted[id] = true
+		}
+	}
+	return nil
+}
+
+// resolve turns a server selector into server IDs.
+func (e *Engine) resolve(selector string) ([]string, error) {
+	if e.Fleet == nil {
+		return nil, fmt.Errorf("server actions are not available")
+	}
+	servers := e.Fleet.Servers()
+	var ids []string
+	for _, srv := range servers {
+		switch {
+		case selector == SelectAll, selector == srv.ID:
+			ids = append(ids, srv.ID)
+		case selector == SelectFirstOnline && srv.Online:
+			return []string{srv.ID}, nil
+		}
+	}
+	if len(ids) == 0 {
+		if selector == SelectFirstOnline {
+			return nil, fmt.Errorf("no server is online")
+		}
+		return nil, fmt.Errorf("server %s not found", selector)
+	}
+	return ids, nil
+}
+
+// traffic sends a step's burst and then its steady stream, waiting for
+// every request to finish.
+func (e *Engine) traffic(ctx context.Context, r *run, stepNum int, step Step, collectors ...*collector) {
+	path := step.Path
+	if path == "" {
+		path = "/lb/"
+	}
+	priorities := newMix(step.Priority)
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxInFlight)
+	send := func() bool {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return false
+		}
+		req := Request{Path: path, Priority: priorities.next()}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			res := e.Sender.Send(ctx, req)
+			if res.Err != nil && ctx.Err() != nil {
+				return // abandoned by cancellation, not a failure of the balancer
+			}
+			for _, c := range collectors {
+				c.record(res)
+			}
+		}()
+		return true
+	}
+
+	for n := 0; n < step.Requests && send(); n++ {
+	}
+
+	if step.Duration.Duration > 0 {
+		deadline := time.NewTimer(step.Duration.Duration)
+		defer deadline.Stop()
+		progress := time.NewTicker(time.Second)
+		defer progress.Stop()
+
+		var tick <-chan time.Time
+		if step.RPS > 0 {
+			ticker := time.NewTicker(time.Second / time.Duration(step.RPS))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+	loop:
+		for {
+			select {
+			case <-ctx.Done():
+				break loop
+			case <-deadline.C:
+				break loop
+			case <-tick:
+				send()
+			case <-progress.C:
+				r.progress("progress", stepNum, step.Name, collectors[0])
+			}
+		}
+	}
+	wg.Wait()
+}
+
+func (r *run) beginStep(stepNum int, name string, total *collector) {
+	r.mu.Lock()
+	r.status.Step = stepNum
+	r.mu.Unlock()
+	r.progress("step", stepNum, name, total)
+}
+
+func (r *run) endStep(sr StepReport) {
+	r.mu.Lock()
+	r.steps = append(r.steps, sr)
+	r.mu.Unlock()
+}
+
+// progress sends an update to every subscriber that keeps up.
+func (r *run) progress(kind string, stepNum int, name string, total *collector) {
+	requests, errs := total.counts()
+	p := Progress{
+		Run:      r.status.ID,
+		Type:     kind,
+		Step:     stepNum,
+		StepName: name,
+		Requests: requests,
+		Errors:   errs,
+		Time:     time.Now(),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for ch := range r.subs {
+		select {
+		case ch <- p:
+		default:
+		}
+	}
+}
+
+// finish records the final report and ends every subscription.
+func (r *run) finish(state string, err error, total *collector) Report {
+	report := total.report()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report.Steps = r.steps
+	r.status.State = state
+	r.status.FinishedAt = time.Now()
+	r.status.Report = &report
+	if err != nil {
+		r.status.Error = err.Error()
+	}
+	for ch := range r.subs {
+		delete(r.subs, ch)
+		close(ch)
+	}
+	return report
+}
+
+func (r *run) state() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status.State
+}
+
+func (r *run) snapshot() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// mix spreads requests over priorities in proportion to their weights using
+// smooth weighted round robin, so even short bursts get the exact mix.
+type mix struct {
+	names   []string
+	weights []float64
+	current []float64
+	total   float64
+}
+
+func newMix(weights map[string]float64) *mix {
+	m := &mix{}
+	for name, w := range weights {
+		if w > 0 {
+			m.names = append(m.names, name)
+		}
+	}
+	sort.Strings(m.names)
+	for _, name := range m.names {
+		m.weights = append(m.weights, weights[name])
+		m.total += weights[name]
+	}
+	m.current = make([]float64, len(m.names))
+	return m
+}
+
+// next returns the priority for the next request; "" when no mix is set.
+func (m *mix) next() string {
+	if len(m.names) == 0 {
+		return ""
+	}
+	best := 0
+	for i := range m.names {
+		m.current[i] += m.weights[i]
+		if m.current[i] > m.current[best] {
+			best = i
+		}
+	}
+	m.current[best] -= m.total
+	return m.names[best]
+}
+
+func (e *Engine) publish(eventType events.EventType, message string) {
+	e.Events.Emit(events.Event{Type: eventType, Source: "scenario", Message: message})
+}
@@ -0,0 +1,214 @@
+package scenario
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/events"
+)
+
+// fakeBackends answers requests round-robin from a set of servers, failing
+// every request to a disabled one.
+type fakeBackends struct {
+	mu         sync.Mutex
+	servers    []ServerState
+	next       int
+	priorities map[string]int
+	actions    []string
+	delay      time.Duration
+}
+
+func newFakeBackends(ids ...string) *fakeBackends {
+	f := &fakeBackends{priorities: make(map[string]int)}
+	for _, id := range ids {
+		f.servers = append(f.servers, ServerState{ID: id, Online: true})
+	}
+	return f
+}
+
+func (f *fakeBackends) Send(ctx context.Context, req Request) Result {
+	f.mu.Lock()
+	f.priorities[req.Priority]++
+	var online []string
+	for _, s := range f.servers {
+		if s.Online {
+			online = append(online, s.ID)
+		}
+	}
+	var served string
+	if len(online) > 0 {
+		served = online[f.next%len(online)]
+		f.next++
+	}
+	f.mu.Unlock()
+
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return Result{Err: ctx.Err()}
+	}
+	if served == "" {
+		return Result{Status: 503, Latency: f.delay}
+	}
+	return Result{Server: served, Status: 200, Latency: f.delay}
+}
+
+func (f *fakeBackends) Servers() []ServerState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]ServerState(nil), f.servers...)
+}
+
+func (f *fakeBackends) Apply(serverID string, action Action) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.actions = append(f.actions, string(action)+" "+serverID)
+	for i := range f.servers {
+		if f.servers[i].ID == serverID {
+			f.servers[i].Online = action != ActionDisable
+		}
+	}
+	return nil
+}
+
+func waitFinished(t *testing.T, e *Engine, id string) Status {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, _ := e.Status(id)
+		if status.State != StateRunning {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run %s still running", id)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestEngine_RunsStepsAndReports(t *testing.T) {
+	backends := newFakeBackends("server-1", "server-2")
+	backends.delay = time.Millisecond
+	engine := NewEngine(context.Background(), backends, backends, events.NewEventSystem(100))
+
+	status, err := engine.Start(Scenario{
+		Name: "drill",
+		Steps: []Step{
+			{Name: "spike", Requests: 14, Priority: map[string]float64{"critical": 8, "medium": 6}},
+			{Name: "fail over", Servers: []ServerAction{{Server: SelectFirstOnline, Action: ActionDisable}}},
+			{Name: "steady", Duration: config.Duration{Duration: 200 * time.Millisecond}, RPS: 50},
+		},
+	})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	updates, _, ok := engine.Subscribe(status.ID)
+	if !ok {
+		t.Fatalf("subscribe: run not found")
+	}
+
+	final := waitFinished(t, engine, status.ID)
+	if final.State != StateCompleted {
+		t.Fatalf("state = %s (%s), want completed", final.State, final.Error)
+	}
+	report := final.Report
+	if len(report.Steps) != 3 || report.Steps[0].Requests != 14 {
+		t.Fatalf("step reports = %+v", report.Steps)
+	}
+	if backends.priorities["critical"] != 8 || backends.priorities["medium"] != 6 {
+		t.Errorf("priority mix = %v, want 8 critical and 6 medium", backends.priorities)
+	}
+	if len(backends.actions) != 1 || backends.actions[0] != "disable server-1" {
+		t.Errorf("actions = %v, want the first online server disabled", backends.actions)
+	}
+
+	steady := report.Steps[2]
+	if steady.Requests < 5 || steady.Servers["server-1"].Requests != 0 || steady.Servers["server-2"].Share != 1 {
+		t.Errorf("steady step after failover = %+v, want all traffic on server-2", steady.Report)
+	}
+	if report.Requests != 14+steady.Requests || report.Errors != 0 || report.StatusCodes[200] != report.Requests {
+		t.Errorf("totals = %d requests, %d errors, codes %v", report.Requests, report.Errors, report.StatusCodes)
+	}
+	if report.Latency.P50 <= 0 || report.Latency.P99 < report.Latency.P50 || report.Latency.Max < report.Latency.P99 {
+		t.Errorf("latency = %+v", report.Latency)
+	}
+
+	var steps []string
+	for p := range updates {
+		if p.Type == "step" {
+			steps = append(steps, p.StepName)
+		}
+	}
+	// The first step may begin before the subscription is made
+	if len(steps) < 2 || steps[len(steps)-1] != "steady" {
+		t.Errorf("step progress = %v", steps)
+	}
+}
+
+func TestEngine_CancelStopsTheRun(t *testing.T) {
+	backends := newFakeBackends("server-1")
+	backends.delay = time.Hour // requests only end when cancelled
+	engine := NewEngine(context.Background(), backends, backends, events.NewEventSystem(100))
+
+	status, err := engine.Start(Scenario{
+		Name:  "long",
+		Steps: []Step{{Duration: config.Duration{Duration: time.Minute}, RPS: 10}},
+	})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if _, err := engine.Start(Builtin()[0]); err != ErrBusy {
+		t.Errorf("second start = %v, want ErrBusy", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if _, err := engine.Cancel(status.ID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	final := waitFinished(t, engine, status.ID)
+	if final.State != StateCancelled {
+		t.Errorf("state = %s, want cancelled", final.State)
+	}
+	if final.Report == nil || final.Report.TransportErrors != 0 {
+		t.Errorf("report = %+v, want abandoned requests left out", final.Report)
+	}
+}
+
+func TestScenario_ValidateRejectsBadSteps(t *testing.T) {
+	cases := map[string]Scenario{
+		"no steps":       {Name: "x"},
+		"rps no time":    {Name: "x", Steps: []Step{{RPS: 5}}},
+		"unknown action": {Name: "x", Steps: []Step{{Servers: []ServerAction{{Server: "*", Action: "explode"}}}}},
+		"fault no type":  {Name: "x", Steps: []Step{{Faults: []FaultAction{{Server: "server-1"}}}}},
+		"other host":     {Name: "x", Steps: []Step{{Path: "@example.org/x", Requests: 1}}},
+		"off the proxy":  {Name: "x", Steps: []Step{{Path: "/api/servers", Requests: 1}}},
+	}
+	for name, s := range cases {
+		if err := s.Validate(); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+	for _, s := range Builtin() {
+		if err := s.Validate(); err != nil {
+			t.Errorf("built-in %s: %v", s.Name, err)
+		}
+	}
+}
+
+func TestHTTPSender_KeepsTheBalancerHost(t *testing.T) {
+	sender := NewHTTPSender("http://127.0.0.1:8080")
+	for path, want := range map[string]string{
+		"/lb/a?b=1":          "http://127.0.0.1:8080/lb/a?b=1",
+		"//example.org/lb/x": "http://127.0.0.1:8080//example.org/lb/x",
+	} {
+		if got, err := sender.url(path); err != nil || got != want {
+			t.Errorf("url(%q) = %q, %v; want %q", path, got, err, want)
+		}
+	}
+	if _, err := sender.url("@example.org/x"); err == nil {
+		t.Error("expected a path without a leading / to be refused")
+	}
+}
@@ -0,0 +1,149 @@
+// internal/scenario/report.go
+package scenario
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"load-balancer/internal/metrics"
+)
+
+// Latency summarises response times in milliseconds.
+type Latency struct {
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+	Mean float64 `json:"mean"`
+}
+
+// ServerReport is the traffic one backend served.
+type ServerReport struct {
+	Requests int     `json:"requests"`
+	Errors   int     `json:"errors"`
+	Share    float64 `json:"share"` // fraction of all answered requests
+}
+
+// Report is the result of a run, or of one of its steps.
+type Report struct {
+	Requests        int                     `json:"requests"`
+	Errors          int                     `json:"errors"`          // transport errors and 4xx/5xx responses
+	TransportErrors int                     `json:"transportErrors"` // requests that got no response at all
+	ErrorRate       float64                 `json:"errorRate"`
+	StatusCodes     map[int]int             `json:"statusCodes"`
+	Latency         Latency                 `json:"latency"`
+	Servers         map[string]ServerReport `json:"servers"`
+	Elapsed         string                  `json:"elapsed"`
+	Steps           []StepReport            `json:"steps,omitempty"`
+}
+
+// StepReport is the Report of one step.
+type StepReport struct {
+	Name string `json:"name"`
+	Report
+}
+
+// collector accumulates results while requests are in flight.
+type collector struct {
+	mu        sync.Mutex
+	started   time.Time
+	latencies []float64
+	errors    int
+	transport int
+	codes     map[int]int
+	servers   map[string]*ServerReport
+}
+
+func newCollector() *collector {
+	return &collector{
+		started: time.Now(),
+		codes:   make(map[int]int),
+		servers: make(map[string]*ServerReport),
+	}
+}
+
+func (c *collector) record(res Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.latencies = append(c.latencies, float64(res.Latency.Microseconds())/1000)
+	failed := res.Err != nil || res.Status >= 400
+	if failed {
+		c.errors++
+	}
+	if res.Err != nil {
+		c.transport++
+	} else {
+		c.codes[res.Status]++
+	}
+	if res.Server != "" {
+		sr := c.servers[res.Server]
+		if sr == nil {
+			sr = &ServerReport{}
+			c.servers[res.Server] = sr
+		}
+		sr.Requests++
+		if failed {
+			sr.Errors++
+		}
+	}
+}
+
+// counts returns the requests and errors recorded so far.
+func (c *collector) counts() (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.latencies), c.errors
+}
+
+func (c *collector) report() Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r := Report{
+		Requests:        len(c.latencies),
+		Errors:          c.errors,
+		TransportErrors: c.transport,
+		StatusCodes:     make(map[int]int, len(c.codes)),
+		Servers:         make(map[string]ServerReport, len(c.servers)),
+		Elapsed:         time.Since(c.started).Round(time.Millisecond).String(),
+	}
+	if r.Requests > 0 {
+		r.ErrorRate = float64(r.Errors) / float64(r.Requests)
+	}
+	for code, n := range c.codes {
+		r.StatusCodes[code] = n
+	}
+
+	answered := 0
+	for _, sr := range c.servers {
+		answered += sr.Requests
+	}
+	for id, sr := range c.servers {
+		out := *sr
+		if answered > 0 {
+			out.Share = float64(sr.Requests) / float64(answered)
+		}
+		r.Servers[id] = out
+	}
+
+	if len(c.latencies) > 0 {
+		sorted := append([]float64(nil), c.latencies...)
+		sort.Float64s(sorted)
+		sum := 0.0
+		for _, v := range sorted {
+			sum += v
+		}
+		r.Latency = Latency{
+			P50:  metrics.Percentile(sorted, 50),
+			P90:  metrics.Percentile(sorted, 90),
+			P95:  metrics.Percentile(sorted, 95),
+			P99:  metrics.Percentile(sorted, 99),
+			Max:  sorted[len(sorted)-1],
+			Mean: sum / float64(len(sorted)),
+		}
+	}
+	return r
+}
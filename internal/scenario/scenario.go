@@ -0,0 +1,171 @@
+// internal/scenario/scenario.go
+package scenario
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"load-balancer/internal/config"
+)
+
+// Scenario is a declarative drill: a list of steps run in order.
+type Scenario struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Steps       []Step `json:"steps"`
+}
+
+// Step applies server actions and faults, then sends traffic. With RPS and
+// Duration set it sends a steady stream; with Requests it sends one burst;
+// with only Duration it waits.
+type Step struct {
+	Name     string             `json:"name,omitempty"`
+	Servers  []ServerAction     `json:"servers,omitempty"`
+	Faults   []FaultAction      `json:"faults,omitempty"`
+	Duration config.Duration    `json:"duration,omitempty"`
+	RPS      int                `json:"rps,omitempty"`
+	Requests int                `json:"requests,omitempty"`
+	Priority map[string]float64 `json:"priority,omitempty"` // priority -> relative weight; default all normal
+	Path     string             `json:"path,omitempty"`     // request path; default /lb/
+}
+
+// Action is something a step does to a server.
+type Action string
+
+const (
+	ActionEnable      Action = "enable"
+	ActionDisable     Action = "disable"
+	ActionReset       Action = "reset"
+	ActionDrain       Action = "drain"
+	ActionMaintenance Action = "maintenance"
+	ActionActivate    Action = "activate"
+)
+
+// Server selectors besides a literal server ID.
+const (
+	SelectAll         = "*"
+	SelectFirstOnline = "first-online"
+)
+
+// ServerAction applies Action to the selected servers.
+type ServerAction struct {
+	Server string `json:"server"` // server ID, "*" or "first-online"
+	Action Action `json:"action"`
+}
+
+// Fault describes misbehaviour injected into a backend, in the form its
+// /admin/faults endpoint accepts (see testserver.Fault).
+type Fault struct {
+	Type       string          `json:"type"`                 // latency, error, hang, drip, reset or flap-health
+	Latency    config.Duration `json:"latency,omitempty"`    // added delay for latency and drip faults
+	StatusCode int             `json:"statusCode,omitempty"` // status returned by error faults
+	Rate       float64         `json:"rate,omitempty"`       // fraction of requests affected (0-1); default all
+	Period     config.Duration `json:"period,omitempty"`     // flap-health half cycle
+	Delay      config.Duration `json:"delay,omitempty"`      // wait before the fault starts
+	Duration   config.Duration `json:"duration,omitempty"`   // how long the fault lasts; 0 until cleared
+}
+
+// FaultAction injects a fault into the selected servers.
+type FaultAction struct {
+	Server string `json:"server"`
+	Fault  Fault  `json:"fault"`
+}
+
+// Limits keep a single scenario from overwhelming the balancer it drills.
+const (
+	maxSteps    = 50
+	maxRPS      = 1000
+	maxRequests = 10000
+	maxDuration = 10 * time.Minute
+)
+
+// Validate checks a scenario before it runs.
+func (s Scenario) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("scenario name is required")
+	}
+	if len(s.Steps) == 0 || len(s.Steps) > maxSteps {
+		return fmt.Errorf("scenario %s: needs between 1 and %d steps", s.Name, maxSteps)
+	}
+	for i, step := range s.Steps {
+		// Traffic must stay on the balancer's own proxy endpoint
+		if step.Path != "" && (!strings.HasPrefix(step.Path, "/lb/") || strings.Contains(step.Path, "..")) {
+			return fmt.Errorf("step %d: path must start with /lb/", i+1)
+		}
+		if step.RPS < 0 || step.RPS > maxRPS {
+			return fmt.Errorf("step %d: rps must be between 0 and %d", i+1, maxRPS)
+		}
+		if step.Requests < 0 || step.Requests > maxRequests {
+			return fmt.Errorf("step %d: requests must be between 0 and %d", i+1, maxRequests)
+		}
+		if step.Duration.Duration < 0 || step.Duration.Duration > maxDuration {
+			return fmt.Errorf("step %d: duration must be between 0 and %v", i+1, maxDuration)
+		}
+		if step.RPS > 0 && step.Duration.Duration == 0 {
+			return fmt.Errorf("step %d: rps needs a duration", i+1)
+		}
+		for priority, weight := range step.Priority {
+			if weight < 0 {
+				return fmt.Errorf("step %d: negative weight for priority %s", i+1, priority)
+			}
+		}
+		for _, sa := range step.Servers {
+			switch sa.Action {
+			case ActionEnable, ActionDisable, ActionReset, ActionDrain, ActionMaintenance, ActionActivate:
+			default:
+				return fmt.Errorf("step %d: unknown server action %q", i+1, sa.Action)
+			}
+			if sa.Server == "" {
+				return fmt.Errorf("step %d: server action needs a server", i+1)
+			}
+		}
+		for _, fa := range step.Faults {
+			if fa.Server == "" || fa.Fault.Type == "" {
+				return fmt.Errorf("step %d: fault needs a server and a type", i+1)
+			}
+		}
+	}
+	return nil
+}
+
+func seconds(n int) config.Duration {
+	return config.Duration{Duration: time.Duration(n) * time.Second}
+}
+
+// Builtin returns the Scenario Lab drills.
+func Builtin() []Scenario {
+	return []Scenario{
+		{
+			Name:        "failure",
+			Description: "Disable the first online server and watch traffic fail over",
+			Steps: []Step{
+				{Name: "disable", Servers: []ServerAction{{Server: SelectFirstOnline, Action: ActionDisable}}},
+				{Name: "failover traffic", Duration: seconds(5), RPS: 10},
+			},
+		},
+		{
+			Name:        "heavy",
+			Description: "A high-priority burst followed by sustained load",
+			Steps: []Step{
+				{Name: "burst", Requests: 60, Priority: map[string]float64{"high": 1}},
+				{Name: "sustained", Duration: seconds(10), RPS: 30, Priority: map[string]float64{"high": 1, "normal": 2}},
+			},
+		},
+		{
+			Name:        "priority",
+			Description: "A spike of critical requests mixed with medium ones",
+			Steps: []Step{
+				{Name: "spike", Requests: 14, Priority: map[string]float64{"critical": 8, "medium": 6}},
+			},
+		},
+		{
+			Name:        "recovery",
+			Description: "Re-enable and reset every server, then verify traffic",
+			Steps: []Step{
+				{Name: "reset all", Servers: []ServerAction{{Server: SelectAll, Action: ActionReset}}},
+				{Name: "verify", Duration: seconds(3), RPS: 5},
+			},
+		},
+	}
+}
@@ -0,0 +1,101 @@
+// internal/scenario/sender.go
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Request is one request a step sends through the balancer.
+type Request struct {
+	Path     string
+	Priority string
+}
+
+// Result is the outcome of one request.
+type Result struct {
+	Server  string // backend that answered, if the balancer said
+	Status  int    // 0 when no response arrived
+	Latency time.Duration
+	Err     error
+}
+
+// Sender sends scenario traffic.
+type Sender interface {
+	Send(ctx context.Context, req Request) Result
+}
+
+// HTTPSender sends scenario traffic to a balancer over HTTP, reading the
+// serving backend from the X-Served-By response header.
+type HTTPSender struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewHTTPSender creates a sender for the balancer at baseURL.
+func NewHTTPSender(baseURL string) *HTTPSender {
+	return &HTTPSender{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Send issues a GET and waits for the whole body. Only the path and query of
+// req.Path are used, so a request never leaves the balancer at BaseURL.
+func (s *HTTPSender) Send(ctx context.Context, req Request) Result {
+	start := time.Now()
+	target, err := s.url(req.Path)
+	if err != nil {
+		return Result{Err: err}
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return Result{Err: err}
+	}
+	if req.Priority != "" {
+		httpReq.Header.Set("X-Task-Priority", req.Priority)
+	}
+
+	resp, err := s.Client.Do(httpReq)
+	if err != nil {
+		return Result{Latency: time.Since(start), Err: err}
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(io.Discard, resp.Body)
+
+	return Result{
+		Server:  resp.Header.Get("X-Served-By"),
+		Status:  resp.StatusCode,
+		Latency: time.Since(start),
+		Err:     err,
+	}
+}
+
+// url joins path to BaseURL, keeping BaseURL's scheme and host.
+func (s *HTTPSender) url(path string) (string, error) {
+	base, err := url.Parse(s.BaseURL)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(path, "/") {
+		return "", fmt.Errorf("invalid request path %q", path)
+	}
+	rawPath, rawQuery, _ := strings.Cut(path, "?")
+	unescaped, err := url.PathUnescape(rawPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid request path %q: %w", path, err)
+	}
+	target := url.URL{
+		Scheme:   base.Scheme,
+		Host:     base.Host,
+		Path:     unescaped,
+		RawPath:  rawPath,
+		RawQuery: rawQuery,
+	}
+	return target.String(), nil
+}
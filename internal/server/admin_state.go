@@ -0,0 +1,87 @@
+// internal/server/admin_state.go
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
+
+// AdminState is the operator-controlled rotation state of a server,
+// independent of its health and circuit breaker.
+type AdminState int32
+
+const (
+	// AdminActive servers receive traffic normally.
+	AdminActive AdminState = iota
+	// AdminDraining servers take no new requests or sticky bindings while
+	// their in-flight requests finish.
+	AdminDraining
+	// AdminMaintenance servers are out of rotation until reactivated.
+	AdminMaintenance
+)
+
+func (s AdminState) String() string {
+	switch s {
+	case AdminDraining:
+		return "draining"
+	case AdminMaintenance:
+		return "maintenance"
+	default:
+		return "active"
+	}
+}
+
+// MarshalJSON encodes the state by name.
+func (s AdminState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON decodes a state name.
+func (s *AdminState) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	switch name {
+	case "", "active":
+		*s = AdminActive
+	case "draining":
+		*s = AdminDraining
+	case "maintenance":
+		*s = AdminMaintenance
+	default:
+		return fmt.Errorf("unknown admin state %q", name)
+	}
+	return nil
+}
+
+// GetAdminState returns the server's admin state.
+func GetAdminState(srv *Server) AdminState {
+	if srv == nil {
+		return AdminActive
+	}
+	return AdminState(atomic.LoadInt32((*int32)(&srv.AdminState)))
+}
+
+// SetAdminState updates the server's admin state.
+func SetAdminState(srv *Server, state AdminState) {
+	if srv == nil {
+		return
+	}
+	atomic.StoreInt32((*int32)(&srv.AdminState), int32(state))
+}
+
+// CompareAndSetAdminState moves the server from one state to another,
+// reporting whether it was still in the expected state.
+func CompareAndSetAdminState(srv *Server, from, to AdminState) bool {
+	if srv == nil {
+		return false
+	}
+	return atomic.CompareAndSwapInt32((*int32)(&srv.AdminState), int32(from), int32(to))
+}
+
+// IsAccepting reports whether the server may be given new requests.
+func IsAccepting(srv *Server) bool {
+	return GetAdminState(srv) == AdminActive
+}
@@ -5,8 +5,10 @@ import "sync"
 
 // Manager holds the list of servers and provides concurrency-safe access.
 type Manager struct {
-	mu      sync.RWMutex
-	servers []*Server
+	mu          sync.RWMutex
+	servers     []*Server
+	onRemove    []func(*Server)
+	listenersMu sync.Mutex
 }
 
 // NewManager creates a new Manager instance.
@@ -14,6 +16,15 @@ func NewManager(servers []*Server) *Manager {
 	return &Manager{servers: servers}
 }
 
+// OnRemove registers a callback that runs whenever a server leaves the pool,
+// either through RemoveServer or because UpdateServers no longer lists it.
+func (m *Manager) OnRemove(fn func(*Server)) {
+	m.listenersMu.Lock()
+	defer m.listenersMu.Unlock()
+
+	m.onRemove = append(m.onRemove, fn)
+}
+
 // GetAllServers returns a copy of the current slice of servers (thread-safe).
 func (m *Manager) GetAllServers() []*Server {
 	m.mu.RLock()
@@ -27,11 +38,23 @@ func (m *Manager) GetAllServers() []*Server {
 // UpdateServers updates the entire server list (thread-safe).
 func (m *Manager) UpdateServers(updated []*Server) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	newServers := make([]*Server, len(updated))
 	copy(newServers, updated)
+
+	kept := make(map[string]bool, len(newServers))
+	for _, srv := range newServers {
+		kept[srv.ID] = true
+	}
+	var removed []*Server
+	for _, srv := range m.servers {
+		if !kept[srv.ID] {
+			removed = append(removed, srv)
+		}
+	}
 	m.servers = newServers
+	m.mu.Unlock()
+
+	m.notifyRemoved(removed)
 }
 
 // AddServer dynamically adds a new server to the pool.
@@ -45,13 +68,35 @@ func (m *Manager) AddServer(s *Server) {
 // RemoveServer removes a server from the pool by ID.
 func (m *Manager) RemoveServer(serverID string) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	var newServers []*Server
+	var removed []*Server
 	for _, srv := range m.servers {
 		if srv.ID != serverID {
 			newServers = append(newServers, srv)
+		} else {
+			removed = append(removed, srv)
 		}
 	}
 	m.servers = newServers
+	m.mu.Unlock()
+
+	m.notifyRemoved(removed)
+}
+
+// notifyRemoved runs the removal callbacks outside the server lock.
+func (m *Manager) notifyRemoved(removed []*Server) {
+	if len(removed) == 0 {
+		return
+	}
+
+	m.listenersMu.Lock()
+	callbacks := make([]func(*Server), len(m.onRemove))
+	copy(callbacks, m.onRemove)
+	m.listenersMu.Unlock()
+
+	for _, srv := range removed {
+		for _, fn := range callbacks {
+			fn(srv)
+		}
+	}
 }
@@ -38,9 +38,11 @@ func SimulateErrorRate() float64 {
 // FetchMetrics updates all metrics for a given server.
 func FetchMetrics(srv *Server) {
 	// Simulate fetching metrics and updating the server object
-	srv.CPUUsage = NormalizeCPUUsage(50 + rand.Float64()*50)    // Simulated CPU usage: 50% - 100%
-	srv.MemUsage = NormalizeMemoryUsage(30 + rand.Float64()*70) // Simulated memory usage: 30% - 100%
-	srv.ResponseTime = SimulateResponseTime()                   // Random response time
-	srv.PingStatus = SimulatePingStatus() == 1                  // Random ping status
-	srv.ErrorRate = SimulateErrorRate()                         // Random error rate
+	Update(srv, func(s *Server) {
+		s.CPUUsage = NormalizeCPUUsage(50 + rand.Float64()*50)    // Simulated CPU usage: 50% - 100%
+		s.MemUsage = NormalizeMemoryUsage(30 + rand.Float64()*70) // Simulated memory usage: 30% - 100%
+		s.ResponseTime = SimulateResponseTime()                   // Random response time
+		s.PingStatus = SimulatePingStatus() == 1                  // Random ping status
+		s.ErrorRate = SimulateErrorRate()                         // Random error rate
+	})
 }
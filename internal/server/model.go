@@ -1,7 +1,10 @@
 // internal/server/model.go
 package server
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 // CBState represents the circuit breaker state for a server.
 type CBState int
@@ -12,8 +15,11 @@ const (
 	CBStateHalfOpen
 )
 
-// Server represents a backend server in the pool.
+// Server represents a backend server in the pool. Its health, weight,
+// breaker, slow start and drain fields are guarded by a lock; see Update.
 type Server struct {
+	mu sync.RWMutex
+
 	ID      string
 	Address string
 	Port    int
@@ -35,6 +41,13 @@ type Server struct {
 	TrialSuccessCount   int
 	OpenSince           time.Time
 
+	// Slow start: set when the server rejoins rotation so its share ramps up
+	SlowStartSince time.Time
+
 	// Concurrency tracking
 	ActiveRequests int64
+
+	// Operator rotation state (active / draining / maintenance)
+	AdminState   AdminState
+	DrainStarted time.Time
 }
@@ -0,0 +1,10 @@
+// internal/server/slow_start.go
+package server
+
+import "time"
+
+// BeginSlowStart marks the server as warming up from now on. Balancers that
+// honour slow start ramp its share of traffic up over their configured window.
+func BeginSlowStart(srv *Server) {
+	Update(srv, func(s *Server) { s.SlowStartSince = time.Now() })
+}
@@ -0,0 +1,103 @@
+// internal/server/state.go
+package server
+
+import "time"
+
+// Breaker is a consistent copy of a server's circuit breaker fields.
+type Breaker struct {
+	State     CBState
+	Failures  int
+	Trials    int
+	OpenSince time.Time
+}
+
+// Update runs fn holding the server's state lock. Changes to its health,
+// weight, breaker, slow start and drain fields must go through it, as the
+// health checker, breaker monitor and request dispatch share them.
+func Update(srv *Server, fn func(s *Server)) {
+	if srv == nil {
+		return
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	fn(srv)
+}
+
+// View runs fn holding the server's state lock for reading.
+func View(srv *Server, fn func(s *Server)) {
+	if srv == nil {
+		return
+	}
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+	fn(srv)
+}
+
+// Snapshot returns a copy of the server taken under its state lock, safe to
+// read or encode while the original keeps changing.
+func Snapshot(srv *Server) *Server {
+	if srv == nil {
+		return nil
+	}
+	var cp *Server
+	View(srv, func(s *Server) {
+		cp = &Server{
+			ID:                  s.ID,
+			Address:             s.Address,
+			Port:                s.Port,
+			CPUUsage:            s.CPUUsage,
+			MemUsage:            s.MemUsage,
+			ResponseTime:        s.ResponseTime,
+			ErrorRate:           s.ErrorRate,
+			PingStatus:          s.PingStatus,
+			HealthScore:         s.HealthScore,
+			CurrentWeight:       s.CurrentWeight,
+			CircuitBreakerState: s.CircuitBreakerState,
+			FailureCount:        s.FailureCount,
+			TrialSuccessCount:   s.TrialSuccessCount,
+			OpenSince:           s.OpenSince,
+			SlowStartSince:      s.SlowStartSince,
+			DrainStarted:        s.DrainStarted,
+		}
+	})
+	cp.ActiveRequests = GetActiveRequests(srv)
+	cp.AdminState = GetAdminState(srv)
+	return cp
+}
+
+// IsUp reports whether the server passes its health checks.
+func IsUp(srv *Server) bool {
+	up := false
+	View(srv, func(s *Server) { up = s.PingStatus })
+	return up
+}
+
+// GetWeight returns the weight the health checker last gave the server.
+func GetWeight(srv *Server) float64 {
+	var weight float64
+	View(srv, func(s *Server) { weight = s.CurrentWeight })
+	return weight
+}
+
+// GetSlowStartSince returns when the server last began warming up.
+func GetSlowStartSince(srv *Server) time.Time {
+	var since time.Time
+	View(srv, func(s *Server) { since = s.SlowStartSince })
+	return since
+}
+
+// GetBreaker returns the server's circuit breaker fields.
+func GetBreaker(srv *Server) Breaker {
+	var b Breaker
+	View(srv, func(s *Server) {
+		b = Breaker{State: s.CircuitBreakerState, Failures: s.FailureCount, Trials: s.TrialSuccessCount, OpenSince: s.OpenSince}
+	})
+	return b
+}
+
+// GetBreakerState returns the server's circuit breaker state.
+func GetBreakerState(srv *Server) CBState {
+	state := CBStateClosed
+	View(srv, func(s *Server) { state = s.CircuitBreakerState })
+	return state
+}
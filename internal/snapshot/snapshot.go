@@ -0,0 +1,187 @@
+// internal/snapshot/snapshot.go
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"load-balancer/internal/events"
+	"load-balancer/internal/handoff"
+	"load-balancer/internal/metrics"
+	"load-balancer/internal/router"
+)
+
+// Version is the snapshot format written by this build. Snapshots with a
+// different version are rejected rather than half-applied.
+const Version = 1
+
+// Snapshot is the runtime state persisted across restarts.
+type Snapshot struct {
+	Version int              `json:"version"`
+	Taken   time.Time        `json:"taken"`
+	State   *handoff.State   `json:"state"` // sticky sessions and per-server breaker/admin state
+	Metrics metrics.Counters `json:"metrics"`
+	Events  []events.Event   `json:"events"`
+}
+
+// Result summarises what a restore applied and what it had to skip.
+type Result struct {
+	Sessions       int      `json:"sessions"`
+	Servers        int      `json:"servers"`
+	Events         int      `json:"events"`
+	SkippedPools   []string `json:"skippedPools,omitempty"`
+	SkippedServers []string `json:"skippedServers,omitempty"`
+}
+
+// Manager captures, restores and persists snapshots.
+type Manager struct {
+	Router  *router.Table
+	Metrics *metrics.MetricsManager
+	Events  *events.EventSystem
+	Path    string // file snapshots are saved to; empty disables Save and Load
+}
+
+// NewManager creates a snapshot manager writing to path.
+func NewManager(table *router.Table, mm *metrics.MetricsManager, es *events.EventSystem, path string) *Manager {
+	return &Manager{Router: table, Metrics: mm, Events: es, Path: path}
+}
+
+// Capture takes a snapshot of the current runtime state.
+func (m *Manager) Capture() *Snapshot {
+	return &Snapshot{
+		Version: Version,
+		Taken:   time.Now(),
+		State:   handoff.Capture(m.Router),
+		Metrics: m.Metrics.ExportCounters(),
+		Events:  m.Events.GetRecentEvents(0),
+	}
+}
+
+// Restore applies a snapshot, reconciling it against the current server
+// list: pools and servers that no longer exist are skipped, as are per-server
+// request counts and sticky sessions that point at them.
+func (m *Manager) Restore(snap *Snapshot) (Result, error) {
+	var result Result
+	if snap == nil {
+		return result, fmt.Errorf("empty snapshot")
+	}
+	if snap.Version != Version {
+		return result, fmt.Errorf("unsupported snapshot version %d (want %d)", snap.Version, Version)
+	}
+
+	if snap.State != nil {
+		for name, ps := range snap.State.Pools {
+			pool := m.Router.Pool(name)
+			if pool == nil {
+				result.SkippedPools = append(result.SkippedPools, name)
+				continue
+			}
+			for id := range ps.Servers {
+				if m.Router.FindServer(id) != pool {
+					result.SkippedServers = append(result.SkippedServers, id)
+				}
+			}
+		}
+		result.Sessions, result.Servers = handoff.Restore(m.Router, snap.State)
+	}
+
+	counters := snap.Metrics
+	known := make(map[string]int64, len(counters.LoadBalancer.RequestsPerServer))
+	for id, n := range counters.LoadBalancer.RequestsPerServer {
+		if m.Router.FindServer(id) != nil {
+			known[id] = n
+		}
+	}
+	counters.LoadBalancer.RequestsPerServer = known
+	m.Metrics.RestoreCounters(counters)
+
+	result.Events = m.Events.RestoreEvents(snap.Events)
+	return result, nil
+}
+
+// Save captures a snapshot and writes it to Path.
+func (m *Manager) Save() error {
+	if m.Path == "" {
+		return nil
+	}
+	return Write(m.Path, m.Capture())
+}
+
+// Load reads the snapshot at Path. It returns nil without an error when
+// there is no snapshot yet.
+func (m *Manager) Load() (*Snapshot, error) {
+	if m.Path == "" {
+		return nil, nil
+	}
+	snap, err := Read(m.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return snap, err
+}
+
+// Run saves a snapshot every interval until ctx is cancelled.
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	if m.Path == "" || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Save(); err != nil {
+				log.Printf("Unable to save snapshot: %v", err)
+			}
+		}
+	}
+}
+
+// Write stores a snapshot at path, replacing any previous file atomically so
+// a crash mid-write never leaves a truncated snapshot behind.
+func Write(path string, snap *Snapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Read loads the snapshot stored at path.
+func Read(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("decode snapshot %s: %w", path, err)
+	}
+	return &snap, nil
+}
@@ -0,0 +1,97 @@
+package snapshot
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/events"
+	"load-balancer/internal/metrics"
+	"load-balancer/internal/router"
+	"load-balancer/internal/server"
+)
+
+func newManager(t *testing.T, path string, serverIDs ...string) *Manager {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	table := router.NewTable(ctx, &config.Config{
+		HealthCheckInterval: time.Hour,
+		UseStickySessions:   true,
+		CircuitBreaker:      config.CircuitBreakerConfig{FailureThreshold: 3, CooldownPeriod: time.Second, TrialRequests: 1},
+	})
+	pool := config.PoolConfig{Name: "web"}
+	for i, id := range serverIDs {
+		pool.Servers = append(pool.Servers, config.ServerConfig{ID: id, Address: "localhost", Port: i + 1})
+	}
+	if _, err := table.AddPoolConfig(pool); err != nil {
+		t.Fatalf("add pool: %v", err)
+	}
+	return NewManager(table, metrics.NewMetricsManager(table.Pool("web").Manager), events.NewEventSystem(10), path)
+}
+
+func TestManager_SaveAndRestoreReconciles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	old := newManager(t, path, "web-1", "web-2")
+	servers := old.Router.Pool("web").Manager.GetAllServers()
+	sticky := old.Router.Pool("web").Balancer.StickySessionMgr
+	sticky.BindSessionToServer("alice", servers[0])
+	sticky.BindSessionToServer("bob", servers[1])
+	old.Router.Pool("web").Breaker.ForceOpen(servers[0], time.Now())
+	old.Metrics.RecordRequest("web-1", 10, false)
+	old.Metrics.RecordRequest("web-2", 20, true)
+	old.Metrics.GeneratePacketID()
+	old.Events.Publish(events.WarningEvent, "before restart")
+	if err := old.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	// web-2 was removed from the configuration before the restart
+	fresh := newManager(t, path, "web-1", "web-3")
+	fresh.Events.Publish(events.InfoEvent, "starting up")
+	snap, err := fresh.Load()
+	if err != nil || snap == nil {
+		t.Fatalf("load: %v (snapshot %v)", err, snap)
+	}
+	result, err := fresh.Restore(snap)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	if result.Sessions != 1 || result.Servers != 1 || len(result.SkippedServers) != 1 || result.SkippedServers[0] != "web-2" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if got := server.GetBreakerState(fresh.Router.Pool("web").Manager.GetAllServers()[0]); got != server.CBStateOpen {
+		t.Errorf("web-1 breaker restored as %v, want open", got)
+	}
+
+	counters := fresh.Metrics.ExportCounters()
+	if counters.LoadBalancer.TotalRequests != 2 {
+		t.Errorf("total requests %d, want 2", counters.LoadBalancer.TotalRequests)
+	}
+	if _, ok := counters.LoadBalancer.RequestsPerServer["web-2"]; ok {
+		t.Errorf("counts for removed server web-2 were restored")
+	}
+	if id := fresh.Metrics.GeneratePacketID(); id != "pkt-2" {
+		t.Errorf("next packet ID %s, want pkt-2", id)
+	}
+
+	history := fresh.Events.GetRecentEvents(0)
+	if len(history) != 2 || history[0].Message != "before restart" || history[1].Message != "starting up" {
+		t.Errorf("unexpected event history %+v", history)
+	}
+}
+
+func TestManager_RejectsOtherVersions(t *testing.T) {
+	m := newManager(t, "", "web-1")
+	if _, err := m.Restore(&Snapshot{Version: Version + 1}); err == nil {
+		t.Fatal("expected an error for an unknown snapshot version")
+	}
+	if snap, err := m.Load(); snap != nil || err != nil {
+		t.Fatalf("load without a path returned %v, %v", snap, err)
+	}
+}
@@ -0,0 +1,329 @@
+// internal/testserver/faults.go
+package testserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"load-balancer/internal/config"
+)
+
+// FaultType selects how an injected fault misbehaves.
+type FaultType string
+
+const (
+	FaultLatency    FaultType = "latency"     // adds Latency before responding
+	FaultError      FaultType = "error"       // responds with StatusCode
+	FaultHang       FaultType = "hang"        // never responds while the fault lasts
+	FaultDrip       FaultType = "drip"        // trickles the body out over Latency
+	FaultReset      FaultType = "reset"       // drops the connection with a TCP reset
+	FaultFlapHealth FaultType = "flap-health" // /health alternates failing and passing every Period
+)
+
+// Fault is runtime misbehaviour injected into a test server. A fault is
+// active from Start (now plus Delay if unset) until End (Start plus Duration
+// if unset, or until removed).
+type Fault struct {
+	ID         string          `json:"id"`
+	Type       FaultType       `json:"type"`
+	Latency    config.Duration `json:"latency,omitempty"`
+	StatusCode int             `json:"statusCode,omitempty"` // error faults; default 500
+	Rate       float64         `json:"rate,omitempty"`       // fraction of requests affected; default all
+	Period     config.Duration `json:"period,omitempty"`     // flap-health half cycle; default 1s
+	Delay      config.Duration `json:"delay,omitempty"`
+	Duration   config.Duration `json:"duration,omitempty"`
+	Start      time.Time       `json:"start"`
+	End        time.Time       `json:"end,omitempty"`
+}
+
+// Active reports whether the fault applies at t.
+func (f Fault) Active(t time.Time) bool {
+	return !t.Before(f.Start) && (f.End.IsZero() || t.Before(f.End))
+}
+
+// normalize fills defaults and resolves the schedule relative to now.
+func (f Fault) normalize(now time.Time) (Fault, error) {
+	switch f.Type {
+	case FaultLatency, FaultDrip:
+		if f.Latency.Duration <= 0 {
+			return f, fmt.Errorf("%s fault needs a positive latency", f.Type)
+		}
+	case FaultError:
+		if f.StatusCode == 0 {
+			f.StatusCode = http.StatusInternalServerError
+		}
+		if f.StatusCode < 400 || f.StatusCode > 599 {
+			return f, fmt.Errorf("error fault status must be 4xx or 5xx")
+		}
+	case FaultFlapHealth:
+		if f.Period.Duration <= 0 {
+			f.Period.Duration = time.Second
+		}
+	case FaultHang, FaultReset:
+	default:
+		return f, fmt.Errorf("unknown fault type %q", f.Type)
+	}
+	if f.Rate < 0 || f.Rate > 1 {
+		return f, fmt.Errorf("rate must be between 0 and 1")
+	}
+	if f.Rate == 0 {
+		f.Rate = 1
+	}
+	if f.Start.IsZero() {
+		f.Start = now.Add(f.Delay.Duration)
+	}
+	if f.End.IsZero() && f.Duration.Duration > 0 {
+		f.End = f.Start.Add(f.Duration.Duration)
+	}
+	if !f.End.IsZero() && !f.End.After(f.Start) {
+		return f, fmt.Errorf("fault ends before it starts")
+	}
+	return f, nil
+}
+
+// faultSet holds a server's injected faults.
+type faultSet struct {
+	mu     sync.Mutex
+	seq    int
+	faults []Fault
+}
+
+func (fs *faultSet) add(f Fault) (Fault, error) {
+	now := time.Now()
+	f, err := f.normalize(now)
+	if err != nil {
+		return Fault{}, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.prune(now)
+	fs.seq++
+	f.ID = fmt.Sprintf("fault-%d", fs.seq)
+	fs.faults = append(fs.faults, f)
+	return f, nil
+}
+
+func (fs *faultSet) remove(id string) bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for i, f := range fs.faults {
+		if f.ID == id {
+			fs.faults = append(fs.faults[:i], fs.faults[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (fs *faultSet) clear() int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	n := len(fs.faults)
+	fs.faults = nil
+	return n
+}
+
+// list returns the active and scheduled faults.
+func (fs *faultSet) list() []Fault {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.prune(time.Now())
+	return append([]Fault{}, fs.faults...)
+}
+
+// roll returns the faults of the given types that hit a request arriving at
+// now, each according to its rate.
+func (fs *faultSet) roll(now time.Time, types ...FaultType) []Fault {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	var hit []Fault
+	for _, f := range fs.faults {
+		if !f.Active(now) {
+			continue
+		}
+		for _, t := range types {
+			if f.Type == t && rand.Float64() < f.Rate {
+				hit = append(hit, f)
+			}
+		}
+	}
+	return hit
+}
+
+// prune drops expired faults; callers hold mu.
+func (fs *faultSet) prune(now time.Time) {
+	kept := fs.faults[:0]
+	for _, f := range fs.faults {
+		if f.End.IsZero() || now.Before(f.End) {
+			kept = append(kept, f)
+		}
+	}
+	fs.faults = kept
+}
+
+// InjectFault schedules a fault and returns it with its ID and schedule.
+func (ts *TestServer) InjectFault(f Fault) (Fault, error) {
+	return ts.faults.add(f)
+}
+
+// RemoveFault removes one fault, reporting whether it existed.
+func (ts *TestServer) RemoveFault(id string) bool {
+	return ts.faults.remove(id)
+}
+
+// ClearFaults removes every fault and returns how many there were.
+func (ts *TestServer) ClearFaults() int {
+	return ts.faults.clear()
+}
+
+// Faults returns the active and scheduled faults.
+func (ts *TestServer) Faults() []Fault {
+	return ts.faults.list()
+}
+
+// handleFaults serves /admin/faults: GET lists, POST injects, DELETE clears
+// all; DELETE /admin/faults/{id} removes one.
+func (ts *TestServer) handleFaults(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/faults"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		writeJSON(w, http.StatusOK, ts.Faults())
+	case r.Method == http.MethodPost && id == "":
+		var f Fault
+		if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		f, err := ts.InjectFault(f)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusCreated, f)
+	case r.Method == http.MethodDelete && id == "":
+		writeJSON(w, http.StatusOK, map[string]int{"cleared": ts.ClearFaults()})
+	case r.Method == http.MethodDelete:
+		if !ts.RemoveFault(id) {
+			http.Error(w, "Fault not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// applyFaults runs the faults hitting a request before the normal handler.
+// It reports whether a fault already dealt with the response.
+func (ts *TestServer) applyFaults(w http.ResponseWriter, r *http.Request) bool {
+	now := time.Now()
+
+	if len(ts.faults.roll(now, FaultReset)) > 0 {
+		resetConnection(w)
+		return true
+	}
+
+	for _, f := range ts.faults.roll(now, FaultHang) {
+		// Hold the request until the client gives up or the fault ends
+		var ends <-chan time.Time
+		if !f.End.IsZero() {
+			timer := time.NewTimer(time.Until(f.End))
+			defer timer.Stop()
+			ends = timer.C
+		}
+		select {
+		case <-r.Context().Done():
+			return true
+		case <-ends:
+		}
+	}
+
+	for _, f := range ts.faults.roll(now, FaultLatency) {
+		time.Sleep(f.Latency.Duration)
+	}
+
+	if errs := ts.faults.roll(now, FaultError); len(errs) > 0 {
+		http.Error(w, "Injected fault", errs[0].StatusCode)
+		return true
+	}
+	return false
+}
+
+// dripLatency is the total time to trickle the body over, or 0.
+func (ts *TestServer) dripLatency() time.Duration {
+	var total time.Duration
+	for _, f := range ts.faults.roll(time.Now(), FaultDrip) {
+		total += f.Latency.Duration
+	}
+	return total
+}
+
+// healthFlapping reports whether a flap-health fault fails /health right now.
+func (ts *TestServer) healthFlapping() bool {
+	now := time.Now()
+	for _, f := range ts.faults.roll(now, FaultFlapHealth) {
+		if (now.Sub(f.Start)/f.Period.Duration)%2 == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// resetConnection aborts the connection so the client sees a reset rather
+// than a clean close.
+func resetConnection(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetLinger(0)
+	}
+	conn.Close()
+}
+
+// writeDrip writes body in small chunks spread over total.
+func writeDrip(w http.ResponseWriter, body []byte, total time.Duration) {
+	const chunks = 10
+	flusher, _ := w.(http.Flusher)
+	size := (len(body) + chunks - 1) / chunks
+	for len(body) > 0 {
+		n := size
+		if n > len(body) {
+			n = len(body)
+		}
+		if _, err := w.Write(body[:n]); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		body = body[n:]
+		time.Sleep(total / chunks)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,137 @@
+package testserver
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"load-balancer/internal/config"
+)
+
+func startTestServer(t *testing.T) (*TestServer, string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	cfg := ServerConfig{ID: "server-1"}
+	cfg.Latency.Min, cfg.Latency.Max = 1, 2
+	ts := NewTestServer(cfg)
+	go ts.Serve(l)
+	t.Cleanup(func() { ts.Stop() })
+	return ts, "http://" + l.Addr().String()
+}
+
+func get(t *testing.T, client *http.Client, url string) (int, error) {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, err = io.ReadAll(resp.Body)
+	return resp.StatusCode, err
+}
+
+func TestFaults_ErrorBurstFollowsItsSchedule(t *testing.T) {
+	_, base := startTestServer(t)
+
+	resp, err := http.Post(base+"/admin/faults", "application/json",
+		strings.NewReader(`{"type":"error","statusCode":503,"delay":"100ms","duration":"200ms"}`))
+	if err != nil {
+		t.Fatalf("inject: %v", err)
+	}
+	var fault Fault
+	json.NewDecoder(resp.Body).Decode(&fault)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || fault.ID == "" || fault.End.Sub(fault.Start) != 200*time.Millisecond {
+		t.Fatalf("inject = %d %+v", resp.StatusCode, fault)
+	}
+
+	if code, _ := get(t, http.DefaultClient, base+"/"); code != http.StatusOK {
+		t.Errorf("before start = %d, want 200", code)
+	}
+	time.Sleep(time.Until(fault.Start) + 10*time.Millisecond)
+	if code, _ := get(t, http.DefaultClient, base+"/"); code != http.StatusServiceUnavailable {
+		t.Errorf("during burst = %d, want 503", code)
+	}
+	time.Sleep(time.Until(fault.End) + 10*time.Millisecond)
+	if code, _ := get(t, http.DefaultClient, base+"/"); code != http.StatusOK {
+		t.Errorf("after end = %d, want 200", code)
+	}
+
+	var remaining []Fault
+	resp, err = http.Get(base + "/admin/faults")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	json.NewDecoder(resp.Body).Decode(&remaining)
+	resp.Body.Close()
+	if len(remaining) != 0 {
+		t.Errorf("expired faults still listed: %+v", remaining)
+	}
+}
+
+func TestFaults_ConnectionLevelFaults(t *testing.T) {
+	ts, base := startTestServer(t)
+	client := &http.Client{Timeout: 300 * time.Millisecond}
+
+	if _, err := ts.InjectFault(Fault{Type: FaultReset}); err != nil {
+		t.Fatalf("inject reset: %v", err)
+	}
+	if _, err := get(t, client, base+"/"); err == nil {
+		t.Errorf("request survived a connection reset")
+	}
+	ts.ClearFaults()
+
+	if _, err := ts.InjectFault(Fault{Type: FaultHang}); err != nil {
+		t.Fatalf("inject hang: %v", err)
+	}
+	if _, err := get(t, client, base+"/"); err == nil {
+		t.Errorf("hung request got a response")
+	}
+	ts.ClearFaults()
+
+	f, err := ts.InjectFault(Fault{Type: FaultDrip, Latency: durationOf(200 * time.Millisecond)})
+	if err != nil {
+		t.Fatalf("inject drip: %v", err)
+	}
+	start := time.Now()
+	if code, err := get(t, &http.Client{}, base+"/"); err != nil || code != http.StatusOK {
+		t.Errorf("drip = %d, %v", code, err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("dripped body arrived in %v", elapsed)
+	}
+	if !ts.RemoveFault(f.ID) {
+		t.Errorf("drip fault not removed")
+	}
+}
+
+func TestFaults_FlappingHealth(t *testing.T) {
+	ts, base := startTestServer(t)
+
+	f, err := ts.InjectFault(Fault{Type: FaultFlapHealth, Period: durationOf(100 * time.Millisecond)})
+	if err != nil {
+		t.Fatalf("inject: %v", err)
+	}
+	if code, _ := get(t, http.DefaultClient, base+"/health"); code != http.StatusServiceUnavailable {
+		t.Errorf("first half cycle = %d, want 503", code)
+	}
+	time.Sleep(time.Until(f.Start.Add(120 * time.Millisecond)))
+	if code, _ := get(t, http.DefaultClient, base+"/health"); code != http.StatusOK {
+		t.Errorf("second half cycle = %d, want 200", code)
+	}
+
+	if _, err := ts.InjectFault(Fault{Type: "meltdown"}); err == nil {
+		t.Errorf("unknown fault type accepted")
+	}
+}
+
+func durationOf(d time.Duration) config.Duration {
+	return config.Duration{Duration: d}
+}
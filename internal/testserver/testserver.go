@@ -2,10 +2,12 @@
 package testserver
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"math/rand"
+	"net"
 	"net/http"
 	"sync"
 	"time"
@@ -36,6 +38,9 @@ type TestServer struct {
 	Stats      RequestStats
 	statsMutex sync.Mutex
 	server     *http.Server
+	listener   net.Listener
+	ready      chan struct{}
+	faults     faultSet
 }
 
 // NewTestServer creates a new test server
@@ -49,6 +54,7 @@ func NewTestServer(config ServerConfig) *TestServer {
 
 	return &TestServer{
 		Config: config,
+		ready:  make(chan struct{}),
 		Stats: RequestStats{
 			LastRequest: time.Now(),
 		},
@@ -57,6 +63,17 @@ func NewTestServer(config ServerConfig) *TestServer {
 
 // Start begins the test server
 func (ts *TestServer) Start() error {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", ts.Config.Port))
+	if err != nil {
+		close(ts.ready)
+		return err
+	}
+	return ts.Serve(l)
+}
+
+// Serve runs the test server on an existing listener, e.g. one inherited
+// from a previous balancer process.
+func (ts *TestServer) Serve(l net.Listener) error {
 	mux := http.NewServeMux()
 
 	// Basic endpoint for handling requests
@@ -68,14 +85,27 @@ func (ts *TestServer) Start() error {
 	// Stats endpoint
 	mux.HandleFunc("/stats", ts.handleStats)
 
+	// Runtime fault injection
+	mux.HandleFunc("/admin/faults", ts.handleFaults)
+	mux.HandleFunc("/admin/faults/", ts.handleFaults)
+
 	ts.server = &http.Server{
 		Addr:    fmt.Sprintf(":%d", ts.Config.Port),
 		Handler: mux,
 	}
+	ts.listener = l
+	close(ts.ready)
 
 	log.Printf("Starting test server %s on port %d", ts.Config.ID, ts.Config.Port)
 
-	return ts.server.ListenAndServe()
+	return ts.server.Serve(l)
+}
+
+// Listener returns the server's listener once it is serving, or nil if it
+// failed to start.
+func (ts *TestServer) Listener() net.Listener {
+	<-ts.ready
+	return ts.listener
 }
 
 // Stop shuts down the server
@@ -86,6 +116,19 @@ func (ts *TestServer) Stop() error {
 	return nil
 }
 
+// Shutdown stops the server gracefully, letting in-flight requests finish
+// until ctx expires, then closes any remaining connections.
+func (ts *TestServer) Shutdown(ctx context.Context) error {
+	if ts.server == nil {
+		return nil
+	}
+	if err := ts.server.Shutdown(ctx); err != nil {
+		ts.server.Close()
+		return err
+	}
+	return nil
+}
+
 // handleRequest is the main handler for all incoming requests
 func (ts *TestServer) handleRequest(w http.ResponseWriter, r *http.Request) {
 	ts.statsMutex.Lock()
@@ -93,6 +136,14 @@ func (ts *TestServer) handleRequest(w http.ResponseWriter, r *http.Request) {
 	ts.Stats.LastRequest = time.Now()
 	ts.statsMutex.Unlock()
 
+	// Injected faults take precedence over the configured behaviour
+	if ts.applyFaults(w, r) {
+		ts.statsMutex.Lock()
+		ts.Stats.Failures++
+		ts.statsMutex.Unlock()
+		return
+	}
+
 	// Simulate random latency
 	latency := ts.Config.Latency.Min + rand.Intn(ts.Config.Latency.Max-ts.Config.Latency.Min)
 	time.Sleep(time.Duration(latency) * time.Millisecond)
@@ -120,6 +171,11 @@ func (ts *TestServer) handleRequest(w http.ResponseWriter, r *http.Request) {
 		"path":    r.URL.Path,
 	}
 
+	if drip := ts.dripLatency(); drip > 0 {
+		body, _ := json.Marshal(response)
+		writeDrip(w, body, drip)
+		return
+	}
 	json.NewEncoder(w).Encode(response)
 }
 
@@ -127,7 +183,16 @@ func (ts *TestServer) handleRequest(w http.ResponseWriter, r *http.Request) {
 func (ts *TestServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Always return status OK for the health check
+	if ts.healthFlapping() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{
+			"status": "failing",
+			"server": ts.Config.ID,
+		})
+		return
+	}
+
+	// Healthy unless a flap-health fault says otherwise
 	response := map[string]string{
 		"status": "ok",
 		"server": ts.Config.ID,
@@ -148,14 +213,30 @@ func (ts *TestServer) handleStats(w http.ResponseWriter, r *http.Request) {
 
 // StartTestServers starts multiple test servers with the given configurations
 func StartTestServers(configs []ServerConfig) []*TestServer {
+	return StartTestServersWithListeners(configs, nil)
+}
+
+// StartTestServersWithListeners starts test servers, serving on the listener
+// returned by inherited for a server ID when there is one.
+func StartTestServersWithListeners(configs []ServerConfig, inherited func(id string) net.Listener) []*TestServer {
 	var servers []*TestServer
 
 	for _, config := range configs {
 		server := NewTestServer(config)
+		var l net.Listener
+		if inherited != nil {
+			l = inherited(config.ID)
+		}
 
 		// Start the server in a goroutine
 		go func() {
-			if err := server.Start(); err != nil && err != http.ErrServerClosed {
+			var err error
+			if l != nil {
+				err = server.Serve(l)
+			} else {
+				err = server.Start()
+			}
+			if err != nil && err != http.ErrServerClosed {
 				log.Printf("Server %s error: %v", server.Config.ID, err)
 			}
 		}()
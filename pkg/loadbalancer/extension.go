@@ -0,0 +1,165 @@
+// pkg/loadbalancer/extension.go
+package loadbalancer
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"load-balancer/internal/dispatch"
+	"load-balancer/internal/proxy"
+	"load-balancer/internal/server"
+)
+
+// EventType classifies an Event.
+type EventType string
+
+const (
+	InfoEvent    EventType = "info"
+	SuccessEvent EventType = "success"
+	WarningEvent EventType = "warning"
+	ErrorEvent   EventType = "error"
+	// PacketEvent carries one request attempt as JSON in Data, in the
+	// format of the admin API's /api/packets.
+	PacketEvent EventType = "packet"
+	// BreakerEvent reports a backend's circuit breaker opening or being reset.
+	BreakerEvent EventType = "breaker"
+)
+
+// Severity ranks an Event: debug, info, warning or error.
+type Severity string
+
+const (
+	SeverityDebug   Severity = "debug"
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Event is something the balancer did: a request served or failed, a
+// breaker tripping, a backend added, and so on. IDs increase with every
+// event. BackendID and RequestID are set when the event concerns one backend
+// or request.
+type Event struct {
+	ID        uint64          `json:"id"`
+	Type      EventType       `json:"type"`
+	Severity  Severity        `json:"severity"`
+	Source    string          `json:"source,omitempty"`
+	BackendID string          `json:"serverId,omitempty"`
+	RequestID string          `json:"requestId,omitempty"`
+	Message   string          `json:"message"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// EventSink receives events on a goroutine of its own, in publish order.
+// A sink that falls behind by more than a few events misses some.
+type EventSink interface {
+	HandleEvent(Event)
+}
+
+// EventSinkFunc adapts a function to an EventSink.
+type EventSinkFunc func(Event)
+
+// HandleEvent calls f(e).
+func (f EventSinkFunc) HandleEvent(e Event) { f(e) }
+
+// Request is a request being proxied. PrePick hooks may replace or adjust
+// HTTP before the first backend is picked.
+type Request struct {
+	HTTP      *http.Request
+	ID        string // packet ID, shared by every attempt
+	Priority  string // X-Task-Priority
+	ClientIP  string
+	Synthetic bool // an admin API /api/test probe
+	Attempt   int  // 1-based; 0 before the first pick
+}
+
+// Response is a backend's buffered answer, about to be returned.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Filter hooks into request dispatch. A filter implements any of PrePicker,
+// PostPicker, ResponseHook and ErrorHook; hooks run in the order filters
+// were added.
+type Filter interface {
+	Name() string
+}
+
+// PrePicker runs once per request before a backend is picked. An error made
+// by Reject answers the client with its status; any other error gives 500.
+type PrePicker interface {
+	PrePick(req *Request) error
+}
+
+// PostPicker runs after each pick. An error skips the backend and the
+// request is retried on another.
+type PostPicker interface {
+	PostPick(req *Request, backend Backend) error
+}
+
+// ResponseHook may rewrite the response before it goes to the client.
+type ResponseHook interface {
+	OnResponse(req *Request, backend Backend, res *Response)
+}
+
+// ErrorHook sees every failed attempt: transport errors and 5xx answers.
+type ErrorHook interface {
+	OnError(req *Request, backend Backend, err error)
+}
+
+// Reject returns an error that makes a PrePicker answer with status.
+func Reject(status int, message string) error {
+	return dispatch.Reject(status, message)
+}
+
+// adaptFilter turns a public filter into a dispatch filter.
+func adaptFilter(f Filter) dispatch.Filter {
+	df := dispatch.Filter{Name: f.Name()}
+	if h, ok := f.(PrePicker); ok {
+		df.PrePick = func(req *dispatch.Request) error {
+			pub := publicRequest(req)
+			err := h.PrePick(pub)
+			if pub.HTTP != nil {
+				req.HTTP = pub.HTTP
+			}
+			return err
+		}
+	}
+	if h, ok := f.(PostPicker); ok {
+		df.PostPick = func(req *dispatch.Request, srv *server.Server) error {
+			return h.PostPick(publicRequest(req), publicBackend(srv))
+		}
+	}
+	if h, ok := f.(ResponseHook); ok {
+		df.OnResponse = func(req *dispatch.Request, srv *server.Server, res *proxy.Result) {
+			pub := &Response{StatusCode: res.StatusCode, Header: res.Header, Body: res.Body}
+			h.OnResponse(publicRequest(req), publicBackend(srv), pub)
+			res.StatusCode, res.Header, res.Body = pub.StatusCode, pub.Header, pub.Body
+		}
+	}
+	if h, ok := f.(ErrorHook); ok {
+		df.OnError = func(req *dispatch.Request, srv *server.Server, err error) {
+			h.OnError(publicRequest(req), publicBackend(srv), err)
+		}
+	}
+	return df
+}
+
+func publicRequest(req *dispatch.Request) *Request {
+	return &Request{
+		HTTP:      req.HTTP,
+		ID:        req.ID,
+		Priority:  req.Priority,
+		ClientIP:  req.ClientIP,
+		Synthetic: req.Synthetic,
+		Attempt:   req.Attempt,
+	}
+}
+
+func publicBackend(srv *server.Server) Backend {
+	return Backend{ID: srv.ID, Address: srv.Address, Port: srv.Port}
+}
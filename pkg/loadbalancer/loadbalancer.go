@@ -0,0 +1,226 @@
+// pkg/loadbalancer/loadbalancer.go
+
+// Package loadbalancer embeds the balancer in another Go program. A
+// LoadBalancer is an http.Handler that proxies every request it is given to
+// one pool of backends, with the same health checks, circuit breakers,
+// retries, sticky sessions and metrics as the standalone binary. Its admin
+// handler serves the JSON API, /healthz, /readyz and the dashboard; mount
+// both wherever suits the host server:
+//
+//	lb, err := loadbalancer.New(
+//		loadbalancer.WithBackends(
+//			loadbalancer.Backend{ID: "a", Address: "10.0.0.1", Port: 8080},
+//			loadbalancer.Backend{ID: "b", Address: "10.0.0.2", Port: 8080},
+//		),
+//		loadbalancer.WithStrategy(loadbalancer.LeastConnections),
+//		loadbalancer.WithHealthCheck("/health", 5*time.Second),
+//	)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer lb.Close()
+//
+//	mux := http.NewServeMux()
+//	mux.Handle("/", lb)
+//	mux.Handle("/lb-admin/", http.StripPrefix("/lb-admin", lb.AdminHandler()))
+package loadbalancer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"load-balancer/internal/app"
+	"load-balancer/internal/config"
+	"load-balancer/internal/events"
+	"load-balancer/internal/server"
+)
+
+// LoadBalancer proxies requests to a pool of backends.
+type LoadBalancer struct {
+	app *app.App
+
+	sinks     []events.Subscriber
+	sinksDone sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// New builds a load balancer from opts. At least one backend is required.
+// Background health checks and breaker monitors run until Close.
+func New(opts ...Option) (*LoadBalancer, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	cfg, err := o.config()
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := app.New(cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mw := range o.middleware {
+		a.Dispatcher.Use(mw)
+	}
+	for _, f := range o.filters {
+		a.Dispatcher.AddFilter(adaptFilter(f))
+	}
+
+	lb := &LoadBalancer{app: a}
+	for _, sink := range o.sinks {
+		lb.startSink(sink)
+	}
+	a.Lifecycle.MarkReady()
+	return lb, nil
+}
+
+// config translates options into a balancer config.
+func (o *options) config() (*config.Config, error) {
+	if len(o.backends) == 0 {
+		return nil, fmt.Errorf("at least one backend is required")
+	}
+	switch o.strategy {
+	case RoundRobin, LeastConnections, IPHash:
+	default:
+		return nil, fmt.Errorf("unknown strategy %q", o.strategy)
+	}
+	if o.stripPrefix != "" && (!strings.HasPrefix(o.stripPrefix, "/") || strings.HasSuffix(o.stripPrefix, "/")) {
+		return nil, fmt.Errorf("strip prefix %q must start and not end with /", o.stripPrefix)
+	}
+
+	seen := make(map[string]bool, len(o.backends))
+	servers := make([]config.ServerConfig, 0, len(o.backends))
+	for _, b := range o.backends {
+		if b.Address == "" || b.Port <= 0 || b.Port > 65535 {
+			return nil, fmt.Errorf("backend %q needs an address and a valid port", b.ID)
+		}
+		id := b.ID
+		if id == "" {
+			id = fmt.Sprintf("%s:%d", b.Address, b.Port)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("duplicate backend ID %s", id)
+		}
+		seen[id] = true
+		servers = append(servers, config.ServerConfig{ID: id, Address: b.Address, Port: b.Port})
+	}
+
+	probePath := o.probePath
+	if probePath == "" {
+		probePath = o.stripPrefix + "/probe"
+	}
+
+	cfg := &config.Config{
+		Servers:             servers,
+		HealthCheckInterval: o.healthInterval,
+		UseIPHash:           o.strategy == IPHash,
+		UseStickySessions:   o.sticky,
+		CircuitBreaker: config.CircuitBreakerConfig{
+			FailureThreshold: o.failureThreshold,
+			CooldownPeriod:   o.cooldown,
+			TrialRequests:    o.trialRequests,
+		},
+		Upstream: config.UpstreamConfig{
+			MaxIdleConns:          32,
+			MaxConns:              256,
+			IdleConnTimeout:       90 * time.Second,
+			KeepAlive:             30 * time.Second,
+			DialTimeout:           5 * time.Second,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: o.requestTimeout,
+			RequestTimeout:        o.requestTimeout,
+		},
+		TrustedProxies: o.trustedProxies,
+		RateLimit: config.RateLimitConfig{
+			RequestsPerSecond: o.rateLimitRPS,
+			Burst:             o.rateLimitBurst,
+		},
+		SlowStart:          config.SlowStartConfig{Duration: o.slowStart, Curve: "linear", MinFactor: 0.1},
+		DrainTimeout:       30 * time.Second,
+		MirrorMaxInFlight:  64,
+		SyntheticProbePath: probePath,
+		Pools: []config.PoolConfig{{
+			Name:            config.DefaultPoolName,
+			Strategy:        string(o.strategy),
+			HealthCheckPath: o.healthPath,
+			Servers:         servers,
+		}},
+		Routes: []config.RouteConfig{{
+			Name:        config.DefaultPoolName,
+			PathPrefix:  o.stripPrefix + "/",
+			StripPrefix: o.stripPrefix,
+			Pool:        config.DefaultPoolName,
+		}},
+	}
+	return cfg, nil
+}
+
+// startSink feeds sink from an event subscription until Close.
+func (lb *LoadBalancer) startSink(sink EventSink) {
+	sub := lb.app.Events.Subscribe()
+	lb.sinks = append(lb.sinks, sub)
+	lb.sinksDone.Add(1)
+	go func() {
+		defer lb.sinksDone.Done()
+		for msg := range sub {
+			var e Event
+			if err := json.Unmarshal([]byte(msg), &e); err != nil {
+				continue
+			}
+			sink.HandleEvent(e)
+		}
+	}()
+}
+
+// ServeHTTP proxies r to a backend.
+func (lb *LoadBalancer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	lb.app.Proxy.ServeHTTP(w, r)
+}
+
+// AdminHandler serves the admin JSON API under /api/, /healthz, /readyz and
+// the dashboard at /. Mount it behind your own authentication: the API can
+// add, drain and remove backends.
+func (lb *LoadBalancer) AdminHandler() http.Handler {
+	return lb.app.Admin
+}
+
+// Backends lists the pool's backends, including any added through the
+// admin API.
+func (lb *LoadBalancer) Backends() []Backend {
+	var backends []Backend
+	for _, srv := range lb.app.Primary.Manager.GetAllServers() {
+		backends = append(backends, publicBackend(srv))
+	}
+	return backends
+}
+
+// Healthy reports whether the backend with the given ID is passing health
+// checks and its circuit breaker is not open.
+func (lb *LoadBalancer) Healthy(id string) bool {
+	for _, srv := range lb.app.Primary.Manager.GetAllServers() {
+		if srv.ID == id {
+			return server.IsUp(srv) && server.GetBreakerState(srv) != server.CBStateOpen
+		}
+	}
+	return false
+}
+
+// Close stops health checks and other background work, closes idle
+// upstream connections and stops the event sinks once they have drained.
+// Requests still being served may fail.
+func (lb *LoadBalancer) Close() error {
+	lb.closeOnce.Do(func() {
+		lb.app.Close()
+		for _, sub := range lb.sinks {
+			lb.app.Events.Unsubscribe(sub)
+		}
+		lb.sinksDone.Wait()
+	})
+	return nil
+}
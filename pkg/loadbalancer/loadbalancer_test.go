@@ -0,0 +1,148 @@
+package loadbalancer
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func startBackends(t *testing.T, ids ...string) []Backend {
+	t.Helper()
+	var backends []Backend
+	for _, id := range ids {
+		id := id
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Backend", id)
+			io.WriteString(w, r.URL.Path)
+		}))
+		t.Cleanup(ts.Close)
+
+		host, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+		p, _ := strconv.Atoi(port)
+		backends = append(backends, Backend{ID: id, Address: host, Port: p})
+	}
+	return backends
+}
+
+type tagFilter struct{}
+
+func (tagFilter) Name() string { return "tag" }
+
+func (tagFilter) PrePick(req *Request) error {
+	if req.HTTP.Header.Get("X-Block") != "" {
+		return Reject(http.StatusForbidden, "blocked")
+	}
+	return nil
+}
+
+func (tagFilter) OnResponse(req *Request, backend Backend, res *Response) {
+	res.Header.Set("X-Tagged", backend.ID)
+}
+
+func TestLoadBalancer_ProxiesThroughFiltersAndSinks(t *testing.T) {
+	backends := startBackends(t, "a", "b")
+
+	var mu sync.Mutex
+	packets := 0
+	lb, err := New(
+		WithBackends(backends...),
+		WithHealthCheck("/health", 20*time.Millisecond),
+		WithStripPrefix("/app"),
+		WithFilter(tagFilter{}),
+		WithEventSink(EventSinkFunc(func(e Event) {
+			if e.Type == PacketEvent {
+				mu.Lock()
+				packets++
+				mu.Unlock()
+			}
+		})),
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer lb.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !lb.Healthy("a") || !lb.Healthy("b") {
+		if time.Now().After(deadline) {
+			t.Fatal("backends never passed health checks")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	served := map[string]int{}
+	for i := 0; i < 20; i++ {
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/app/hello", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body)
+		}
+		if body := rec.Body.String(); body != "/hello" {
+			t.Errorf("backend saw path %q, want /hello", body)
+		}
+		if tag := rec.Header().Get("X-Tagged"); tag != rec.Header().Get("X-Served-By") {
+			t.Errorf("X-Tagged %q, want the serving backend", tag)
+		}
+		served[rec.Header().Get("X-Backend")]++
+	}
+	if served["a"] == 0 || served["b"] == 0 {
+		t.Errorf("traffic not spread over both backends: %v", served)
+	}
+
+	rec := httptest.NewRecorder()
+	blocked := httptest.NewRequest(http.MethodGet, "/app/hello", nil)
+	blocked.Header.Set("X-Block", "1")
+	lb.ServeHTTP(rec, blocked)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("filtered request got %d, want 403", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/other", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("request outside the prefix got %d, want 404", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	lb.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/servers", nil))
+	var servers []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &servers); err != nil {
+		t.Fatalf("decoding /api/servers: %v (%s)", err, rec.Body)
+	}
+	if len(servers) != 2 {
+		t.Errorf("/api/servers listed %d backends, want 2", len(servers))
+	}
+
+	lb.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	if packets == 0 {
+		t.Error("event sink saw no packet events")
+	}
+}
+
+func TestNew_RejectsBadOptions(t *testing.T) {
+	cases := map[string][]Option{
+		"no backends":  nil,
+		"bad strategy": {WithBackends(Backend{Address: "127.0.0.1", Port: 1}), WithStrategy("random")},
+		"bad port":     {WithBackends(Backend{Address: "127.0.0.1"})},
+		"bad prefix":   {WithBackends(Backend{Address: "127.0.0.1", Port: 1}), WithStripPrefix("app/")},
+		"duplicate IDs": {WithBackends(
+			Backend{ID: "x", Address: "127.0.0.1", Port: 1},
+			Backend{ID: "x", Address: "127.0.0.1", Port: 2})},
+	}
+	for name, opts := range cases {
+		if lb, err := New(opts...); err == nil {
+			lb.Close()
+			t.Errorf("%s: New succeeded, want an error", name)
+		}
+	}
+}
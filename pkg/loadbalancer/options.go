@@ -0,0 +1,152 @@
+// pkg/loadbalancer/options.go
+package loadbalancer
+
+import (
+	"net/http"
+	"time"
+)
+
+// Strategy is how a backend is picked for each request.
+type Strategy string
+
+const (
+	// RoundRobin spreads requests by health-weighted round robin.
+	RoundRobin Strategy = "weighted-round-robin"
+	// LeastConnections picks the backend with the fewest requests in flight.
+	LeastConnections Strategy = "least-connections"
+	// IPHash pins each client IP to a backend.
+	IPHash Strategy = "ip-hash"
+)
+
+// Backend is a server requests are proxied to.
+type Backend struct {
+	ID      string
+	Address string // host name or IP
+	Port    int
+}
+
+// Option configures a LoadBalancer.
+type Option func(*options)
+
+type options struct {
+	backends         []Backend
+	strategy         Strategy
+	sticky           bool
+	healthPath       string
+	healthInterval   time.Duration
+	failureThreshold int
+	cooldown         time.Duration
+	trialRequests    int
+	requestTimeout   time.Duration
+	rateLimitRPS     int
+	rateLimitBurst   int
+	trustedProxies   []string
+	stripPrefix      string
+	slowStart        time.Duration
+	sinks            []EventSink
+	filters          []Filter
+	middleware       []func(http.Handler) http.Handler
+	probePath        string
+}
+
+func defaultOptions() *options {
+	return &options{
+		strategy:         RoundRobin,
+		healthInterval:   5 * time.Second,
+		failureThreshold: 3,
+		cooldown:         10 * time.Second,
+		trialRequests:    1,
+		requestTimeout:   30 * time.Second,
+	}
+}
+
+// WithBackends adds backends to the pool.
+func WithBackends(backends ...Backend) Option {
+	return func(o *options) { o.backends = append(o.backends, backends...) }
+}
+
+// WithStrategy sets how backends are picked; the default is RoundRobin.
+func WithStrategy(s Strategy) Option {
+	return func(o *options) { o.strategy = s }
+}
+
+// WithStickySessions pins clients with a session_id cookie to one backend.
+func WithStickySessions(enabled bool) Option {
+	return func(o *options) { o.sticky = enabled }
+}
+
+// WithHealthCheck probes path on every backend each interval and takes a
+// backend out of rotation while it answers anything but 2xx. Without it,
+// backend health is simulated as in the demo.
+func WithHealthCheck(path string, interval time.Duration) Option {
+	return func(o *options) {
+		o.healthPath = path
+		if interval > 0 {
+			o.healthInterval = interval
+		}
+	}
+}
+
+// WithCircuitBreaker opens a backend's breaker after threshold consecutive
+// failures, keeps it open for cooldown, then lets trials requests through
+// before closing it again. The default is 3 failures, 10s and 1 trial.
+func WithCircuitBreaker(threshold int, cooldown time.Duration, trials int) Option {
+	return func(o *options) {
+		o.failureThreshold = threshold
+		o.cooldown = cooldown
+		o.trialRequests = trials
+	}
+}
+
+// WithSlowStart ramps a recovered backend up to its full share over d.
+func WithSlowStart(d time.Duration) Option {
+	return func(o *options) { o.slowStart = d }
+}
+
+// WithRequestTimeout bounds each attempt against a backend; default 30s.
+func WithRequestTimeout(d time.Duration) Option {
+	return func(o *options) { o.requestTimeout = d }
+}
+
+// WithRateLimit limits each client IP to rps requests per second with the
+// given burst; excess requests get 429.
+func WithRateLimit(rps, burst int) Option {
+	return func(o *options) {
+		o.rateLimitRPS = rps
+		o.rateLimitBurst = burst
+	}
+}
+
+// WithTrustedProxies honours X-Forwarded-For and X-Real-IP from these CIDRs
+// when resolving the client IP.
+func WithTrustedProxies(cidrs ...string) Option {
+	return func(o *options) { o.trustedProxies = append(o.trustedProxies, cidrs...) }
+}
+
+// WithStripPrefix removes prefix from the path before proxying, for a
+// balancer mounted under e.g. /app/. Requests outside the prefix get 404.
+func WithStripPrefix(prefix string) Option {
+	return func(o *options) { o.stripPrefix = prefix }
+}
+
+// WithProbePath sets the proxied path the admin API's /api/test probes
+// request; the default is /probe under the strip prefix.
+func WithProbePath(path string) Option {
+	return func(o *options) { o.probePath = path }
+}
+
+// WithEventSink delivers every event, packet events included, to sink.
+func WithEventSink(sink EventSink) Option {
+	return func(o *options) { o.sinks = append(o.sinks, sink) }
+}
+
+// WithFilter adds dispatch filters; see Filter.
+func WithFilter(filters ...Filter) Option {
+	return func(o *options) { o.filters = append(o.filters, filters...) }
+}
+
+// WithMiddleware wraps the proxy handler. The first middleware given is the
+// outermost; all of them run inside client IP resolution.
+func WithMiddleware(mw ...func(http.Handler) http.Handler) Option {
+	return func(o *options) { o.middleware = append(o.middleware, mw...) }
+}
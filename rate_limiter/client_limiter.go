@@ -0,0 +1,127 @@
+package ratelimiter
+
+import (
+	"sync"
+	"time"
+)
+
+// ClientLimiter applies a token-bucket rate limit per client key (normally the
+// resolved client IP).
+type ClientLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // tokens added per second
+	burst   float64 // bucket capacity
+	buckets map[string]*bucket
+	lastGC  time.Time
+	usage   map[string]int // allowed requests per client since the last TakeUsage
+	sharing bool           // usage is recorded; see TrackUsage
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewClientLimiter creates a limiter allowing rps requests per second per
+// client, with bursts of up to burst requests.
+func NewClientLimiter(rps, burst int) *ClientLimiter {
+	if burst < rps {
+		burst = rps
+	}
+	return &ClientLimiter{
+		rate:    float64(rps),
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+		lastGC:  time.Now(),
+		usage:   make(map[string]int),
+	}
+}
+
+// Allow reports whether a request from the given client may proceed.
+func (cl *ClientLimiter) Allow(client string) bool {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	now := time.Now()
+	cl.collectIdle(now)
+
+	b, ok := cl.buckets[client]
+	if !ok {
+		b = &bucket{tokens: cl.burst, last: now}
+		cl.buckets[client] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * cl.rate
+	if b.tokens > cl.burst {
+		b.tokens = cl.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	if cl.sharing {
+		cl.usage[client]++
+	}
+	return true
+}
+
+// TrackUsage starts recording the requests TakeUsage reports. Only a limiter
+// shared with peers needs it; otherwise nothing would drain the counts.
+func (cl *ClientLimiter) TrackUsage() {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	cl.sharing = true
+}
+
+// TakeUsage returns how many requests each client was allowed since the
+// previous call or TrackUsage, so peers sharing the limit can be told about
+// them.
+func (cl *ClientLimiter) TakeUsage() map[string]int {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	usage := cl.usage
+	cl.usage = make(map[string]int)
+	return usage
+}
+
+// Charge spends n tokens from a client's bucket for requests allowed by a
+// peer. The bucket may go negative, delaying the client's next request.
+func (cl *ClientLimiter) Charge(client string, n int) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	now := time.Now()
+	b, ok := cl.buckets[client]
+	if !ok {
+		b = &bucket{tokens: cl.burst, last: now}
+		cl.buckets[client] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * cl.rate
+	if b.tokens > cl.burst {
+		b.tokens = cl.burst
+	}
+	b.last = now
+	b.tokens -= float64(n)
+	if b.tokens < -cl.burst {
+		b.tokens = -cl.burst
+	}
+}
+
+// collectIdle drops buckets that have been full (idle) for a while so the map
+// does not grow without bound.
+func (cl *ClientLimiter) collectIdle(now time.Time) {
+	if now.Sub(cl.lastGC) < time.Minute {
+		return
+	}
+	cl.lastGC = now
+
+	refill := time.Duration(cl.burst/cl.rate*float64(time.Second)) + time.Minute
+	for client, b := range cl.buckets {
+		if now.Sub(b.last) > refill {
+			delete(cl.buckets, client)
+		}
+	}
+}
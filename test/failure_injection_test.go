@@ -3,8 +3,88 @@ package test
 
 import (
 	"testing"
+	"time"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/harness"
+	"load-balancer/internal/server"
+	"load-balancer/internal/testserver"
 )
 
-func TestFailureInjection(t *testing.T) {
-	t.Log("Failure injection test placeholder. Simulate server failures/timeouts here.")
+func durationOf(d time.Duration) config.Duration {
+	return config.Duration{Duration: d}
+}
+
+func TestFailureInjection_RetriesAroundErroringBackend(t *testing.T) {
+	h := harness.Start(t, harness.Options{})
+	for _, ts := range h.Backends {
+		h.WaitForHealth(ts.Config.ID, true, 2*time.Second)
+	}
+
+	if _, err := h.Backend("server-1").InjectFault(testserver.Fault{Type: testserver.FaultError, StatusCode: 503}); err != nil {
+		t.Fatalf("inject: %v", err)
+	}
+
+	// The 503s are retried on the other servers, so clients never see them
+	d := h.Send(30, "/lb/hello")
+	d.AssertNoErrors(t)
+	d.AssertNotServed(t, "server-1")
+	d.AssertServed(t, 1, "server-2", "server-3")
+	h.WaitForBreaker("server-1", server.CBStateOpen, time.Second)
+}
+
+func TestFailureInjection_RetriesAroundResetConnections(t *testing.T) {
+	h := harness.Start(t, harness.Options{Backends: 2})
+	for _, ts := range h.Backends {
+		h.WaitForHealth(ts.Config.ID, true, 2*time.Second)
+	}
+
+	if _, err := h.Backend("server-2").InjectFault(testserver.Fault{Type: testserver.FaultReset}); err != nil {
+		t.Fatalf("inject: %v", err)
+	}
+
+	d := h.Send(20, "/lb/hello")
+	d.AssertNoErrors(t)
+	d.AssertNotServed(t, "server-2")
+}
+
+func TestFailureInjection_BreakerRecoversAfterFaultClears(t *testing.T) {
+	h := harness.Start(t, harness.Options{Backends: 2})
+	for _, ts := range h.Backends {
+		h.WaitForHealth(ts.Config.ID, true, 2*time.Second)
+	}
+
+	fault, err := h.Backend("server-1").InjectFault(testserver.Fault{
+		Type:       testserver.FaultError,
+		StatusCode: 500,
+		Duration:   durationOf(300 * time.Millisecond),
+	})
+	if err != nil {
+		t.Fatalf("inject: %v", err)
+	}
+	h.Send(10, "/lb/hello").AssertNoErrors(t)
+	h.WaitForBreaker("server-1", server.CBStateOpen, time.Second)
+
+	// Once the fault expires and the cooldown passes, a trial request closes
+	// the breaker and server-1 takes traffic again
+	time.Sleep(time.Until(fault.End))
+	h.WaitFor("server-1 to serve again", 5*time.Second, func() bool {
+		return h.Get("/lb/hello").Server == "server-1"
+	})
+	h.WaitForBreaker("server-1", server.CBStateClosed, time.Second)
+}
+
+func TestFailureInjection_AllBackendsDown(t *testing.T) {
+	h := harness.Start(t, harness.Options{Backends: 2})
+	for _, ts := range h.Backends {
+		h.WaitForHealth(ts.Config.ID, true, 2*time.Second)
+		if _, err := ts.InjectFault(testserver.Fault{Type: testserver.FaultError, StatusCode: 502}); err != nil {
+			t.Fatalf("inject: %v", err)
+		}
+	}
+
+	resp := h.Get("/lb/hello")
+	if resp.Err != nil || resp.Status != 503 {
+		t.Errorf("all backends failing = %d, %v; want 503", resp.Status, resp.Err)
+	}
 }
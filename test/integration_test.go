@@ -2,9 +2,101 @@
 package test
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"testing"
+	"time"
+
+	"load-balancer/internal/api"
+	"load-balancer/internal/harness"
+	"load-balancer/internal/metrics"
+	"load-balancer/internal/testserver"
 )
 
-func TestIntegration(t *testing.T) {
-	t.Log("Integration test placeholder. Here, you'd spin up your LB + servers.")
+func TestIntegration_SpreadsTrafficAcrossBackends(t *testing.T) {
+	h := harness.Start(t, harness.Options{})
+	for _, ts := range h.Backends {
+		h.WaitForHealth(ts.Config.ID, true, 2*time.Second)
+	}
+
+	d := h.Send(60, "/lb/hello")
+	d.AssertNoErrors(t)
+	d.AssertServed(t, 1, "server-1", "server-2", "server-3")
+}
+
+func TestIntegration_StickySessionsPinAClient(t *testing.T) {
+	h := harness.Start(t, harness.Options{})
+	for _, ts := range h.Backends {
+		h.WaitForHealth(ts.Config.ID, true, 2*time.Second)
+	}
+
+	for i := 0; i < 3; i++ {
+		session := fmt.Sprintf("client-%d", i)
+		d := h.Send(10, "/lb/cart", harness.WithSession(session))
+		d.AssertNoErrors(t)
+		if len(d.Servers) != 1 {
+			t.Errorf("session %s spread over %v, want one server", session, d.Servers)
+		}
+	}
+}
+
+func TestIntegration_RoutesOnlyToHealthyBackends(t *testing.T) {
+	h := harness.Start(t, harness.Options{})
+	h.WaitForHealth("server-1", true, 2*time.Second)
+
+	if _, err := h.Backend("server-1").InjectFault(testserver.Fault{Type: testserver.FaultFlapHealth, Period: durationOf(time.Hour)}); err != nil {
+		t.Fatalf("inject: %v", err)
+	}
+	h.WaitForHealth("server-1", false, 2*time.Second)
+
+	d := h.Send(30, "/lb/hello")
+	d.AssertNoErrors(t)
+	d.AssertNotServed(t, "server-1")
+
+	h.Backend("server-1").ClearFaults()
+	h.WaitForHealth("server-1", true, 2*time.Second)
+	if resp := h.Get("/lb/hello"); resp.Status != http.StatusOK {
+		t.Errorf("after recovery = %d, want 200", resp.Status)
+	}
+}
+
+func TestIntegration_SyntheticProbesStayOutOfRealMetrics(t *testing.T) {
+	h := harness.Start(t, harness.Options{})
+	for _, ts := range h.Backends {
+		h.WaitForHealth(ts.Config.ID, true, 2*time.Second)
+	}
+	h.Send(5, "/lb/hello").AssertNoErrors(t)
+
+	for i := 0; i < 3; i++ {
+		resp := h.Get("/api/test?priority=high")
+		var probe api.TestResponse
+		if err := json.Unmarshal(resp.Body, &probe); err != nil || resp.Status != http.StatusOK {
+			t.Fatalf("probe = %d %s, %v", resp.Status, resp.Body, err)
+		}
+		if !probe.Synthetic || probe.Server == "" || probe.Path != "/lb/probe" {
+			t.Errorf("probe response = %+v", probe)
+		}
+	}
+
+	m := h.App.Metrics
+	if total := m.Metrics.TotalRequests; total != 5 {
+		t.Errorf("real requests = %d, want 5", total)
+	}
+	if stats := m.GetSyntheticStats(); stats == nil || stats.Requests != 3 {
+		t.Errorf("synthetic stats = %+v, want 3 requests", stats)
+	}
+
+	var packets struct {
+		Events []metrics.PacketEvent `json:"events"`
+	}
+	json.Unmarshal(h.Get("/api/packets?synthetic=true").Body, &packets)
+	if len(packets.Events) != 6 { // a dispatch and a completion per probe
+		t.Errorf("synthetic packet events = %d, want 6", len(packets.Events))
+	}
+	for _, evt := range packets.Events {
+		if !evt.Synthetic || evt.Priority != "high" {
+			t.Errorf("packet event %+v not tagged as a high priority probe", evt)
+		}
+	}
 }